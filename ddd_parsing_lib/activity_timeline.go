@@ -0,0 +1,93 @@
+package ddd
+
+import (
+	"time"
+)
+
+// Интервал деятельности водителя: от изменения деятельности до следующего изменения
+// или до конца суток
+type ActivityInterval struct {
+	Start            time.Time    `json:"start"`
+	End              time.Time    `json:"end"`
+	Duration         int          `json:"duration"`
+	ActivityKindId   ActivityKind `json:"activity_kind_id"`
+	ActivityKindName string       `json:"activity_kind_name"`
+	Slot             int          `json:"slot"`
+	Crew             bool         `json:"crew"`
+	CardInserted     bool         `json:"card_inserted"`
+}
+
+// Суммарная продолжительность деятельности каждого вида за сутки в минутах
+type ActivityTotals struct {
+	Driving      int `json:"driving"`
+	Work         int `json:"work"`
+	Availability int `json:"availability"`
+	Rest         int `json:"rest"`
+}
+
+// Количество минут в сутках
+const minutesPerDay = 24 * 60
+
+// Функция преобразует изменения деятельности за сутки в интервалы. Каждое изменение
+// длится до следующего, последнее - до полуночи. Интервалы нулевой длительности
+// (несколько изменений в одну минуту) не включаются. Бит статуса управления означает
+// работу в экипаже только при вставленной карте, при извлеченной карте он указывает,
+// введена ли деятельность вручную.
+func buildActivityIntervals(date time.Time, acis []ActivityChangeInfo) []ActivityInterval {
+	result := []ActivityInterval{}
+
+	for i, aci := range acis {
+		begin := clampDayMinutes(aci.ActivityChangeInfoT)
+		end := minutesPerDay
+		if i+1 < len(acis) {
+			end = clampDayMinutes(acis[i+1].ActivityChangeInfoT)
+		}
+		if end <= begin {
+			continue
+		}
+
+		result = append(result, ActivityInterval{
+			Start:            date.Add(time.Duration(begin) * time.Minute),
+			End:              date.Add(time.Duration(end) * time.Minute),
+			Duration:         end - begin,
+			ActivityKindId:   aci.ActivityKindId,
+			ActivityKindName: aci.ActivityKindId.String(),
+			Slot:             aci.TachographCardReaderId,
+			Crew:             aci.CardPositionId == 0 && aci.StateDrivingId == 1,
+			CardInserted:     aci.CardPositionId == 0,
+		})
+	}
+
+	return result
+}
+
+// Функция ограничивает время изменения деятельности пределами суток
+func clampDayMinutes(minutes int) int {
+	if minutes < 0 {
+		return 0
+	}
+	if minutes > minutesPerDay {
+		return minutesPerDay
+	}
+	return minutes
+}
+
+// Функция подсчитывает суммарную продолжительность деятельности каждого вида
+func calcActivityTotals(intervals []ActivityInterval) ActivityTotals {
+	result := ActivityTotals{}
+
+	for _, interval := range intervals {
+		switch interval.ActivityKindId {
+		case ActivityDriving:
+			result.Driving += interval.Duration
+		case ActivityWork:
+			result.Work += interval.Duration
+		case ActivityAvailability:
+			result.Availability += interval.Duration
+		case ActivityBreakRest:
+			result.Rest += interval.Duration
+		}
+	}
+
+	return result
+}
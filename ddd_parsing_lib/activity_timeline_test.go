@@ -0,0 +1,65 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivityTimeline(t *testing.T) {
+	c, err := ParseBytes(buildDriverDDD())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.ActivityDailyRecords[0]
+	if r.ActivityTotals != (ActivityTotals{Driving: 345, Work: 0, Availability: 270, Rest: 825}) {
+		t.Errorf("unexpected totals %+v", r.ActivityTotals)
+	}
+	if len(r.ActivityIntervals) != 7 {
+		t.Fatalf("expected 7 intervals, got %+v", r.ActivityIntervals)
+	}
+	// последний интервал продолжается до конца суток
+	last := r.ActivityIntervals[len(r.ActivityIntervals)-1]
+	if last.Duration != 420 || !last.End.Equal(r.ActivityRecordDate.AddDate(0, 0, 1)) || last.CardInserted {
+		t.Errorf("unexpected last interval %+v", last)
+	}
+}
+
+func TestBuildActivityIntervalsSameMinute(t *testing.T) {
+	// изменение деятельности в ту же минуту заменяет предыдущую деятельность
+	intervals := buildActivityIntervals(day0, []ActivityChangeInfo{
+		{ActivityKindId: ActivityDriving, ActivityChangeInfoT: 10},
+		{ActivityKindId: ActivityWork, ActivityChangeInfoT: 10},
+		{ActivityKindId: ActivityBreakRest, ActivityChangeInfoT: 20},
+	})
+	if len(intervals) != 2 || intervals[0].ActivityKindId != ActivityWork || intervals[0].Duration != 10 ||
+		!intervals[0].Start.Equal(day0.Add(10*time.Minute)) {
+		t.Errorf("unexpected intervals %+v", intervals)
+	}
+}
+
+func TestBuildActivityIntervalsCrew(t *testing.T) {
+	tests := []struct {
+		stateDriving int
+		cardPosition int
+		crew         bool
+		inserted     bool
+	}{
+		{stateDriving: 0, cardPosition: 0, crew: false, inserted: true},
+		{stateDriving: 1, cardPosition: 0, crew: true, inserted: true},
+		// при извлеченной карте бит означает ручной ввод, а не экипаж
+		{stateDriving: 1, cardPosition: 1, crew: false, inserted: false},
+		{stateDriving: 0, cardPosition: 1, crew: false, inserted: false},
+	}
+	for _, tt := range tests {
+		intervals := buildActivityIntervals(day0, []ActivityChangeInfo{
+			{StateDrivingId: tt.stateDriving, CardPositionId: tt.cardPosition, ActivityKindId: ActivityDriving},
+		})
+		if len(intervals) != 1 || intervals[0].Duration != minutesPerDay {
+			t.Fatalf("unexpected intervals %+v", intervals)
+		}
+		if intervals[0].Crew != tt.crew || intervals[0].CardInserted != tt.inserted {
+			t.Errorf("state %d position %d: crew %v inserted %v", tt.stateDriving, tt.cardPosition,
+				intervals[0].Crew, intervals[0].CardInserted)
+		}
+	}
+}
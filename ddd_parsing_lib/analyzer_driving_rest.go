@@ -0,0 +1,371 @@
+package ddd
+
+import (
+	"time"
+)
+
+// Правило, нарушение которого выводится в отчет: имя и описание
+type ruleText struct {
+	rule        string
+	description string
+}
+
+// Нормы набора правил для проверки времени управления и отдыха в минутах и границы тяжести
+// нарушений для severityByExcess и severityByShortfall. Нулевая норма splitDailyRestFirst
+// означает, что разделенный отдых не допускается, нулевые нормы dutyWorkingTime и weeklyWorkingTime -
+// что рабочее время за период работы и за неделю не проверяется. Если сокращенный еженедельный отдых равен регулярному, сокращение
+// не допускается.
+type drivingRestLimits struct {
+	regulation string
+
+	uninterruptedDriving  int // непрерывное управление
+	breakLen              int // перерыв после непрерывного управления
+	splitBreakFirst       int // первая часть разделенного перерыва
+	splitBreakSecond      int // вторая часть разделенного перерыва
+	uninterruptedSeverity [3]int
+
+	dailyDriving                 int // время управления за период работы
+	dailyDrivingExtended         int // продленное время управления
+	dailyExtensions              int // продлений за календарную неделю
+	dailyDrivingSeverity         [3]int
+	dailyDrivingExtendedSeverity [3]int
+
+	dutyWorkingTime         int // рабочее время за период работы
+	dutyWorkingTimeSeverity [3]int
+
+	regularDailyRest         int
+	reducedDailyRest         int
+	reducedDailyRests        int // сокращенных ежедневных отдыхов между еженедельными
+	splitDailyRestFirst      int // первая часть разделенного ежедневного отдыха
+	regularDailyRestSeverity [2]int
+	reducedDailyRestSeverity [2]int
+
+	regularWeeklyRest          int
+	reducedWeeklyRest          int
+	weeklyRestInterval         int // еженедельный отдых не позднее чем через это время после предыдущего
+	regularWeeklyRestSeverity  [2]int
+	weeklyRestIntervalSeverity [3]int
+
+	weeklyDriving              int
+	fortnightlyDriving         int
+	weeklyDrivingSeverity      [3]int
+	fortnightlyDrivingSeverity [3]int
+
+	weeklyWorkingTime         int // рабочее время за календарную неделю
+	weeklyWorkingTimeSeverity [3]int
+
+	breakRule                    ruleText
+	dailyDrivingExtendedRule     ruleText // превышено продленное время управления
+	dailyExtensionsRule          ruleText // превышено количество продлений за неделю
+	dutyWorkingTimeRule          ruleText
+	dailyRestRule                ruleText
+	reducedDailyRestsRule        ruleText
+	weeklyRestRule               ruleText
+	consecutiveReducedWeeklyRule ruleText
+	weeklyDrivingRule            ruleText
+	fortnightlyDrivingRule       ruleText
+	weeklyWorkingTimeRule        ruleText
+}
+
+// Функция проверяет время управления и отдыха по нормам limits. Периоды без данных считаются
+// отдыхом, интервалы специальных условий conditions накладываются на шкалу деятельности.
+func analyzeDrivingRest(records ActivityDailyRecords, conditions SpecificConditionIntervals,
+	limits drivingRestLimits) ComplianceReport {
+	report := ComplianceReport{
+		Regulation:    limits.regulation,
+		DutyPeriods:   []DutyPeriod{},
+		Infringements: []Infringement{},
+	}
+
+	spans := buildComplianceTimeline(records, conditions)
+	report.Weeks = weeklyTotals(spans)
+	if len(spans) == 0 {
+		return report
+	}
+
+	checkBreaks(spans, limits, &report)
+	checkDutyPeriods(spans, limits, &report)
+	checkWeeks(report.Weeks, limits, &report)
+	report.sortInfringements()
+
+	return report
+}
+
+// Функция проверяет, что после непрерывного управления предоставлен перерыв, который может быть
+// разделен на две части
+func checkBreaks(spans []activitySpan, limits drivingRestLimits, report *ComplianceReport) {
+	for _, b := range uninterruptedDriving(spans, limits.breakLen, limits.splitBreakFirst, limits.splitBreakSecond) {
+		if b.Driving <= limits.uninterruptedDriving {
+			continue
+		}
+		report.add(Infringement{
+			Rule:        limits.breakRule.rule,
+			Description: limits.breakRule.description,
+			Severity:    severityByExcess(b.Driving, limits.uninterruptedSeverity),
+			Begin:       b.Begin,
+			End:         b.End,
+			Limit:       limits.uninterruptedDriving,
+			Actual:      b.Driving,
+		})
+	}
+}
+
+// Функция разбивает деятельность на периоды работы (смены) между ежедневными отдыхами и проверяет
+// время управления и рабочее время каждого периода, ежедневный и еженедельный отдых
+func checkDutyPeriods(spans []activitySpan, limits drivingRestLimits, report *ComplianceReport) {
+	extensions := map[time.Time]int{}
+	reducedDaily := 0
+	var lastWeekly *activitySpan
+	lastWeeklyReduced := false
+
+	// данные начинаются с отдыха, он завершает предыдущий период работы
+	periodBegin := spans[0].Start
+	if spans[0].Kind == ActivityBreakRest {
+		periodBegin = spans[0].End
+		if d := spans[0].minutes(); d >= limits.reducedWeeklyRest {
+			lastWeekly = &spans[0]
+			lastWeeklyReduced = d < limits.regularWeeklyRest
+		}
+	}
+
+	for i := range spans {
+		s := spans[i]
+		d := s.minutes()
+		if s.Kind != ActivityBreakRest || d < limits.reducedDailyRest || !s.Start.After(periodBegin) {
+			continue
+		}
+
+		period := DutyPeriod{
+			Begin:        periodBegin,
+			End:          s.Start,
+			Driving:      sumActivity(spans, ActivityDriving, periodBegin, s.Start),
+			Work:         sumActivity(spans, ActivityWork, periodBegin, s.Start),
+			Availability: sumActivity(spans, ActivityAvailability, periodBegin, s.Start),
+			RestDuration: d,
+		}
+		checkDutyPeriod(period, extensions, limits, report)
+
+		// ежедневный отдых должен быть использован в течение 24 часов после окончания предыдущего
+		inWindow, windowEnd := restInWindow(periodBegin, s)
+		split := limits.splitDailyRestFirst > 0 && hasRestPart(spans, periodBegin, s.Start, limits.splitDailyRestFirst)
+
+		switch {
+		case inWindow < limits.reducedDailyRest:
+			// в отчет выводится самый длинный отдых в течение 24 часов
+			if longest := longestRest(spans, periodBegin, windowEnd); longest > inWindow {
+				inWindow = longest
+			}
+			period.RestType = RestInsufficient
+			report.add(Infringement{
+				Rule:        limits.dailyRestRule.rule,
+				Description: limits.dailyRestRule.description,
+				Severity:    severityByShortfall(inWindow, limits.reducedDailyRestSeverity),
+				Begin:       periodBegin,
+				End:         windowEnd,
+				Limit:       limits.reducedDailyRest,
+				Actual:      inWindow,
+			})
+		case split:
+			period.RestType = RestSplitDaily
+		case inWindow >= limits.regularDailyRest:
+			period.RestType = RestRegularDaily
+		default:
+			period.RestType = RestReducedDaily
+			reducedDaily++
+			if reducedDaily > limits.reducedDailyRests {
+				report.add(Infringement{
+					Rule:        limits.reducedDailyRestsRule.rule,
+					Description: limits.reducedDailyRestsRule.description,
+					Severity:    severityByShortfall(inWindow, limits.regularDailyRestSeverity),
+					Begin:       s.Start,
+					End:         s.End,
+					Limit:       limits.regularDailyRest,
+					Actual:      inWindow,
+				})
+			}
+		}
+
+		if d >= limits.reducedWeeklyRest {
+			reduced := d < limits.regularWeeklyRest
+			if period.RestType != RestInsufficient {
+				period.RestType = RestRegularWeekly
+				if reduced {
+					period.RestType = RestReducedWeekly
+				}
+			}
+			checkWeeklyRestInterval(lastWeekly, s.Start, limits, report)
+			if reduced && lastWeekly != nil && lastWeeklyReduced {
+				report.add(Infringement{
+					Rule:        limits.consecutiveReducedWeeklyRule.rule,
+					Description: limits.consecutiveReducedWeeklyRule.description,
+					Severity:    severityByShortfall(d, limits.regularWeeklyRestSeverity),
+					Begin:       s.Start,
+					End:         s.End,
+					Limit:       limits.regularWeeklyRest,
+					Actual:      d,
+				})
+			}
+			lastWeekly = &spans[i]
+			lastWeeklyReduced = reduced
+			reducedDaily = 0
+		}
+
+		report.DutyPeriods = append(report.DutyPeriods, period)
+		periodBegin = s.End
+	}
+
+	// последний период работы не завершен отдыхом
+	end := spans[len(spans)-1].End
+	if end.After(periodBegin) && sumActivity(spans, ActivityBreakRest, periodBegin, end) < int(end.Sub(periodBegin)/time.Minute) {
+		period := DutyPeriod{
+			Begin:        periodBegin,
+			End:          end,
+			Driving:      sumActivity(spans, ActivityDriving, periodBegin, end),
+			Work:         sumActivity(spans, ActivityWork, periodBegin, end),
+			Availability: sumActivity(spans, ActivityAvailability, periodBegin, end),
+			RestType:     RestNone,
+		}
+		checkDutyPeriod(period, extensions, limits, report)
+		report.DutyPeriods = append(report.DutyPeriods, period)
+	}
+	checkWeeklyRestInterval(lastWeekly, end, limits, report)
+}
+
+// Функция проверяет рабочее время и время управления за период работы: время управления может
+// быть продлено ограниченное число раз в календарную неделю. extensions - количество продлений
+// по календарным неделям.
+func checkDutyPeriod(period DutyPeriod, extensions map[time.Time]int, limits drivingRestLimits, report *ComplianceReport) {
+	working := period.Driving + period.Work + period.Availability
+	if limits.dutyWorkingTime > 0 && working > limits.dutyWorkingTime {
+		report.add(Infringement{
+			Rule:        limits.dutyWorkingTimeRule.rule,
+			Description: limits.dutyWorkingTimeRule.description,
+			Severity:    severityByExcess(working, limits.dutyWorkingTimeSeverity),
+			Begin:       period.Begin,
+			End:         period.End,
+			Limit:       limits.dutyWorkingTime,
+			Actual:      working,
+		})
+	}
+
+	if period.Driving <= limits.dailyDriving {
+		return
+	}
+
+	week := weekStart(period.Begin)
+	extensions[week]++
+	switch {
+	case period.Driving > limits.dailyDrivingExtended:
+		report.add(Infringement{
+			Rule:        limits.dailyDrivingExtendedRule.rule,
+			Description: limits.dailyDrivingExtendedRule.description,
+			Severity:    severityByExcess(period.Driving, limits.dailyDrivingExtendedSeverity),
+			Begin:       period.Begin,
+			End:         period.End,
+			Limit:       limits.dailyDrivingExtended,
+			Actual:      period.Driving,
+		})
+	case extensions[week] > limits.dailyExtensions:
+		report.add(Infringement{
+			Rule:        limits.dailyExtensionsRule.rule,
+			Description: limits.dailyExtensionsRule.description,
+			Severity:    severityByExcess(period.Driving, limits.dailyDrivingSeverity),
+			Begin:       period.Begin,
+			End:         period.End,
+			Limit:       limits.dailyDriving,
+			Actual:      period.Driving,
+		})
+	}
+}
+
+// Функция проверяет, что еженедельный отдых начался не позднее установленного времени
+// после окончания предыдущего еженедельного отдыха
+func checkWeeklyRestInterval(lastWeekly *activitySpan, next time.Time, limits drivingRestLimits, report *ComplianceReport) {
+	if lastWeekly == nil {
+		return
+	}
+
+	interval := int(next.Sub(lastWeekly.End) / time.Minute)
+	if interval <= limits.weeklyRestInterval {
+		return
+	}
+	report.add(Infringement{
+		Rule:        limits.weeklyRestRule.rule,
+		Description: limits.weeklyRestRule.description,
+		Severity:    severityByExcess(interval, limits.weeklyRestIntervalSeverity),
+		Begin:       lastWeekly.End,
+		End:         next,
+		Limit:       limits.weeklyRestInterval,
+		Actual:      interval,
+	})
+}
+
+// Функция проверяет время управления за календарную неделю и за две последовательные недели
+// и рабочее время за календарную неделю
+func checkWeeks(weeks []WeekTotal, limits drivingRestLimits, report *ComplianceReport) {
+	for i, w := range weeks {
+		end := w.Begin.AddDate(0, 0, 7)
+		if w.Driving > limits.weeklyDriving {
+			report.add(Infringement{
+				Rule:        limits.weeklyDrivingRule.rule,
+				Description: limits.weeklyDrivingRule.description,
+				Severity:    severityByExcess(w.Driving, limits.weeklyDrivingSeverity),
+				Begin:       w.Begin,
+				End:         end,
+				Limit:       limits.weeklyDriving,
+				Actual:      w.Driving,
+			})
+		}
+		if limits.weeklyWorkingTime > 0 && w.WorkingTime > limits.weeklyWorkingTime {
+			report.add(Infringement{
+				Rule:        limits.weeklyWorkingTimeRule.rule,
+				Description: limits.weeklyWorkingTimeRule.description,
+				Severity:    severityByExcess(w.WorkingTime, limits.weeklyWorkingTimeSeverity),
+				Begin:       w.Begin,
+				End:         end,
+				Limit:       limits.weeklyWorkingTime,
+				Actual:      w.WorkingTime,
+			})
+		}
+
+		if i == 0 {
+			continue
+		}
+		fortnight := weeks[i-1].Driving + w.Driving
+		if fortnight > limits.fortnightlyDriving {
+			report.add(Infringement{
+				Rule:        limits.fortnightlyDrivingRule.rule,
+				Description: limits.fortnightlyDrivingRule.description,
+				Severity:    severityByExcess(fortnight, limits.fortnightlyDrivingSeverity),
+				Begin:       weeks[i-1].Begin,
+				End:         end,
+				Limit:       limits.fortnightlyDriving,
+				Actual:      fortnight,
+			})
+		}
+	}
+}
+
+// Функция проверяет, есть ли в интервале [begin, end) отдых не короче minutes
+func hasRestPart(spans []activitySpan, begin time.Time, end time.Time, minutes int) bool {
+	for _, s := range spans {
+		if s.Kind == ActivityBreakRest && !s.Start.Before(begin) && !s.End.After(end) && s.minutes() >= minutes {
+			return true
+		}
+	}
+	return false
+}
+
+// Функция возвращает продолжительность самого длинного отдыха в интервале [begin, end)
+func longestRest(spans []activitySpan, begin time.Time, end time.Time) int {
+	result := 0
+	for _, s := range spans {
+		if s.Kind != ActivityBreakRest || !s.End.After(begin) || !s.Start.Before(end) {
+			continue
+		}
+		if d := sumActivity([]activitySpan{s}, ActivityBreakRest, begin, end); d > result {
+			result = d
+		}
+	}
+	return result
+}
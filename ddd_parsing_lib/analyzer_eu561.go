@@ -0,0 +1,99 @@
+package ddd
+
+// Нормы регламента (ЕС) 561/2006 в минутах
+const (
+	eu561DailyDriving         = 9 * 60  // ст. 6(1): ежедневное время управления
+	eu561DailyDrivingExtended = 10 * 60 // ст. 6(1): продленное ежедневное время управления
+	eu561DailyExtensions      = 2       // продлений за календарную неделю
+	eu561WeeklyDriving        = 56 * 60 // ст. 6(2)
+	eu561FortnightlyDriving   = 90 * 60 // ст. 6(3)
+	eu561UninterruptedDriving = 4*60 + 30
+	eu561Break                = 45      // ст. 7: перерыв после 4,5 ч управления
+	eu561SplitBreakFirst      = 15      // разделенный перерыв: первая часть не менее 15 мин
+	eu561SplitBreakSecond     = 30      // вторая часть не менее 30 мин
+	eu561RegularDailyRest     = 11 * 60 // ст. 4(g)
+	eu561ReducedDailyRest     = 9 * 60
+	eu561SplitDailyRestFirst  = 3 * 60
+	eu561ReducedDailyRests    = 3       // сокращенных ежедневных отдыхов между еженедельными
+	eu561RegularWeeklyRest    = 45 * 60 // ст. 4(h)
+	eu561ReducedWeeklyRest    = 24 * 60
+	eu561WeeklyRestInterval   = 6 * 24 * 60 // ст. 8(6): не позднее шести 24-часовых периодов
+)
+
+// Границы тяжести нарушений по приложению III директивы 2006/22/EC (в ред. регламента 2016/403)
+var (
+	eu561DailyDrivingSeverity         = [3]int{10 * 60, 11 * 60, 13*60 + 30}
+	eu561DailyDrivingExtendedSeverity = [3]int{11 * 60, 12 * 60, 15 * 60}
+	eu561WeeklyDrivingSeverity        = [3]int{60 * 60, 65 * 60, 70 * 60}
+	eu561FortnightlyDrivingSeverity   = [3]int{100 * 60, 105 * 60, 112*60 + 30}
+	eu561UninterruptedSeverity        = [3]int{5 * 60, 6 * 60, 6*60 + 45}
+	eu561RegularDailyRestSeverity     = [2]int{10 * 60, 8*60 + 30}
+	eu561ReducedDailyRestSeverity     = [2]int{8 * 60, 7 * 60}
+	eu561RegularWeeklyRestSeverity    = [2]int{42 * 60, 36 * 60}
+	eu561WeeklyRestIntervalSeverity   = [3]int{eu561WeeklyRestInterval + 3*60, eu561WeeklyRestInterval + 12*60, 1 << 30}
+)
+
+// Нормы регламента (ЕС) 561/2006 для проверки времени управления и отдыха
+var eu561Limits = drivingRestLimits{
+	regulation: "EU 561/2006",
+
+	uninterruptedDriving:  eu561UninterruptedDriving,
+	breakLen:              eu561Break,
+	splitBreakFirst:       eu561SplitBreakFirst,
+	splitBreakSecond:      eu561SplitBreakSecond,
+	uninterruptedSeverity: eu561UninterruptedSeverity,
+
+	dailyDriving:                 eu561DailyDriving,
+	dailyDrivingExtended:         eu561DailyDrivingExtended,
+	dailyExtensions:              eu561DailyExtensions,
+	dailyDrivingSeverity:         eu561DailyDrivingSeverity,
+	dailyDrivingExtendedSeverity: eu561DailyDrivingExtendedSeverity,
+
+	regularDailyRest:         eu561RegularDailyRest,
+	reducedDailyRest:         eu561ReducedDailyRest,
+	reducedDailyRests:        eu561ReducedDailyRests,
+	splitDailyRestFirst:      eu561SplitDailyRestFirst,
+	regularDailyRestSeverity: eu561RegularDailyRestSeverity,
+	reducedDailyRestSeverity: eu561ReducedDailyRestSeverity,
+
+	regularWeeklyRest:          eu561RegularWeeklyRest,
+	reducedWeeklyRest:          eu561ReducedWeeklyRest,
+	weeklyRestInterval:         eu561WeeklyRestInterval,
+	regularWeeklyRestSeverity:  eu561RegularWeeklyRestSeverity,
+	weeklyRestIntervalSeverity: eu561WeeklyRestIntervalSeverity,
+
+	weeklyDriving:              eu561WeeklyDriving,
+	fortnightlyDriving:         eu561FortnightlyDriving,
+	weeklyDrivingSeverity:      eu561WeeklyDrivingSeverity,
+	fortnightlyDrivingSeverity: eu561FortnightlyDrivingSeverity,
+
+	breakRule: ruleText{"EU561_BREAK", "Uninterrupted driving exceeds 4.5 hours without a break (Art. 7)"},
+	dailyDrivingExtendedRule: ruleText{"EU561_DAILY_DRIVING",
+		"Daily driving time exceeds 10 hours (Art. 6(1))"},
+	dailyExtensionsRule: ruleText{"EU561_DAILY_DRIVING",
+		"Daily driving time exceeds 9 hours more than twice a week (Art. 6(1))"},
+	dailyRestRule: ruleText{"EU561_DAILY_REST", "Daily rest shorter than 9 hours within 24 hours (Art. 8(2))"},
+	reducedDailyRestsRule: ruleText{"EU561_REDUCED_DAILY_REST_COUNT",
+		"More than 3 reduced daily rests between two weekly rests (Art. 8(4))"},
+	weeklyRestRule: ruleText{"EU561_WEEKLY_REST", "Weekly rest not taken within six 24-hour periods (Art. 8(6))"},
+	consecutiveReducedWeeklyRule: ruleText{"EU561_CONSECUTIVE_REDUCED_WEEKLY_REST",
+		"Two consecutive reduced weekly rests (Art. 8(6))"},
+	weeklyDrivingRule: ruleText{"EU561_WEEKLY_DRIVING", "Weekly driving time exceeds 56 hours (Art. 6(2))"},
+	fortnightlyDrivingRule: ruleText{"EU561_FORTNIGHTLY_DRIVING",
+		"Driving time in two consecutive weeks exceeds 90 hours (Art. 6(3))"},
+}
+
+// Функция проверяет соблюдение режима труда и отдыха по регламенту (ЕС) 561/2006:
+// ежедневное, еженедельное и двухнедельное время управления, перерывы (в том числе
+// разделенные 15+30 мин), ежедневный (регулярный, сокращенный, разделенный) и еженедельный отдых.
+// Периоды без данных считаются отдыхом, интервалы специальных условий conditions (вне сферы
+// действия, паром/поезд) накладываются на шкалу деятельности. Правила экипажа из нескольких
+// водителей и компенсации сокращенного еженедельного отдыха не учитываются.
+func AnalyzeEU561(records ActivityDailyRecords, conditions ...SpecificConditionInterval) ComplianceReport {
+	return analyzeDrivingRest(records, conditions, eu561Limits)
+}
+
+// Метод проверяет деятельность карты по регламенту (ЕС) 561/2006
+func (c *Card) AnalyzeEU561() ComplianceReport {
+	return AnalyzeEU561(c.activityRecords(), c.specificConditions()...)
+}
@@ -0,0 +1,102 @@
+package ddd
+
+import (
+	"testing"
+)
+
+// Рабочий день: управление 06:00-10:30, перерыв 45 мин, управление 11:15-15:45 (9 ч)
+var nineHourDay = [][2]int{{0, 0}, {3, 360}, {0, 630}, {3, 675}, {0, 945}}
+
+func TestAnalyzeEU561(t *testing.T) {
+	// понедельник-четверг работа 06:00-20:00, отдых 10 ч; пятница работа 06:00-14:00
+	reducedRests := append(workDays(monday, 4, 7, [2]int{0, 0}, [2]int{2, 360}, [2]int{0, 1200}),
+		dayRecord(monday.AddDate(0, 0, 4), [2]int{0, 0}, [2]int{2, 360}, [2]int{0, 840}),
+		dayRecord(monday.AddDate(0, 0, 5), [2]int{0, 0}),
+		dayRecord(monday.AddDate(0, 0, 6), [2]int{0, 0}))
+
+	// три дня отдыха, затем 9 дней по 10 ч управления без еженедельного отдыха
+	tenHourDays := append(workDays(monday.AddDate(0, 0, -3), 3, 0),
+		workDays(monday, 9, 7, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 630}, [2]int{3, 675}, [2]int{0, 945},
+			[2]int{3, 990}, [2]int{0, 1050})...)
+
+	tests := []struct {
+		name     string
+		records  ActivityDailyRecords
+		expected map[string]int
+		details  map[string]expectedInfringement
+	}{
+		{
+			name:     "regular weeks",
+			records:  workDays(monday, 14, 5, nineHourDay...),
+			expected: map[string]int{},
+		},
+		{
+			// 5 ч управления, перерыв 45 мин, 2 ч управления, разделенный перерыв 15 + 30 мин,
+			// 4 ч управления (всего 11 ч), затем отдых 8 ч
+			name: "break, daily driving and daily rest",
+			records: ActivityDailyRecords{
+				dayRecord(monday, [2]int{0, 0}, [2]int{3, 300}, [2]int{0, 600}, [2]int{3, 645}, [2]int{0, 765},
+					[2]int{2, 780}, [2]int{0, 800}, [2]int{3, 830}, [2]int{0, 1070}, [2]int{2, 1080}, [2]int{0, 1200}),
+				dayRecord(monday.AddDate(0, 0, 1), [2]int{0, 0}, [2]int{2, 240}, [2]int{0, 600}),
+			},
+			expected: map[string]int{"EU561_BREAK": 1, "EU561_DAILY_DRIVING": 1, "EU561_DAILY_REST": 1},
+			details: map[string]expectedInfringement{
+				"EU561_BREAK":         {300, SeveritySerious},
+				"EU561_DAILY_DRIVING": {660, SeveritySerious},
+				"EU561_DAILY_REST":    {480, SeverityMinor},
+			},
+		},
+		{
+			name:     "reduced daily rests",
+			records:  reducedRests,
+			expected: map[string]int{"EU561_REDUCED_DAILY_REST_COUNT": 1},
+			details: map[string]expectedInfringement{
+				"EU561_REDUCED_DAILY_REST_COUNT": {600, SeverityMinor},
+			},
+		},
+		{
+			name:    "daily extensions, weekly driving and weekly rest",
+			records: tenHourDays,
+			expected: map[string]int{"EU561_DAILY_DRIVING": 5, "EU561_WEEKLY_DRIVING": 1,
+				"EU561_WEEKLY_REST": 1},
+			details: map[string]expectedInfringement{
+				"EU561_DAILY_DRIVING":  {600, SeveritySerious},
+				"EU561_WEEKLY_DRIVING": {70 * 60, SeverityMostSerious},
+				"EU561_WEEKLY_REST":    {210 * 60, SeverityVerySerious},
+			},
+		},
+		{
+			// 6 дней по 9 ч управления: 108 ч за две недели, еженедельный отдых только сокращенный
+			name:    "fortnightly driving and consecutive reduced weekly rests",
+			records: workDays(monday, 14, 6, nineHourDay...),
+			expected: map[string]int{"EU561_FORTNIGHTLY_DRIVING": 1,
+				"EU561_CONSECUTIVE_REDUCED_WEEKLY_REST": 1},
+			details: map[string]expectedInfringement{
+				"EU561_FORTNIGHTLY_DRIVING":             {108 * 60, SeverityVerySerious},
+				"EU561_CONSECUTIVE_REDUCED_WEEKLY_REST": {32*60 + 15, SeverityVerySerious},
+			},
+		},
+	}
+	for _, tt := range tests {
+		checkInfringements(t, tt.name, AnalyzeEU561(tt.records), tt.expected, tt.details)
+	}
+}
+
+func TestAnalyzeEU561DutyPeriods(t *testing.T) {
+	report := AnalyzeEU561(workDays(monday, 14, 5, nineHourDay...))
+	if len(report.Weeks) != 2 || report.Weeks[0].Driving != 5*9*60 {
+		t.Errorf("unexpected weeks %+v", report.Weeks)
+	}
+	if len(report.DutyPeriods) != 10 {
+		t.Fatalf("expected 10 duty periods, got %d", len(report.DutyPeriods))
+	}
+	for i, p := range report.DutyPeriods {
+		expected := RestRegularDaily
+		if i%5 == 4 {
+			expected = RestRegularWeekly
+		}
+		if p.Driving != 9*60 || p.RestType != expected {
+			t.Errorf("period %d: driving %d, rest %s", i, p.Driving, p.RestType)
+		}
+	}
+}
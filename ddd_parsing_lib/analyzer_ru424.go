@@ -0,0 +1,132 @@
+package ddd
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Нормы приказа Минтранса России от 16.10.2020 № 424 "Об утверждении Особенностей режима
+// рабочего времени и времени отдыха, условий труда водителей автомобилей" в минутах
+const (
+	ru424ShiftDriving         = 9 * 60  // время управления в течение смены
+	ru424ShiftDrivingExtended = 10 * 60 // продленное время управления
+	ru424ShiftExtensions      = 2       // продлений за календарную неделю
+	ru424WeeklyDriving        = 56 * 60
+	ru424FortnightlyDriving   = 90 * 60
+	ru424UninterruptedDriving = 4*60 + 30
+	ru424Break                = 45      // специальный перерыв после 4,5 ч непрерывного управления
+	ru424SplitBreakFirst      = 15      // перерыв может быть разделен: первая часть не менее 15 мин
+	ru424SplitBreakSecond     = 30      // вторая часть не менее 30 мин
+	ru424ShiftWorkingTime     = 12 * 60 // продолжительность смены при суммированном учете
+	ru424WeeklyWorkingTime    = 40 * 60 // нормальная продолжительность рабочего времени
+	ru424DailyRest            = 11 * 60 // ежедневный (междусменный) отдых
+	ru424ReducedDailyRest     = 9 * 60
+	ru424ReducedDailyRests    = 3       // сокращенных отдыхов между еженедельными
+	ru424WeeklyRest           = 42 * 60 // еженедельный непрерывный отдых
+	ru424WeeklyRestInterval   = 6 * 24 * 60
+)
+
+// Нормы приказа № 424 для проверки времени управления и отдыха. Приказ не классифицирует нарушения
+// по тяжести, поэтому границы тяжести определяются по отклонению от нормы. Сокращение еженедельного
+// отдыха и разделение междусменного отдыха приказом не предусмотрены.
+var ru424Limits = drivingRestLimits{
+	regulation: "RU Mintrans Order 424",
+
+	uninterruptedDriving:  ru424UninterruptedDriving,
+	breakLen:              ru424Break,
+	splitBreakFirst:       ru424SplitBreakFirst,
+	splitBreakSecond:      ru424SplitBreakSecond,
+	uninterruptedSeverity: excessLimits(ru424UninterruptedDriving),
+
+	dailyDriving:                 ru424ShiftDriving,
+	dailyDrivingExtended:         ru424ShiftDrivingExtended,
+	dailyExtensions:              ru424ShiftExtensions,
+	dailyDrivingSeverity:         excessLimits(ru424ShiftDriving),
+	dailyDrivingExtendedSeverity: excessLimits(ru424ShiftDrivingExtended),
+
+	dutyWorkingTime:         ru424ShiftWorkingTime,
+	dutyWorkingTimeSeverity: excessLimits(ru424ShiftWorkingTime),
+
+	regularDailyRest:         ru424DailyRest,
+	reducedDailyRest:         ru424ReducedDailyRest,
+	reducedDailyRests:        ru424ReducedDailyRests,
+	regularDailyRestSeverity: shortfallLimits(ru424DailyRest),
+	reducedDailyRestSeverity: shortfallLimits(ru424ReducedDailyRest),
+
+	regularWeeklyRest:          ru424WeeklyRest,
+	reducedWeeklyRest:          ru424WeeklyRest,
+	weeklyRestInterval:         ru424WeeklyRestInterval,
+	weeklyRestIntervalSeverity: excessLimits(ru424WeeklyRestInterval),
+
+	weeklyDriving:              ru424WeeklyDriving,
+	fortnightlyDriving:         ru424FortnightlyDriving,
+	weeklyDrivingSeverity:      excessLimits(ru424WeeklyDriving),
+	fortnightlyDrivingSeverity: excessLimits(ru424FortnightlyDriving),
+
+	weeklyWorkingTime:         ru424WeeklyWorkingTime,
+	weeklyWorkingTimeSeverity: excessLimits(ru424WeeklyWorkingTime),
+
+	breakRule:                ruleText{"RU424_CONTINUOUS_DRIVING", "Continuous driving exceeds 4.5 hours without a special break"},
+	dailyDrivingExtendedRule: ruleText{"RU424_SHIFT_DRIVING", "Driving time per shift exceeds 10 hours"},
+	dailyExtensionsRule: ruleText{"RU424_SHIFT_DRIVING",
+		"Driving time per shift exceeds 9 hours more than twice a week"},
+	dutyWorkingTimeRule: ruleText{"RU424_SHIFT_WORKING_TIME", "Shift working time exceeds 12 hours"},
+	dailyRestRule:       ruleText{"RU424_DAILY_REST", "Daily rest shorter than 9 hours within 24 hours"},
+	reducedDailyRestsRule: ruleText{"RU424_REDUCED_DAILY_REST_COUNT",
+		"Daily rest reduced to less than 11 hours more than 3 times between weekly rests"},
+	weeklyRestRule: ruleText{"RU424_WEEKLY_REST",
+		"Weekly rest of at least 42 hours not taken within six 24-hour periods"},
+	weeklyDrivingRule:      ruleText{"RU424_WEEKLY_DRIVING", "Weekly driving time exceeds 56 hours"},
+	fortnightlyDrivingRule: ruleText{"RU424_FORTNIGHTLY_DRIVING", "Driving time in two consecutive weeks exceeds 90 hours"},
+	weeklyWorkingTimeRule:  ruleText{"RU424_WEEKLY_WORKING_TIME", "Weekly working time exceeds 40 hours"},
+}
+
+// Настройки проверки по приказу Минтранса России № 424
+type RU424Options struct {
+	// Норма рабочего времени за календарную неделю в минутах. По умолчанию 40 ч; для водителей,
+	// которым установлена сокращенная продолжительность рабочего времени, задается меньшая норма.
+	WeeklyWorkingTime int
+}
+
+// Функция возвращает настройки, в которых незаданные значения заменены значениями по умолчанию
+func (o RU424Options) withDefaults() RU424Options {
+	if o.WeeklyWorkingTime <= 0 {
+		o.WeeklyWorkingTime = ru424WeeklyWorkingTime
+	}
+	return o
+}
+
+// Функция проверяет соблюдение режима труда и отдыха по приказу Минтранса России № 424
+// с нормами по умолчанию (см. AnalyzeRU424WithOptions)
+func AnalyzeRU424(records ActivityDailyRecords, conditions ...SpecificConditionInterval) ComplianceReport {
+	return AnalyzeRU424WithOptions(records, RU424Options{}, conditions...)
+}
+
+// Функция проверяет соблюдение режима труда и отдыха по приказу Минтранса России № 424:
+// непрерывное управление и специальные перерывы, время управления за смену, неделю и две недели,
+// продолжительность смены и рабочее время за неделю (норма задается в opts), ежедневный
+// (междусменный) и еженедельный отдых. Рабочим временем считается управление, работа и готовность.
+// Смены разделяются отдыхом не менее 9 ч, периоды без данных считаются отдыхом, интервалы
+// специальных условий conditions накладываются на шкалу деятельности. Приказ не классифицирует
+// нарушения по тяжести, поэтому она определяется по отклонению от нормы: до 10% - незначительное,
+// до 20% - серьезное, до 50% - очень серьезное, больше - наиболее серьезное.
+func AnalyzeRU424WithOptions(records ActivityDailyRecords, opts RU424Options, conditions ...SpecificConditionInterval) ComplianceReport {
+	opts = opts.withDefaults()
+	limits := ru424Limits
+	limits.weeklyWorkingTime = opts.WeeklyWorkingTime
+	limits.weeklyWorkingTimeSeverity = excessLimits(opts.WeeklyWorkingTime)
+	limits.weeklyWorkingTimeRule.description = fmt.Sprintf("Weekly working time exceeds %s hours",
+		strconv.FormatFloat(float64(opts.WeeklyWorkingTime)/60, 'f', -1, 64))
+
+	return analyzeDrivingRest(records, conditions, limits)
+}
+
+// Метод проверяет деятельность карты по приказу Минтранса России № 424
+func (c *Card) AnalyzeRU424() ComplianceReport {
+	return AnalyzeRU424(c.activityRecords(), c.specificConditions()...)
+}
+
+// Метод проверяет деятельность карты по приказу Минтранса России № 424 с настройками opts
+func (c *Card) AnalyzeRU424WithOptions(opts RU424Options) ComplianceReport {
+	return AnalyzeRU424WithOptions(c.activityRecords(), opts, c.specificConditions()...)
+}
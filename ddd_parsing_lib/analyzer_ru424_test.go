@@ -0,0 +1,144 @@
+package ddd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeRU424(t *testing.T) {
+	tests := []struct {
+		name     string
+		records  ActivityDailyRecords
+		expected map[string]int
+		details  map[string]expectedInfringement
+	}{
+		{
+			// управление 08:00-12:00 и 12:45-16:45, выходные - суббота и воскресенье
+			name: "regular weeks",
+			records: workDays(monday, 14, 5, [2]int{0, 0}, [2]int{3, 480}, [2]int{0, 720}, [2]int{3, 765},
+				[2]int{0, 1005}),
+			expected: map[string]int{},
+		},
+		{
+			// каждый день 5 ч непрерывного управления, перерыв 45 мин, 4 ч управления и 4 ч работы
+			name: "continuous driving, shift working time and weekly driving",
+			records: workDays(monday, 7, 7, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 660}, [2]int{3, 705},
+				[2]int{2, 945}, [2]int{0, 1185}),
+			expected: map[string]int{"RU424_CONTINUOUS_DRIVING": 7, "RU424_SHIFT_WORKING_TIME": 7,
+				"RU424_WEEKLY_DRIVING": 1, "RU424_WEEKLY_WORKING_TIME": 1, "RU424_REDUCED_DAILY_REST_COUNT": 3},
+			details: map[string]expectedInfringement{
+				"RU424_CONTINUOUS_DRIVING":       {300, SeveritySerious},
+				"RU424_SHIFT_WORKING_TIME":       {780, SeverityMinor},
+				"RU424_WEEKLY_DRIVING":           {63 * 60, SeveritySerious},
+				"RU424_WEEKLY_WORKING_TIME":      {91 * 60, SeverityMostSerious},
+				"RU424_REDUCED_DAILY_REST_COUNT": {10*60 + 15, SeverityMinor},
+			},
+		},
+		{
+			// после трех дней отдыха 13 дней работы 08:00-15:00: отдыха 42 ч нет
+			name: "weekly rest",
+			records: append(workDays(monday.AddDate(0, 0, -3), 3, 0),
+				workDays(monday, 13, 7, [2]int{0, 0}, [2]int{2, 480}, [2]int{0, 900})...),
+			expected: map[string]int{"RU424_WEEKLY_REST": 1, "RU424_WEEKLY_WORKING_TIME": 2},
+			details: map[string]expectedInfringement{
+				"RU424_WEEKLY_REST": {13*24*60 - 8*60, SeverityMostSerious},
+			},
+		},
+		{
+			// отдых 32 ч в выходные не является еженедельным: сокращение еженедельного отдыха
+			// приказом не предусмотрено
+			name: "no reduced weekly rest",
+			records: append(workDays(monday.AddDate(0, 0, -3), 3, 0),
+				workDays(monday, 14, 6, [2]int{0, 0}, [2]int{2, 480}, [2]int{0, 960})...),
+			expected: map[string]int{"RU424_WEEKLY_REST": 1, "RU424_WEEKLY_WORKING_TIME": 2},
+		},
+		{
+			// пять дней работы 08:00-17:00: 45 ч при норме 40 ч в неделю
+			name:     "weekly working time",
+			records:  workDays(monday, 14, 5, [2]int{0, 0}, [2]int{2, 480}, [2]int{0, 1020}),
+			expected: map[string]int{"RU424_WEEKLY_WORKING_TIME": 2},
+			details: map[string]expectedInfringement{
+				"RU424_WEEKLY_WORKING_TIME": {45 * 60, SeveritySerious},
+			},
+		},
+	}
+	for _, tt := range tests {
+		checkInfringements(t, tt.name, AnalyzeRU424(tt.records), tt.expected, tt.details)
+	}
+}
+
+func TestAnalyzeRU424WeeklyWorkingTime(t *testing.T) {
+	// пять дней работы 08:00-17:00: 45 ч в неделю
+	records := workDays(monday, 14, 5, [2]int{0, 0}, [2]int{2, 480}, [2]int{0, 1020})
+
+	tests := []struct {
+		name     string
+		opts     RU424Options
+		expected map[string]int
+		details  map[string]expectedInfringement
+	}{
+		{name: "default limit", expected: map[string]int{"RU424_WEEKLY_WORKING_TIME": 2}},
+		{name: "increased limit", opts: RU424Options{WeeklyWorkingTime: 45 * 60}, expected: map[string]int{}},
+		{
+			name:     "reduced limit",
+			opts:     RU424Options{WeeklyWorkingTime: 36 * 60},
+			expected: map[string]int{"RU424_WEEKLY_WORKING_TIME": 2},
+			details: map[string]expectedInfringement{
+				"RU424_WEEKLY_WORKING_TIME": {45 * 60, SeverityVerySerious},
+			},
+		},
+	}
+	for _, tt := range tests {
+		checkInfringements(t, tt.name, AnalyzeRU424WithOptions(records, tt.opts), tt.expected, tt.details)
+	}
+
+	report := AnalyzeRU424WithOptions(records, RU424Options{WeeklyWorkingTime: 37*60 + 30})
+	if len(report.Infringements) == 0 || report.Infringements[0].Limit != 37*60+30 ||
+		report.Infringements[0].Description != "Weekly working time exceeds 37.5 hours" {
+		t.Errorf("unexpected infringements %+v", report.Infringements)
+	}
+
+	c, err := ParseBytesWithOptions(buildDriverDDD(), ParseOptions{Ruleset: RulesetRU424,
+		RU424: &RU424Options{WeeklyWorkingTime: 60}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Compliance == nil || c.Compliance.Regulation != "RU Mintrans Order 424" {
+		t.Fatalf("unexpected compliance report %+v", c.Compliance)
+	}
+	found := false
+	for _, i := range c.Compliance.Infringements {
+		found = found || (i.Rule == "RU424_WEEKLY_WORKING_TIME" && i.Limit == 60)
+	}
+	if !found {
+		t.Errorf("weekly working time limit is not applied: %+v", c.Compliance.Infringements)
+	}
+}
+
+func TestAnalyzeCompliance(t *testing.T) {
+	if _, err := AnalyzeCompliance(nil, "foo"); err == nil || Ruleset("foo").Valid() || !RulesetRU424.Valid() {
+		t.Error("expected error for unknown ruleset")
+	}
+
+	c, err := ParseBytesWithOptions(buildDriverDDD(), ParseOptions{Ruleset: RulesetRU424})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Compliance == nil || c.Compliance.Regulation != "RU Mintrans Order 424" {
+		t.Fatalf("unexpected compliance report %+v", c.Compliance)
+	}
+	js, _ := json.Marshal(c)
+	if !strings.Contains(string(js), `"Compliance":{"regulation"`) {
+		t.Errorf("compliance report not found in %s", js)
+	}
+
+	c, _ = ParseBytes(buildDriverDDD())
+	js, _ = json.Marshal(c)
+	if c.Compliance != nil || strings.Contains(string(js), "Compliance") {
+		t.Error("unexpected compliance report")
+	}
+	if _, err := ParseBytesWithOptions(buildDriverDDD(), ParseOptions{Ruleset: "x"}); err == nil {
+		t.Error("expected error for unknown ruleset")
+	}
+}
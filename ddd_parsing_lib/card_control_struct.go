@@ -0,0 +1,76 @@
+package ddd
+
+import (
+	"time"
+)
+
+// Структуры контрольной карты и карты предприятия первого поколения (приложение 1B)
+
+// Данные владельца контрольной карты (EF_Identification)
+type ControlCardHolder struct {
+	ControlBodyName             string `tlv:"0520 36 65 string" json:"control_body_name"`
+	ControlBodyAddress          string `tlv:"0520 36 101 string" json:"control_body_address"`
+	HolderSurname               string `tlv:"0520 36 137 string" json:"holder_surname"`
+	HolderFirstNames            string `tlv:"0520 36 173 string" json:"holder_first_names"`
+	CardHolderPreferredLanguage string `tlv:"0520 2 209 string" json:"card_holder_preferred_language"`
+}
+
+// Запись о проведенном контроле (EF_Controller_Activity_Data)
+type ControlActivityRecord struct {
+	ControlType                   ControlType   `tlv:"050C 1 0 int" json:"control_type"`
+	ControlTypeName               string        `code:"ControlType" json:"control_type_name"`
+	ControlTime                   time.Time     `tlv:"050C 4 1 date" json:"control_time"`
+	ControlledCardType            EquipmentType `tlv:"050C 1 5 int" json:"controlled_card_type"`
+	ControlledCardTypeName        string        `code:"ControlledCardType" json:"controlled_card_type_name"`
+	ControlledCardIssuingNation   int           `tlv:"050C 1 6 int" json:"controlled_card_issuing_nation"`
+	ControlledCardNumber          string        `tlv:"050C 16 7 string" json:"controlled_card_number"`
+	ControlledVehicleNation       int           `tlv:"050C 1 23 int" json:"controlled_vehicle_nation"`
+	ControlledVehicleRegistration string        `tlv:"050C 14 24 string" json:"controlled_vehicle_registration"`
+	ControlDownloadPeriodBegin    time.Time     `tlv:"050C 4 38 date" json:"control_download_period_begin"`
+	ControlDownloadPeriodEnd      time.Time     `tlv:"050C 4 42 date" json:"control_download_period_end"`
+}
+
+type ControlActivityRecords []ControlActivityRecord
+
+type ControlCard struct {
+	Holder                 ControlCardHolder
+	ControlActivityRecords ControlActivityRecords
+}
+
+// Данные владельца карты предприятия (EF_Identification)
+type CompanyCardHolder struct {
+	CompanyName                 string `tlv:"0520 36 65 string" json:"company_name"`
+	CompanyAddress              string `tlv:"0520 36 101 string" json:"company_address"`
+	CardHolderPreferredLanguage string `tlv:"0520 2 137 string" json:"card_holder_preferred_language"`
+}
+
+// Запись о действии с картой предприятия (EF_Company_Activity_Data):
+// выгрузка данных, блокировка или разблокировка ВБУ
+type CompanyActivityRecord struct {
+	CompanyActivityType          int         `tlv:"050C 1 0 int" json:"company_activity_type"`
+	CompanyActivityTime          time.Time   `tlv:"050C 4 1 date" json:"company_activity_time"`
+	CardType                     int         `tlv:"050C 1 5 int" json:"card_type"`
+	CardIssuingNation            int         `tlv:"050C 1 6 int" json:"card_issuing_nation"`
+	CardNumber                   string      `tlv:"050C 16 7 string" json:"card_number"`
+	VehicleRegistrationNation    int         `tlv:"050C 1 23 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `tlv:"050C 14 24 string" json:"vehicle_registration_number"`
+	DownloadPeriodBegin          time.Time   `tlv:"050C 4 38 date" json:"download_period_begin"`
+	DownloadPeriodEnd            time.Time   `tlv:"050C 4 42 date" json:"download_period_end"`
+}
+
+type CompanyActivityRecords []CompanyActivityRecord
+
+type CompanyCard struct {
+	Holder                 CompanyCardHolder
+	CompanyActivityRecords CompanyActivityRecords
+}
+
+// Названия секций (EF) контрольной карты и карты предприятия
+var controlSectionNames = map[string]string{
+	"050C": "EF_Controller_Activity_Data",
+}
+
+var companySectionNames = map[string]string{
+	"050C": "EF_Company_Activity_Data",
+}
@@ -0,0 +1,61 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+// Функция формирует выгрузку карты контролера (cardType 3) или предприятия (cardType 4)
+// с двумя записями деятельности
+func buildControlDDD(cardType byte) []byte {
+	var out []byte
+	out = append(out, tlv([]byte{0x00, 0x02, 0x00}, make([]byte, 25))...)
+	out = append(out, tlv([]byte{0x00, 0x05, 0x00}, []byte{1, 2, 3, 4, 5, 6, 7, 8})...)
+	out = append(out, tlv([]byte{0x05, 0x01, 0x00}, []byte{cardType, 0x00, 0x02, 0x00, 0xE6})...)
+	ident := make([]byte, 211)
+	ident[0] = 0x11
+	copy(ident[1:], strField("C1234567890", 16))
+	copy(ident[65:], append([]byte{0x01}, strField("POLICE", 35)...))
+	copy(ident[101:], append([]byte{0x01}, strField("STREET 5", 35)...))
+	copy(ident[137:], append([]byte{0x01}, strField("OFFICER", 35)...))
+	out = append(out, tlv([]byte{0x05, 0x20, 0x00}, ident)...)
+	act := make([]byte, 2+46*5)
+	act[1] = 1
+	for i := 0; i < 2; i++ {
+		r := act[2+46*i:]
+		r[0] = byte(i + 1)
+		copy(r[1:], ts(day0.Add(time.Duration(i)*time.Hour)))
+		r[5] = 1
+		r[6] = 0x11
+		copy(r[7:], strField("D1234567890", 16))
+		r[23] = 0x11
+		copy(r[24:], append([]byte{0x01}, strField("AB123", 13)...))
+		copy(r[38:], ts(day0.AddDate(0, 0, -28)))
+		copy(r[42:], ts(day0))
+	}
+	out = append(out, tlv([]byte{0x05, 0x0C, 0x00}, act)...)
+	return out
+}
+
+func TestParseControlAndCompanyCards(t *testing.T) {
+	c, err := ParseBytes(buildControlDDD(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Control == nil || c.Control.Holder.ControlBodyName != "POLICE" || len(c.Control.ControlActivityRecords) != 2 ||
+		c.Control.ControlActivityRecords[1].ControlledCardNumber != "D1234567890" || c.Control.ControlActivityRecords[0].ControlledVehicleRegistration != "AB123" {
+		t.Fatalf("unexpected control card data %+v", c.Control)
+	}
+	c, err = ParseBytes(buildControlDDD(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Company == nil || c.Company.Holder.CompanyName != "POLICE" || len(c.Company.CompanyActivityRecords) != 2 ||
+		c.Company.CompanyActivityRecords[1].CompanyActivityType != 2 || c.Company.CompanyActivityRecords[0].DownloadPeriodEnd != day0 {
+		t.Fatalf("unexpected company card data %+v", c.Company)
+	}
+	s, _ := c.Report.Section("050C")
+	if s.Status != SectionDecoded || s.Name != "EF_Company_Activity_Data" {
+		t.Errorf("unexpected company activity section %+v", s)
+	}
+}
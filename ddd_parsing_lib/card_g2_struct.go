@@ -0,0 +1,267 @@
+package ddd
+
+import (
+	"strings"
+	"time"
+)
+
+// Структуры приложения Tachograph_G2 карты второго поколения (приложение 1C).
+// В ddd файле данные приложения G2 хранятся под тэгами с третьим байтом 0x02,
+// перед загрузкой они приводятся к виду "XXXX" (см. gen2Records), поэтому тэги
+// в подсказках `tlv` совпадают с номерами EF.
+
+type ApplicationIdentificationG2 struct {
+	TypeOfTachographCardId       EquipmentType `tlv:"0501 1 0 int" json:"type_of_tachograph_card_id"`
+	TypeOfTachographCardName     string        `code:"TypeOfTachographCardId" json:"type_of_tachograph_card_name"`
+	CardStructureVersion         string        `tlv:"0501 2 1 hexadecimal" json:"card_structure_version"`
+	NoOfEventsPerType            int           `tlv:"0501 1 3 int" json:"no_of_events_per_type"`
+	NoOfFaultsPerType            int           `tlv:"0501 1 4 int" json:"no_of_faults_per_type"`
+	ActivityStructureLength      int           `tlv:"0501 2 5 int" json:"activity_structure_length"`
+	NoOfCardVehicleRecords       int           `tlv:"0501 2 7 int" json:"no_of_card_vehicle_records"`
+	NoOfCardPlaceRecords         int           `tlv:"0501 2 9 int" json:"no_of_card_place_records"`
+	NoOfGNSSADRecords            int           `tlv:"0501 2 11 int" json:"no_of_gnss_ad_records"`
+	NoOfSpecificConditionRecords int           `tlv:"0501 2 13 int" json:"no_of_specific_condition_records"`
+	NoOfCardVehicleUnitRecords   int           `tlv:"0501 2 15 int" json:"no_of_card_vehicle_unit_records"`
+}
+
+// Дополнительные сведения о приложении карты версии 2 (EF_Application_Identification_V2)
+type ApplicationIdentificationV2 struct {
+	NoOfBorderCrossingRecords  int `tlv:"0525 2 0 int" json:"no_of_border_crossing_records"`
+	NoOfLoadUnloadRecords      int `tlv:"0525 2 2 int" json:"no_of_load_unload_records"`
+	NoOfLoadTypeEntryRecords   int `tlv:"0525 2 4 int" json:"no_of_load_type_entry_records"`
+	VuConfigurationLengthRange int `tlv:"0525 1 6 int" json:"vu_configuration_length_range"`
+}
+
+type CardInfoG2 struct {
+	CardNumber                string      `tlv:"0520 16 1 string" json:"card_number"`
+	CardIssuingMemberState    int         `tlv:"0520 1 0 int" json:"card_issuing_member_state"`
+	CardIssuingMemberStateIso *NationInfo `iso:"CardIssuingMemberState" json:"card_issuing_member_state_iso,omitempty"`
+	CardIssuingAuthorityName  string      `tlv:"0520 36 17 string" json:"card_issuing_authority_name"`
+	CardIssueDate             time.Time   `tlv:"0520 4 53 date" json:"card_issue_date"`
+	CardValidityBegin         time.Time   `tlv:"0520 4 57 date" json:"card_validity_begin"`
+	CardExpiryDate            time.Time   `tlv:"0520 4 61 date" json:"card_expiry_date"`
+	LastCardDownload          time.Time   `tlv:"050E 4 0 date 0" json:"last_card_download"`
+	CardCertificate           string      `tlv:"C100 -1 0 hexadecimal 0" json:"card_certificate,omitempty"`
+	CardSignCertificate       string      `tlv:"C101 -1 0 hexadecimal 0" json:"card_sign_certificate,omitempty"`
+	CACertificate             string      `tlv:"C108 -1 0 hexadecimal 0" json:"ca_certificate,omitempty"`
+	LinkCertificate           string      `tlv:"C109 -1 0 hexadecimal 0" json:"link_certificate,omitempty"`
+}
+
+type CardVehicleRecordG2 struct {
+	VehicleOdometerBegin         int         `tlv:"0505 3 0 int" json:"vehicle_odometer_begin"`
+	VehicleOdometerEnd           int         `tlv:"0505 3 3 int" json:"vehicle_odometer_end"`
+	VehicleFirstUse              time.Time   `tlv:"0505 4 6 date" json:"vehicle_first_use"`
+	VehicleLastUse               time.Time   `tlv:"0505 4 10 date" json:"vehicle_last_use"`
+	VehicleRegistrationNation    int         `tlv:"0505 1 14 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `tlv:"0505 14 15 string" json:"vehicle_registration_number"`
+	VuDataBlockCounter           string      `tlv:"0505 2 29 hexadecimal" json:"vu_data_block_counter"`
+	VehicleIdentificationNumber  string      `tlv:"0505 17 31 string" json:"vehicle_identification_number"`
+}
+
+type CardVehicleRecordsG2 []CardVehicleRecordG2
+
+type PlaceRecordG2 struct {
+	EntryTime                 time.Time                `tlv:"0506 4 0 date" json:"entry_time"`
+	TypePeriodId              EntryTypeDailyWorkPeriod `tlv:"0506 1 4 int" json:"type_period_id"`
+	TypePeriodName            string                   `code:"TypePeriodId" json:"type_period_name"`
+	DailyWorkPeriodCountry    int                      `tlv:"0506 1 5 int" json:"daily_work_period_country"`
+	DailyWorkPeriodCountryIso *NationInfo              `iso:"DailyWorkPeriodCountry" json:"daily_work_period_country_iso,omitempty"`
+	DailyWorkPeriodRegion     int                      `tlv:"0506 1 6 int" json:"daily_work_period_region"`
+	DailyWorkPeriodRegionIso  *RegionInfo              `iso:"DailyWorkPeriodRegion DailyWorkPeriodCountry" json:"daily_work_period_region_iso,omitempty"`
+	VehicleOdometerValue      int                      `tlv:"0506 3 7 int" json:"vehicle_odometer_value"`
+	GnssTimeStamp             time.Time                `tlv:"0506 4 10 date" json:"gnss_time_stamp"`
+	GnssAccuracy              int                      `tlv:"0506 1 14 int" json:"gnss_accuracy"`
+	Latitude                  float64                  `tlv:"0506 3 15 coordinate" json:"latitude"`
+	Longitude                 float64                  `tlv:"0506 3 18 coordinate" json:"longitude"`
+	// статус аутентификации места из EF_Places_Authentication, если он есть на карте
+	AuthenticationStatus *int `json:"authentication_status,omitempty"`
+}
+
+type PlaceRecordsG2 []PlaceRecordG2
+
+type CardBorderCrossingRecord struct {
+	CountryLeft          int         `tlv:"0528 1 0 int" json:"country_left"`
+	CountryLeftIso       *NationInfo `iso:"CountryLeft" json:"country_left_iso,omitempty"`
+	CountryEntered       int         `tlv:"0528 1 1 int" json:"country_entered"`
+	CountryEnteredIso    *NationInfo `iso:"CountryEntered" json:"country_entered_iso,omitempty"`
+	GnssTimeStamp        time.Time   `tlv:"0528 4 2 date" json:"gnss_time_stamp"`
+	GnssAccuracy         int         `tlv:"0528 1 6 int" json:"gnss_accuracy"`
+	Latitude             float64     `tlv:"0528 3 7 coordinate" json:"latitude"`
+	Longitude            float64     `tlv:"0528 3 10 coordinate" json:"longitude"`
+	AuthenticationStatus int         `tlv:"0528 1 13 int" json:"authentication_status"`
+	VehicleOdometerValue int         `tlv:"0528 3 14 int" json:"vehicle_odometer_value"`
+}
+
+type CardBorderCrossingRecords []CardBorderCrossingRecord
+
+type CardLoadUnloadRecord struct {
+	TimeStamp            time.Time `tlv:"0529 4 0 date" json:"time_stamp"`
+	OperationType        int       `tlv:"0529 1 4 int" json:"operation_type"`
+	GnssTimeStamp        time.Time `tlv:"0529 4 5 date" json:"gnss_time_stamp"`
+	GnssAccuracy         int       `tlv:"0529 1 9 int" json:"gnss_accuracy"`
+	Latitude             float64   `tlv:"0529 3 10 coordinate" json:"latitude"`
+	Longitude            float64   `tlv:"0529 3 13 coordinate" json:"longitude"`
+	AuthenticationStatus int       `tlv:"0529 1 16 int" json:"authentication_status"`
+	VehicleOdometerValue int       `tlv:"0529 3 17 int" json:"vehicle_odometer_value"`
+}
+
+type CardLoadUnloadRecords []CardLoadUnloadRecord
+
+type CardLoadTypeEntryRecord struct {
+	TimeStamp       time.Time `tlv:"0530 4 0 date" json:"time_stamp"`
+	LoadTypeEntered int       `tlv:"0530 1 4 int" json:"load_type_entered"`
+}
+
+type CardLoadTypeEntryRecords []CardLoadTypeEntryRecord
+
+type SpecificConditionRecordG2 struct {
+	EntryTime                 time.Time               `tlv:"0522 4 0 date" json:"entry_time"`
+	SpecificConditionTypeId   SpecificConditionTypeG2 `tlv:"0522 1 4 int" json:"specific_condition_type_id"`
+	SpecificConditionTypeName string                  `code:"SpecificConditionTypeId" json:"specific_condition_type_name"`
+}
+
+type SpecificConditionRecordsG2 []SpecificConditionRecordG2
+
+type CardVehicleUnitRecord struct {
+	TimeStamp         time.Time `tlv:"0523 4 0 date" json:"time_stamp"`
+	ManufacturerCode  int       `tlv:"0523 1 4 int" json:"manufacturer_code"`
+	DeviceId          int       `tlv:"0523 1 5 int" json:"device_id"`
+	VuSoftwareVersion string    `tlv:"0523 4 6 string" json:"vu_software_version"`
+}
+
+type CardVehicleUnitRecords []CardVehicleUnitRecord
+
+type GNSSAccumulatedDrivingRecord struct {
+	TimeStamp            time.Time `tlv:"0524 4 0 date" json:"time_stamp"`
+	GnssTimeStamp        time.Time `tlv:"0524 4 4 date" json:"gnss_time_stamp"`
+	GnssAccuracy         int       `tlv:"0524 1 8 int" json:"gnss_accuracy"`
+	Latitude             float64   `tlv:"0524 3 9 coordinate" json:"latitude"`
+	Longitude            float64   `tlv:"0524 3 12 coordinate" json:"longitude"`
+	VehicleOdometerValue int       `tlv:"0524 3 15 int" json:"vehicle_odometer_value"`
+	// статус аутентификации места из EF_GNSS_Places_Authentication, если он есть на карте
+	AuthenticationStatus *int `json:"authentication_status,omitempty"`
+}
+
+type GNSSAccumulatedDrivingRecords []GNSSAccumulatedDrivingRecord
+
+type PlaceAuthStatusRecord struct {
+	EntryTime            time.Time `tlv:"0526 4 0 date"`
+	AuthenticationStatus int       `tlv:"0526 1 4 int"`
+}
+
+type PlaceAuthStatusRecords []PlaceAuthStatusRecord
+
+type GNSSPlaceAuthStatusRecord struct {
+	EntryTime            time.Time `tlv:"0527 4 0 date"`
+	AuthenticationStatus int       `tlv:"0527 1 4 int"`
+}
+
+type GNSSPlaceAuthStatusRecords []GNSSPlaceAuthStatusRecord
+
+// Данные приложения Tachograph_G2 карты водителя
+type CardG2 struct {
+	Application                   ApplicationIdentificationG2
+	ApplicationV2                 *ApplicationIdentificationV2 `json:",omitempty"`
+	Card                          CardInfoG2
+	Certificates                  CardG2Certificates
+	SessionOpen                   SessionOpen
+	Driver                        Driver
+	DLicense                      DLicense
+	CardVehicleRecords            CardVehicleRecordsG2
+	VehicleUsage                  VehicleUsages
+	ActivityDailyRecords          ActivityDailyRecords
+	PlaceRecords                  PlaceRecordsG2
+	WorkShifts                    WorkShifts
+	BorderCrossingRecords         CardBorderCrossingRecords `json:",omitempty"`
+	LoadUnloadRecords             CardLoadUnloadRecords     `json:",omitempty"`
+	LoadTypeEntryRecords          CardLoadTypeEntryRecords  `json:",omitempty"`
+	CardEventRecords              CardEventRecords          `json:"-"`
+	CardFaultRecords              CardFaultRecords          `json:"-"`
+	CardEventGroups               CardEventGroups
+	CardFaultGroups               CardFaultGroups
+	CardControlActivityDataRecord CardControlActivityDataRecords
+	SpecificConditionRecord       SpecificConditionRecordsG2
+	SpecificConditionIntervals    SpecificConditionIntervals
+	VehicleUnitRecords            CardVehicleUnitRecords
+	GNSSPlaceRecords              GNSSAccumulatedDrivingRecords
+}
+
+// Метод дополняет записи о местах и местах GNSS статусами аутентификации.
+// Статус сопоставляется с записью по времени ввода.
+func (c *CardG2) applyAuthStatuses(places PlaceAuthStatusRecords, gnss GNSSPlaceAuthStatusRecords) {
+	for _, as := range places {
+		status := as.AuthenticationStatus
+		for i := range c.PlaceRecords {
+			if c.PlaceRecords[i].EntryTime.Equal(as.EntryTime) {
+				c.PlaceRecords[i].AuthenticationStatus = &status
+			}
+		}
+	}
+
+	for _, as := range gnss {
+		status := as.AuthenticationStatus
+		for i := range c.GNSSPlaceRecords {
+			if c.GNSSPlaceRecords[i].TimeStamp.Equal(as.EntryTime) {
+				c.GNSSPlaceRecords[i].AuthenticationStatus = &status
+			}
+		}
+	}
+}
+
+// Названия секций (EF) приложения Tachograph_G2 по приложению 1C
+var cardG2SectionNames = map[string]string{
+	"050102": "EF_Application_Identification (G2)",
+	"050202": "EF_Events_Data (G2)",
+	"050302": "EF_Faults_Data (G2)",
+	"050402": "EF_Driver_Activity_Data (G2)",
+	"050502": "EF_Vehicles_Used (G2)",
+	"050602": "EF_Places (G2)",
+	"050702": "EF_Current_Usage (G2)",
+	"050802": "EF_Control_Activity_Data (G2)",
+	"050E02": "EF_Card_Download (G2)",
+	"052002": "EF_Identification (G2)",
+	"052102": "EF_Driving_Licence_Info (G2)",
+	"052202": "EF_Specific_Conditions (G2)",
+	"052302": "EF_VehicleUnits_Used (G2)",
+	"052402": "EF_GNSS_Places (G2)",
+	"052502": "EF_Application_Identification_V2 (G2)",
+	"052602": "EF_Places_Authentication (G2)",
+	"052702": "EF_GNSS_Places_Authentication (G2)",
+	"052802": "EF_Border_Crossings (G2)",
+	"052902": "EF_Load_Unload_Operations (G2)",
+	"053002": "EF_Load_Type_Entries (G2)",
+	"C10002": "EF_Card_Certificate (G2)",
+	"C10102": "EF_CardSignCertificate (G2)",
+	"C10802": "EF_CA_Certificate (G2)",
+	"C10902": "EF_Link_Certificate (G2)",
+}
+
+// Суффикс тэгов приложения Tachograph_G2 в ddd файле
+const gen2TagSuffix = "02"
+
+// Функция выбирает из tlv записей данные приложения Tachograph_G2 и приводит их тэги
+// к номерам EF, например "050402" -> "0504". Данные MF (0002, 0005) общие для обоих
+// приложений и копируются без изменений.
+func gen2Records(tlvRecords map[string][]byte) map[string][]byte {
+	result := map[string][]byte{}
+
+	for tag, val := range tlvRecords {
+		if len(tag) == 6 && strings.HasSuffix(tag, gen2TagSuffix) {
+			result[tag[:4]] = val
+		}
+	}
+	for _, tag := range []string{"0002", "0005"} {
+		if val, ok := tlvRecords[tag]; ok {
+			result[tag] = val
+		}
+	}
+
+	return result
+}
+
+// Функция проверяет наличие в tlv записях данных приложения Tachograph_G2
+func hasGen2Records(tlvRecords map[string][]byte) bool {
+	_, ok := tlvRecords["0501"+gen2TagSuffix]
+	return ok
+}
@@ -0,0 +1,148 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+// Функция формирует данные приложения Tachograph_G2 карты водителя: идентификацию,
+// двое суток деятельности, одно транспортное средство, одно место со статусом аутентификации.
+func buildG2Part() []byte {
+	var out []byte
+	out = append(out, tlv([]byte{0x05, 0x01, 0x02}, []byte{0x01, 0x01, 0x00, 12, 24, 0x05, 0x3C, 0, 2, 0, 2, 0, 2, 0, 2, 0, 2})...)
+	ident := make([]byte, 143)
+	ident[0] = 0x11
+	copy(ident[1:], strField("D1234567890", 16))
+	copy(ident[65:], append([]byte{0x01}, strField("DOE", 35)...))
+	copy(ident[137:], []byte{0x19, 0x80, 0x01, 0x02})
+	out = append(out, tlv([]byte{0x05, 0x20, 0x02}, ident)...)
+	out = append(out, tlv([]byte{0x05, 0x20, 0x03}, make([]byte, 64))...)
+	out = append(out, tlv([]byte{0x05, 0x04, 0x02}, buildActivity(2))...)
+
+	veh := []byte{0, 0}
+	r := []byte{0, 0, 100, 0, 1, 0}
+	r = append(r, ts(day0.Add(6*time.Hour))...)
+	r = append(r, ts(day0.Add(16*time.Hour))...)
+	r = append(r, 0x11)
+	r = append(r, append([]byte{0x01}, strField("XY999", 13)...)...)
+	r = append(r, 0x00, 0x01)
+	r = append(r, strField("VIN12345678901234", 17)...)
+	veh = append(veh, r...)
+	veh = append(veh, make([]byte, 48)...)
+	out = append(out, tlv([]byte{0x05, 0x05, 0x02}, veh)...)
+
+	pl := []byte{0, 0}
+	p := append(ts(day0.Add(6*time.Hour)), 0, 0x11, 0, 0, 0, 100)
+	p = append(p, ts(day0.Add(6*time.Hour))...)
+	p = append(p, 1, 0x00, 0xC8, 0x69, 0xFF, 0xE3, 0xD2) // 51305 -> 51.5083, -7214 -> -7.3567
+	pl = append(pl, p...)
+	pl = append(pl, make([]byte, 21)...)
+	out = append(out, tlv([]byte{0x05, 0x06, 0x02}, pl)...)
+
+	pa := []byte{0, 0}
+	pa = append(pa, ts(day0.Add(6*time.Hour))...)
+	pa = append(pa, 1)
+	pa = append(pa, make([]byte, 5)...)
+	out = append(out, tlv([]byte{0x05, 0x26, 0x02}, pa)...)
+	return out
+}
+
+func TestParseBytesG2(t *testing.T) {
+	// секций EF_Current_Usage и EF_Driving_Licence_Info приложения G2 в выгрузке нет,
+	// поэтому ошибка ожидаема, а остальные данные должны быть загружены
+	c, err := ParseBytes(append(buildDriverDDD(), buildG2Part()...))
+	if err == nil {
+		t.Error("expected error about missing G2 sections")
+	}
+	if c.Generation != 2 || c.G2 == nil {
+		t.Fatalf("expected generation 2 card, got %d", c.Generation)
+	}
+	if c.Card.CardNumber != "D1234567890" || len(c.ActivityDailyRecords) != 5 {
+		t.Errorf("gen1 application is not loaded: %q, %d days", c.Card.CardNumber, len(c.ActivityDailyRecords))
+	}
+
+	g2 := c.G2
+	if g2.Application.NoOfCardVehicleRecords != 2 || g2.Card.CardNumber != "D1234567890" ||
+		g2.Driver.HolderSurname != "DOE" || len(g2.ActivityDailyRecords) != 2 {
+		t.Errorf("unexpected G2 application: %+v, %+v, %+v", g2.Application, g2.Card, g2.Driver)
+	}
+
+	if len(g2.CardVehicleRecords) != 1 {
+		t.Fatalf("expected 1 vehicle, got %d", len(g2.CardVehicleRecords))
+	}
+	v := g2.CardVehicleRecords[0]
+	if v.VehicleRegistrationNumber != "XY999" || v.VehicleIdentificationNumber != "VIN12345678901234" ||
+		v.VehicleOdometerEnd != 256 || !v.VehicleFirstUse.Equal(day0.Add(6*time.Hour)) {
+		t.Errorf("unexpected vehicle record %+v", v)
+	}
+
+	if len(g2.PlaceRecords) != 1 {
+		t.Fatalf("expected 1 place, got %d", len(g2.PlaceRecords))
+	}
+	p := g2.PlaceRecords[0]
+	if p.DailyWorkPeriodCountry != 0x11 || p.VehicleOdometerValue != 100 || p.GnssAccuracy != 1 {
+		t.Errorf("unexpected place record %+v", p)
+	}
+	if p.Latitude < 51.5 || p.Latitude > 51.51 || p.Longitude > -7.35 || p.Longitude < -7.36 {
+		t.Errorf("unexpected coordinates %v, %v", p.Latitude, p.Longitude)
+	}
+	if p.AuthenticationStatus == nil || *p.AuthenticationStatus != 1 {
+		t.Errorf("place authentication status is not applied: %v", p.AuthenticationStatus)
+	}
+
+	for _, s := range c.Report.Sections {
+		if s.Tag == "050202" && s.Status != SectionNotFound {
+			t.Errorf("unexpected status of missing G2 section: %+v", s)
+		}
+	}
+}
+
+func TestParseBytesG2Only(t *testing.T) {
+	c, err := ParseBytes(buildG2Part())
+	if c.Generation != 2 || c.G2 == nil || c.G2.Card.CardNumber != "D1234567890" {
+		t.Fatalf("G2 application is not loaded: %v", err)
+	}
+	if c.Card.CardNumber != "" {
+		t.Errorf("gen1 card info is filled without gen1 application: %q", c.Card.CardNumber)
+	}
+}
+
+func TestParseBytesG2v2(t *testing.T) {
+	d := append(buildDriverDDD(), buildG2Part()...)
+	d = append(d, tlv([]byte{0x05, 0x25, 0x02}, []byte{0, 2, 0, 2, 0, 2, 1})...)
+	bc := []byte{0, 0, 0x11, 0x0A}
+	bc = append(bc, ts(day0.Add(9*time.Hour))...)
+	bc = append(bc, 1, 0x00, 0xC8, 0x69, 0xFF, 0xE3, 0xD2, 1, 0, 1, 0)
+	bc = append(bc, make([]byte, 17)...)
+	d = append(d, tlv([]byte{0x05, 0x28, 0x02}, bc)...)
+	lt := []byte{0, 0}
+	lt = append(lt, ts(day0.Add(9*time.Hour))...)
+	lt = append(lt, 1)
+	lt = append(lt, make([]byte, 5)...)
+	d = append(d, tlv([]byte{0x05, 0x30, 0x02}, lt)...)
+
+	c, _ := ParseBytes(d)
+	if c.G2 == nil {
+		t.Fatal("G2 application is not loaded")
+	}
+	g2 := c.G2
+	if g2.ApplicationV2 == nil || g2.ApplicationV2.NoOfBorderCrossingRecords != 2 || g2.ApplicationV2.VuConfigurationLengthRange != 1 {
+		t.Errorf("unexpected application identification V2 %+v", g2.ApplicationV2)
+	}
+
+	if len(g2.BorderCrossingRecords) != 1 {
+		t.Fatalf("expected 1 border crossing, got %d", len(g2.BorderCrossingRecords))
+	}
+	bcr := g2.BorderCrossingRecords[0]
+	if bcr.CountryLeft != 0x11 || bcr.CountryEntered != 0x0A || !bcr.GnssTimeStamp.Equal(day0.Add(9*time.Hour)) ||
+		bcr.AuthenticationStatus != 1 || bcr.VehicleOdometerValue != 256 {
+		t.Errorf("unexpected border crossing %+v", bcr)
+	}
+
+	if len(g2.LoadTypeEntryRecords) != 1 || g2.LoadTypeEntryRecords[0].LoadTypeEntered != 1 {
+		t.Errorf("unexpected load type entries %+v", g2.LoadTypeEntryRecords)
+	}
+	if g2.LoadUnloadRecords != nil {
+		t.Errorf("load/unload records without section: %+v", g2.LoadUnloadRecords)
+	}
+}
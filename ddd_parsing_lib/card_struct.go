@@ -1,225 +1,477 @@
-package main
+// Пакет ddd предназначен для разбора ddd файлов, выгруженных с карт тахографа.
+//
+// Пример использования:
+//
+//	f, _ := os.Open("test.ddd")
+//	c, err := ddd.Parse(f)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(c.Card.CardNumber)
+package ddd
 
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"log"
 	"time"
 )
 
-type сardVehicleRecord struct {
-	VehicleOdometerBegin      int       `tlv:"0505 3 0 int" json:"vehicle_odometer_begin"`
-	VehicleOdometerEnd        int       `tlv:"0505 3 3 int" json:"vehicle_odometer_end"`
-	VehicleFirstUse           time.Time `tlv:"0505 4 6 date" json:"vehicle_first_use"`
-	VehicleLastUse            time.Time `tlv:"0505 4 10 date" json:"vehicle_last_use"`
-	VehicleRegistrationNation int       `tlv:"0505 1 14 int" json:"vehicle_registration_nation"`
-	VehicleRegistrationNumber string    `tlv:"0505 14 15 string" json:"vehicle_registration_number"`
+type CardVehicleRecord struct {
+	VehicleOdometerBegin         int         `tlv:"0505 3 0 int" json:"vehicle_odometer_begin"`
+	VehicleOdometerEnd           int         `tlv:"0505 3 3 int" json:"vehicle_odometer_end"`
+	VehicleFirstUse              time.Time   `tlv:"0505 4 6 date" json:"vehicle_first_use"`
+	VehicleLastUse               time.Time   `tlv:"0505 4 10 date" json:"vehicle_last_use"`
+	VehicleRegistrationNation    int         `tlv:"0505 1 14 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `tlv:"0505 14 15 string" json:"vehicle_registration_number"`
 }
 
-type сardVehicleRecords []сardVehicleRecord
+type CardVehicleRecords []CardVehicleRecord
 
-type activityChangeInfo struct {
-	TachographCardReaderId int       `json:"tachograph_card_reader_id"`
-	StateDrivingId         int       `json:"state_driving_id"`
-	CardPositionId         int       `json:"card_position_id"`
-	ActivityKindId         int       `json:"activity_kind_id"`
-	ActivityChangeInfoT    int       `json:"activity_change_info_t"`
-	CalculatedTime         time.Time `json:"calculated_time"`
+type ActivityChangeInfo struct {
+	TachographCardReaderId int          `json:"tachograph_card_reader_id"`
+	StateDrivingId         int          `json:"state_driving_id"`
+	CardPositionId         int          `json:"card_position_id"`
+	ActivityKindId         ActivityKind `json:"activity_kind_id"`
+	ActivityKindName       string       `code:"ActivityKindId" json:"activity_kind_name"`
+	ActivityChangeInfoT    int          `json:"activity_change_info_t"`
+	CalculatedTime         time.Time    `json:"calculated_time"`
 }
 
-type activityDailyRecord struct {
+type ActivityDailyRecord struct {
 	ActivityRecordDate           time.Time            `tlv:"0504 4 0 date" json:"activity_record_date"`
 	ActivityDailyPresenceCounter string               `tlv:"0504 2 4 daylicounter" json:"activity_daily_presence_counter"`
 	ActivityDayDistance          int                  `tlv:"0504 2 6 int" json:"activity_day_distance"`
 	ActivitiesS                  string               `tlv:"0504 -1 8 activites" json:"activities_s"`
-	ActivityChangeInfos          []activityChangeInfo `json:"activity_change_infos"`
-}
-
-func (adr *activityDailyRecord) ParseChangeInfo() error {
-	beginAciIdx := 0
-	nextAciIdx := beginAciIdx + 4 // т.к. 2 байта записанные строкой это 4 символа
-	aci := activityChangeInfo{}
-	adr.ActivityChangeInfos = []activityChangeInfo{}
-	for beginAciIdx < len(adr.ActivitiesS) {
-		currentACI := adr.ActivitiesS[beginAciIdx:nextAciIdx]
-		if err := parseChangeInfoData(adr.ActivityRecordDate, currentACI, &aci); err != nil {
-			log.Println("Can't upload change info for activity daily record")
-			return err
-		}
-
-		beginAciIdx = nextAciIdx
-		nextAciIdx = nextAciIdx + 4
+	ActivityChangeInfos          []ActivityChangeInfo `json:"activity_change_infos"`
+	ActivityIntervals            []ActivityInterval   `json:"activity_intervals"`
+	ActivityTotals               ActivityTotals       `json:"activity_totals"`
+}
 
-		adr.ActivityChangeInfos = append(adr.ActivityChangeInfos, aci)
+func (adr *ActivityDailyRecord) ParseChangeInfo() error {
+	acis, err := parseChangeInfos(adr.ActivityRecordDate, adr.ActivitiesS)
+	adr.ActivityChangeInfos = acis
+	adr.ActivityIntervals = buildActivityIntervals(adr.ActivityRecordDate, acis)
+	adr.ActivityTotals = calcActivityTotals(adr.ActivityIntervals)
+	if err != nil {
+		log.Println("Can't upload change info for activity daily record")
 	}
 
-	return nil
+	return err
+}
+
+type ActivityDailyRecords []ActivityDailyRecord
+
+type PlaceRecord struct {
+	EntryTime                 time.Time                `tlv:"0506 4 0 date" json:"entry_time"`
+	TypePeriodId              EntryTypeDailyWorkPeriod `tlv:"0506 1 4 int" json:"type_period_id"`
+	TypePeriodName            string                   `code:"TypePeriodId" json:"type_period_name"`
+	DailyWorkPeriodCountry    int                      `tlv:"0506 1 5 int" json:"daily_work_period_country"`
+	DailyWorkPeriodCountryIso *NationInfo              `iso:"DailyWorkPeriodCountry" json:"daily_work_period_country_iso,omitempty"`
+	DailyWorkPeriodRegion     int                      `tlv:"0506 1 6 int" json:"daily_work_period_region"`
+	DailyWorkPeriodRegionIso  *RegionInfo              `iso:"DailyWorkPeriodRegion DailyWorkPeriodCountry" json:"daily_work_period_region_iso,omitempty"`
+	VehicleOdometerValue      int                      `tlv:"0506 3 7 int" json:"vehicle_odometer_value"`
 }
 
-type activityDailyRecords []activityDailyRecord
+type PlaceRecords []PlaceRecord
 
-type placeRecord struct {
-	EntryTime              time.Time `tlv:"0506 4 0 date" json:"entry_time"`
-	TypePeriodId           int       `tlv:"0506 1 4 int" json:"type_period_id"`
-	DailyWorkPeriodCountry int       `tlv:"0506 1 5 int" json:"daily_work_period_country"`
-	DailyWorkPeriodRegion  int       `tlv:"0506 1 6 int" json:"daily_work_period_region"`
-	VehicleOdometerValue   int       `tlv:"0506 3 7 int" json:"vehicle_odometer_value"`
+type CardEventRecord struct {
+	EventTypeId                  EventFaultType `tlv:"0502 1 0 int" json:"event_type_id"`
+	EventTypeName                string         `code:"EventTypeId" json:"event_type_name"`
+	EventBeginTime               time.Time      `tlv:"0502 4 1 date" json:"event_begin_time"`
+	EventEndTime                 time.Time      `tlv:"0502 4 5 date" json:"event_end_time"`
+	VehicleRegistrationNation    int            `tlv:"0502 1 9 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo    `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string         `tlv:"0502 14 10 string" json:"vehicle_registration_number"`
 }
 
-type placeRecords []placeRecord
+type CardEventRecords []CardEventRecord
 
-type cardEventRecord struct {
-	EventTypeId               int       `tlv:"0502 1 0 int" json:"event_type_id"`
-	EventBeginTime            time.Time `tlv:"0502 4 1 date" json:"event_begin_time"`
-	EventEndTime              time.Time `tlv:"0502 4 5 date" json:"event_end_time"`
-	VehicleRegistrationNation int       `tlv:"0502 1 9 int" json:"vehicle_registration_nation"`
-	VehicleRegistrationNumber string    `tlv:"0502 14 10 string" json:"vehicle_registration_number"`
+// Группа записей о событиях одного типа, в том виде как она хранится на карте
+type CardEventGroup struct {
+	EventTypeId EventFaultType   `json:"event_type_id"`
+	GroupName   string           `json:"group_name"`
+	Records     CardEventRecords `json:"records"`
 }
 
-type cardEventRecords []cardEventRecord
+type CardEventGroups []CardEventGroup
 
-type cardFaultRecord struct {
-	FaultTypeId               int       `tlv:"0503 1 0 int" json:"fault_type_id"`
-	FaultBeginTime            time.Time `tlv:"0503 4 1 date" json:"fault_begin_time"`
-	FaultEndTime              time.Time `tlv:"0503 4 5 date" json:"fault_end_time"`
-	VehicleRegistrationNation int       `tlv:"0503 1 9 int" json:"vehicle_registration_nation"`
-	VehicleRegistrationNumber string    `tlv:"0503 14 10 string" json:"vehicle_registration_number"`
+// Метод возвращает записи всех групп одним списком в порядке групп
+func (g CardEventGroups) Records() CardEventRecords {
+	records := CardEventRecords{}
+	for _, group := range g {
+		records = append(records, group.Records...)
+	}
+	return records
+}
+
+type CardFaultRecord struct {
+	FaultTypeId                  EventFaultType `tlv:"0503 1 0 int" json:"fault_type_id"`
+	FaultTypeName                string         `code:"FaultTypeId" json:"fault_type_name"`
+	FaultBeginTime               time.Time      `tlv:"0503 4 1 date" json:"fault_begin_time"`
+	FaultEndTime                 time.Time      `tlv:"0503 4 5 date" json:"fault_end_time"`
+	VehicleRegistrationNation    int            `tlv:"0503 1 9 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo    `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string         `tlv:"0503 14 10 string" json:"vehicle_registration_number"`
 }
 
-type cardFaultRecords []cardFaultRecord
+type CardFaultRecords []CardFaultRecord
 
-type cardControlActivityDataRecord struct {
-	ControlTypeId              int       `tlv:"0508 1 0 int" json:"control_type_id"`
-	ControlTime                time.Time `tlv:"0508 4 1 date" json:"control_time"`
-	CardTypeId                 int       `tlv:"0508 1 5 int" json:"card_type_id"`
-	CardIssuingMemberState     int       `tlv:"0508 1 6 int" json:"card_issuing_member_state"`
-	ControlCardNumber          string    `tlv:"0508 16 7 string" json:"control_card_number"`
-	ControlDownloadPeriodBegin time.Time `tlv:"0508 4 38 date" json:"control_download_period_begin"`
-	ControlDownloadPeriodEnd   time.Time `tlv:"0508 4 42 date" json:"control_download_period_end"`
-	VehicleRegistrationNation  int       `tlv:"0508 1 46 int" json:"vehicle_registration_nation"`
-	VehicleRegistrationNumber  string    `tlv:"0508 14 47 string" json:"vehicle_registration_number"`
+// Группа записей о неисправностях одного типа, в том виде как она хранится на карте
+type CardFaultGroup struct {
+	FaultTypeId EventFaultType   `json:"fault_type_id"`
+	GroupName   string           `json:"group_name"`
+	Records     CardFaultRecords `json:"records"`
 }
 
-type cardControlActivityDataRecords []cardControlActivityDataRecord
+type CardFaultGroups []CardFaultGroup
+
+// Метод возвращает записи всех групп одним списком в порядке групп
+func (g CardFaultGroups) Records() CardFaultRecords {
+	records := CardFaultRecords{}
+	for _, group := range g {
+		records = append(records, group.Records...)
+	}
+	return records
+}
 
-type specificConditionRecord struct {
-	SpecificConditionTypeId int       `tlv:"0522 1 4 int" json:"specific_condition_type_id" db:"specific_condition_type_id"`
-	EntryTime               time.Time `tlv:"0522 4 0 date" json:"entry_time" db:"entry_time"`
+type CardControlActivityDataRecord struct {
+	ControlTypeId                ControlType `tlv:"0508 1 0 int" json:"control_type_id"`
+	ControlTypeName              string      `code:"ControlTypeId" json:"control_type_name"`
+	ControlTime                  time.Time   `tlv:"0508 4 1 date" json:"control_time"`
+	CardTypeId                   int         `tlv:"0508 1 5 int" json:"card_type_id"`
+	CardIssuingMemberState       int         `tlv:"0508 1 6 int" json:"card_issuing_member_state"`
+	CardIssuingMemberStateIso    *NationInfo `iso:"CardIssuingMemberState" json:"card_issuing_member_state_iso,omitempty"`
+	ControlCardNumber            string      `tlv:"0508 16 7 string" json:"control_card_number"`
+	ControlDownloadPeriodBegin   time.Time   `tlv:"0508 4 38 date" json:"control_download_period_begin"`
+	ControlDownloadPeriodEnd     time.Time   `tlv:"0508 4 42 date" json:"control_download_period_end"`
+	VehicleRegistrationNation    int         `tlv:"0508 1 23 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `tlv:"0508 14 24 string" json:"vehicle_registration_number"`
 }
 
-type specificConditionRecords []specificConditionRecord
+type CardControlActivityDataRecords []CardControlActivityDataRecord
 
-type driver struct {
+type SpecificConditionRecord struct {
+	SpecificConditionTypeId   SpecificConditionType `tlv:"0522 1 4 int" json:"specific_condition_type_id" db:"specific_condition_type_id"`
+	SpecificConditionTypeName string                `code:"SpecificConditionTypeId" json:"specific_condition_type_name"`
+	EntryTime                 time.Time             `tlv:"0522 4 0 date" json:"entry_time" db:"entry_time"`
+}
+
+type SpecificConditionRecords []SpecificConditionRecord
+
+type Driver struct {
 	HolderSurname               string    `tlv:"0520 36 65 string" json:"holder_surname"`
 	HolderFirstNames            string    `tlv:"0520 36 101 string" json:"holder_first_names"`
 	CardHolderBirthDate         time.Time `tlv:"0520 5 137 birthday" json:"card_holder_birth_date"`
 	CardHolderPreferredLanguage string    `tlv:"0520 2 141 string" json:"card_holder_preferred_language"`
 }
 
-type dlicense struct {
+type DLicense struct {
 	DrivingLicenceIssuingAuthority string `tlv:"0521 36 0 string" json:"driving_licence_issuing_authority"`
 	DrivingLicenceIssuingNation    int    `tlv:"0521 1 36 int" json:"driving_licence_issuing_nation"`
 	DrivingLicenceNumber           string `tlv:"0521 16 37 string" json:"driving_licence_number"`
 }
 
-type sessionOpen struct {
-	SessionOpenTime          time.Time `tlv:"0507 4 0 date" json:"session_open_time"`
-	SessionOpenVehicleNation int       `tlv:"0507 1 4 int" json:"vehicle_registration_nation"`
-	SessionOpenVehicleNumber string    `tlv:"0507 14 5 string" json:"vehicle_registration_number"`
-}
-
-type cardInfo struct {
-	IcSerialNumber            string    `tlv:"0005 4 0 hexadecimal" json:"ic_serial_number"`
-	IcManufacturingReferences string    `tlv:"0005 4 4 hexadecimal" json:"ic_manufacturing_references"`
-	CardExtendedSerialNumber  string    `tlv:"0002 8 1 string" json:"card_extended_serial_number"`
-	CardApprovalNumber        string    `tlv:"0002 8 9 string" json:"card_approval_number"`
-	CardPersonalizerId        int       `tlv:"0002 1 17 int" json:"card_personalizer_id"`
-	EmbeddericAssemblerId     string    `tlv:"0002 5 18 string" json:"embedderic_assembler_id"`
-	IcIdentifier              int       `tlv:"0002 2 23 int" json:"ic_identifier"`
-	CardNumber                string    `tlv:"0520 16 1 string" json:"card_number"`
-	CardIssuingMemberState    int       `tlv:"0520 1 0 int" json:"card_issuing_member_state"`
-	CardIssuingAuthorityName  string    `tlv:"0520 36 17 string" json:"card_issuing_authority_name"`
-	CardIssueDate             time.Time `tlv:"0520 4 53 date" json:"card_issue_date"`
-	CardValidityBegin         time.Time `tlv:"0520 4 57 date" json:"card_validity_begin"`
-	CardExpiryDate            time.Time `tlv:"0520 4 61 date" json:"card_expiry_date"`
-	LastCardDownload          time.Time `tlv:"050E 4 0 date 0" json:"last_card_download"`
-	TypeOfTachographCardId    int       `tlv:"0501 1 0 int" json:"type_of_tachograph_card_id"`
-	CardStructureVersion      string    `tlv:"0501 2 1 hexadecimal" json:"card_structure_version"`
-	CardCertificateGost       string    `tlv:"C200 1000 0 hexadecimal 0" json:"card_certificate_gost,omitempty"`
-	CACertificateGost         string    `tlv:"C208 1000 0 hexadecimal 0" json:"ca_certificate_gost,omitempty"`
-	CardCertificateESTR       string    `tlv:"C100 194 0 hexadecimal 0" json:"card_certificate_estr,omitempty"`
-	CACertificateESTR         string    `tlv:"C108 194 0 hexadecimal 0" json:"ca_certificate_estr,omitempty"`
-}
-
-type card struct {
-	Card                          cardInfo
-	SessionOpen                   sessionOpen
-	Driver                        driver
-	DLicense                      dlicense
-	CardVehicleRecords            сardVehicleRecords
-	ActivityDailyRecords          activityDailyRecords
-	PlaceRecords                  placeRecords
-	CardEventRecords              cardEventRecords
-	CardFaultRecords              cardFaultRecords
-	CardControlActivityDataRecord cardControlActivityDataRecords
-	SpecificConditionRecord       specificConditionRecords
-}
-
-func (c *card) ParseFromDDD(ddd []byte) error {
-	TlvCardMap, err := extractFieldVals(ddd)
+type SessionOpen struct {
+	SessionOpenTime             time.Time   `tlv:"0507 4 0 date" json:"session_open_time"`
+	SessionOpenVehicleNation    int         `tlv:"0507 1 4 int" json:"vehicle_registration_nation"`
+	SessionOpenVehicleNationIso *NationInfo `iso:"SessionOpenVehicleNation" json:"vehicle_registration_nation_iso,omitempty"`
+	SessionOpenVehicleNumber    string      `tlv:"0507 14 5 string" json:"vehicle_registration_number"`
+}
+
+type CardInfo struct {
+	IcSerialNumber            string        `tlv:"0005 4 0 hexadecimal" json:"ic_serial_number"`
+	IcManufacturingReferences string        `tlv:"0005 4 4 hexadecimal" json:"ic_manufacturing_references"`
+	CardExtendedSerialNumber  string        `tlv:"0002 8 1 string" json:"card_extended_serial_number"`
+	CardApprovalNumber        string        `tlv:"0002 8 9 string" json:"card_approval_number"`
+	CardPersonalizerId        int           `tlv:"0002 1 17 int" json:"card_personalizer_id"`
+	EmbeddericAssemblerId     string        `tlv:"0002 5 18 string" json:"embedderic_assembler_id"`
+	IcIdentifier              int           `tlv:"0002 2 23 int" json:"ic_identifier"`
+	CardNumber                string        `tlv:"0520 16 1 string" json:"card_number"`
+	CardIssuingMemberState    int           `tlv:"0520 1 0 int" json:"card_issuing_member_state"`
+	CardIssuingMemberStateIso *NationInfo   `iso:"CardIssuingMemberState" json:"card_issuing_member_state_iso,omitempty"`
+	CardIssuingAuthorityName  string        `tlv:"0520 36 17 string" json:"card_issuing_authority_name"`
+	CardIssueDate             time.Time     `tlv:"0520 4 53 date" json:"card_issue_date"`
+	CardValidityBegin         time.Time     `tlv:"0520 4 57 date" json:"card_validity_begin"`
+	CardExpiryDate            time.Time     `tlv:"0520 4 61 date" json:"card_expiry_date"`
+	LastCardDownload          time.Time     `tlv:"050E 4 0 date 0" json:"last_card_download"`
+	TypeOfTachographCardId    EquipmentType `tlv:"0501 1 0 int" json:"type_of_tachograph_card_id"`
+	TypeOfTachographCardName  string        `code:"TypeOfTachographCardId" json:"type_of_tachograph_card_name"`
+	CardStructureVersion      string        `tlv:"0501 2 1 hexadecimal" json:"card_structure_version"`
+	CardCertificateGost       string        `tlv:"C200 1000 0 hexadecimal 0" json:"card_certificate_gost,omitempty"`
+	CACertificateGost         string        `tlv:"C208 1000 0 hexadecimal 0" json:"ca_certificate_gost,omitempty"`
+	CardCertificateESTR       string        `tlv:"C100 194 0 hexadecimal 0" json:"card_certificate_estr,omitempty"`
+	CACertificateESTR         string        `tlv:"C108 194 0 hexadecimal 0" json:"ca_certificate_estr,omitempty"`
+}
+
+type Card struct {
+	Card                          CardInfo
+	Certificates                  CardCertificates
+	SessionOpen                   SessionOpen
+	Driver                        Driver
+	DLicense                      DLicense
+	CardVehicleRecords            CardVehicleRecords
+	VehicleUsage                  VehicleUsages
+	ActivityDailyRecords          ActivityDailyRecords
+	PlaceRecords                  PlaceRecords
+	WorkShifts                    WorkShifts
+	CardEventRecords              CardEventRecords `json:"-"`
+	CardFaultRecords              CardFaultRecords `json:"-"`
+	CardEventGroups               CardEventGroups
+	CardFaultGroups               CardFaultGroups
+	CardControlActivityDataRecord CardControlActivityDataRecords
+	SpecificConditionRecord       SpecificConditionRecords
+	SpecificConditionIntervals    SpecificConditionIntervals
+	Workshop                      *WorkshopCard `json:",omitempty"`
+	Control                       *ControlCard  `json:",omitempty"`
+	Company                       *CompanyCard  `json:",omitempty"`
+	Generation                    int
+	Type                          CardType
+	G2                            *CardG2            `json:",omitempty"`
+	Compliance                    *ComplianceReport  `json:",omitempty"`
+	WorkingTime                   *WorkingTimeReport `json:",omitempty"`
+	CountryDays                   *CountryReport     `json:",omitempty"`
+	Consistency                   *ConsistencyReport `json:",omitempty"`
+	Report                        ParseReport
+}
+
+// Функция разбирает ddd файл, прочитанный из r, и возвращает заполненную карту.
+func Parse(r io.Reader) (*Card, error) {
+	ddd, err := ioutil.ReadAll(r)
 	if err != nil {
-		return fmt.Errorf("Parse field error: %v", err)
+		return nil, fmt.Errorf("Can't read ddd file: %v", err)
 	}
 
-	if err = loadFields(&c.Card, TlvCardMap); err != nil {
-		return fmt.Errorf("Error card info load: %v", err)
+	return ParseBytes(ddd)
+}
+
+// Функция разбирает содержимое ddd файла и возвращает заполненную карту.
+// При ошибке разбора возвращается карта с данными, которые удалось загрузить.
+func ParseBytes(ddd []byte) (*Card, error) {
+	return ParseBytesWithOptions(ddd, ParseOptions{})
+}
+
+// Функция разбирает ddd файл, прочитанный из r, с заданными настройками.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (*Card, error) {
+	ddd, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read ddd file: %v", err)
 	}
 
-	if err = loadFields(&c.SessionOpen, TlvCardMap); err != nil {
-		return fmt.Errorf("Error sesion info load: %v", err)
+	return ParseBytesWithOptions(ddd, opts)
+}
+
+// Функция разбирает содержимое ddd файла с заданными настройками.
+func ParseBytesWithOptions(ddd []byte, opts ParseOptions) (*Card, error) {
+	c := &Card{}
+	err := c.ParseFromDDDWithOptions(ddd, opts)
+
+	return c, err
+}
+
+// Метод заполняет карту данными из ddd файла.
+// Ошибка в одной секции не прерывает разбор остальных, результат обработки
+// каждой секции записывается в отчет Report. Возвращается первая возникшая ошибка.
+func (c *Card) ParseFromDDD(ddd []byte) error {
+	return c.ParseFromDDDWithOptions(ddd, ParseOptions{})
+}
+
+// Метод заполняет карту данными из ddd файла с заданными настройками,
+// например, с проверкой подписей секций.
+func (c *Card) ParseFromDDDWithOptions(ddd []byte, opts ParseOptions) error {
+	TlvCardMap, signatures, err := extractFieldVals(ddd)
+
+	// карта второго поколения содержит оба приложения: Tachograph и Tachograph_G2
+	_, hasGen1 := TlvCardMap["0501"]
+	hasGen2 := hasGen2Records(TlvCardMap)
+	// тип карты определяет набор секций, которые есть на карте
+	c.Type = detectCardType(TlvCardMap)
+	c.Generation = 1
+	sectionNames := []map[string]string{cardSchemaSectionNames(c.Type)}
+	if hasGen2 {
+		c.Generation = 2
+		sectionNames = append(sectionNames, cardG2SectionNames)
 	}
 
-	if err = loadFields(&c.Driver, TlvCardMap); err != nil {
-		return fmt.Errorf("Error driver info load: %v", err)
+	c.Report = newParseReport(mergeSectionNames(sectionNames...), TlvCardMap)
+	if err != nil && len(TlvCardMap) == 0 {
+		return fmt.Errorf("Parse field error: %v", err)
 	}
 
-	if err = loadFields(&c.DLicense, TlvCardMap); err != nil {
-		return fmt.Errorf("Error dlicense info load: %v", err)
+	var firstErr error
+	if err != nil {
+		// файл обрезан, последняя секция загружена не полностью
+		c.Report.setStatus(errorTag(err), SectionPartial, err.Error())
+		firstErr = fmt.Errorf("Parse field error: %v", err)
 	}
 
-	if err = loadFields(&c.CardEventRecords, TlvCardMap); err != nil {
-		return fmt.Errorf("Event record load error: %v", err)
+	type sectionLoader struct {
+		tags   []string
+		target interface{}
+		errMsg string
 	}
 
-	if err = loadFields(&c.CardFaultRecords, TlvCardMap); err != nil {
-		return fmt.Errorf("Fault record load error: %v", err)
+	load := func(loaders []sectionLoader, tlvRecords map[string][]byte, tagSuffix string) {
+		for _, l := range loaders {
+			err := suffixErrorTags(loadFields(l.target, tlvRecords), tagSuffix)
+			tags := []string{}
+			for _, tag := range l.tags {
+				tags = append(tags, tag+tagSuffix)
+			}
+			c.Report.addLoadResult(tags, l.target, err)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("%s: %v", l.errMsg, err)
+			}
+		}
 	}
 
-	if err = loadFields(&c.CardVehicleRecords, TlvCardMap); err != nil {
-		return fmt.Errorf("Vehicle record load error: %v", err)
+	// если нет данных ни одного приложения, то разбираем файл как карту первого поколения,
+	// чтобы получить ошибки об отсутствующих секциях
+	if hasGen1 || !hasGen2 {
+		load([]sectionLoader{
+			{[]string{"0002", "0005", "0501", "050E", "0520", "C100", "C108", "C200", "C208"}, &c.Card, "Error card info load"},
+		}, TlvCardMap, "")
+
+		// данные о деятельности есть только на картах водителя и мастерской
+		activityLoaders := []sectionLoader{
+			{[]string{"0507"}, &c.SessionOpen, "Error sesion info load"},
+			{[]string{"0502"}, &c.CardEventGroups, "Event group load error"},
+			{[]string{"0503"}, &c.CardFaultGroups, "Fault group load error"},
+			{[]string{"0505"}, &c.CardVehicleRecords, "Vehicle record load error"},
+			{[]string{"0504"}, &c.ActivityDailyRecords, "Activity daily record load error"},
+			{[]string{"0506"}, &c.PlaceRecords, "Place record load error"},
+			{[]string{"0508"}, &c.CardControlActivityDataRecord, "Control activity daily record load error"},
+			{[]string{"0522"}, &c.SpecificConditionRecord, "Specific condition record load error"},
+		}
+
+		switch c.Type {
+		case CardTypeWorkshop:
+			// вместо данных водителя загружаются данные мастерской и калибровки
+			c.Workshop = &WorkshopCard{}
+			load(append([]sectionLoader{
+				{[]string{"0520"}, &c.Workshop.Holder, "Error workshop info load"},
+				{[]string{"0509", "050A"}, &c.Workshop.Calibration, "Error calibration data load"},
+				{[]string{"050A"}, &c.Workshop.CalibrationRecords, "Calibration record load error"},
+				{[]string{"050B"}, &c.Workshop.SensorInstallation, "Error sensor installation data load"},
+			}, activityLoaders...), TlvCardMap, "")
+		case CardTypeControl:
+			c.Control = &ControlCard{}
+			load([]sectionLoader{
+				{[]string{"0520"}, &c.Control.Holder, "Error control card info load"},
+				{[]string{"050C"}, &c.Control.ControlActivityRecords, "Controller activity record load error"},
+			}, TlvCardMap, "")
+		case CardTypeCompany:
+			c.Company = &CompanyCard{}
+			load([]sectionLoader{
+				{[]string{"0520"}, &c.Company.Holder, "Error company card info load"},
+				{[]string{"050C"}, &c.Company.CompanyActivityRecords, "Company activity record load error"},
+			}, TlvCardMap, "")
+		default:
+			load(append([]sectionLoader{
+				{[]string{"0520"}, &c.Driver, "Error driver info load"},
+				{[]string{"0521"}, &c.DLicense, "Error dlicense info load"},
+			}, activityLoaders...), TlvCardMap, "")
+		}
 	}
 
-	if err = loadFields(&c.ActivityDailyRecords, TlvCardMap); err != nil {
-		return fmt.Errorf("Activity daily record load error: %v", err)
+	if hasGen1 || !hasGen2 {
+		c.CardEventRecords = c.CardEventGroups.Records()
+		c.CardFaultRecords = c.CardFaultGroups.Records()
+		c.VehicleUsage = c.CardVehicleRecords.Usage(c.ActivityDailyRecords)
+		c.WorkShifts = c.PlaceRecords.WorkShifts()
+		c.SpecificConditionIntervals = c.SpecificConditionRecord.Intervals(c.ActivityDailyRecords)
+		c.Certificates = decodeCardCertificates(TlvCardMap, opts, &c.Report)
 	}
 
-	if err = loadFields(&c.PlaceRecords, TlvCardMap); err != nil {
-		return fmt.Errorf("Place record load error: %v", err)
+	// проверка подписей карты первого поколения: ЕСТР (RSA) и СКЗИ (ГОСТ)
+	_, hasGostCA := TlvCardMap["C208"]
+	_, hasGostCard := TlvCardMap["C200"]
+	isGostCard := hasGostCA || hasGostCard
+	_, hasEstrCA := TlvCardMap["C108"]
+	_, hasEstrCard := TlvCardMap["C100"]
+	if hasGen1 && opts.EuropeanRootKey != nil && (!isGostCard || hasEstrCA || hasEstrCard) {
+		verifyEstrSignatures(TlvCardMap, signatures, opts.EuropeanRootKey, &c.Report)
+	}
+	if hasGen1 && opts.GostRootKey != nil && isGostCard {
+		verifyGostSignatures(TlvCardMap, signatures, opts.GostRootKey, &c.Report)
 	}
 
-	if err = loadFields(&c.CardControlActivityDataRecord, TlvCardMap); err != nil {
-		return fmt.Errorf("Control activity daily record load error: %v", err)
+	if hasGen2 {
+		g2 := &CardG2{}
+		placeAuth := PlaceAuthStatusRecords{}
+		gnssAuth := GNSSPlaceAuthStatusRecords{}
+		load([]sectionLoader{
+			{[]string{"0501"}, &g2.Application, "Error G2 application identification load"},
+			{[]string{"050E", "0520", "C100", "C101", "C108", "C109"}, &g2.Card, "Error G2 card info load"},
+			{[]string{"0507"}, &g2.SessionOpen, "Error G2 sesion info load"},
+			{[]string{"0520"}, &g2.Driver, "Error G2 driver info load"},
+			{[]string{"0521"}, &g2.DLicense, "Error G2 dlicense info load"},
+			{[]string{"0502"}, &g2.CardEventGroups, "G2 event group load error"},
+			{[]string{"0503"}, &g2.CardFaultGroups, "G2 fault group load error"},
+			{[]string{"0505"}, &g2.CardVehicleRecords, "G2 vehicle record load error"},
+			{[]string{"0504"}, &g2.ActivityDailyRecords, "G2 activity daily record load error"},
+			{[]string{"0506"}, &g2.PlaceRecords, "G2 place record load error"},
+			{[]string{"0508"}, &g2.CardControlActivityDataRecord, "G2 control activity daily record load error"},
+			{[]string{"0522"}, &g2.SpecificConditionRecord, "G2 specific condition record load error"},
+			{[]string{"0523"}, &g2.VehicleUnitRecords, "G2 vehicle unit record load error"},
+			{[]string{"0524"}, &g2.GNSSPlaceRecords, "G2 GNSS place record load error"},
+			{[]string{"0528"}, &g2.BorderCrossingRecords, "G2 border crossing record load error"},
+			{[]string{"0529"}, &g2.LoadUnloadRecords, "G2 load/unload record load error"},
+			{[]string{"0530"}, &g2.LoadTypeEntryRecords, "G2 load type entry record load error"},
+			{[]string{"0526"}, &placeAuth, "G2 place authentication load error"},
+			{[]string{"0527"}, &gnssAuth, "G2 GNSS place authentication load error"},
+		}, gen2Records(TlvCardMap), gen2TagSuffix)
+		// карты версии 2 содержат дополнительное описание приложения
+		if _, ok := TlvCardMap["0525"+gen2TagSuffix]; ok {
+			g2.ApplicationV2 = &ApplicationIdentificationV2{}
+			load([]sectionLoader{
+				{[]string{"0525"}, g2.ApplicationV2, "Error G2 application identification V2 load"},
+			}, gen2Records(TlvCardMap), gen2TagSuffix)
+		}
+		g2.applyAuthStatuses(placeAuth, gnssAuth)
+		g2.CardEventRecords = g2.CardEventGroups.Records()
+		g2.CardFaultRecords = g2.CardFaultGroups.Records()
+		g2.VehicleUsage = g2.CardVehicleRecords.Usage(g2.ActivityDailyRecords)
+		g2.WorkShifts = g2.PlaceRecords.WorkShifts()
+		g2.SpecificConditionIntervals = g2.SpecificConditionRecord.Intervals()
+		g2.Certificates = decodeCardG2Certificates(TlvCardMap, &c.Report)
+		c.G2 = g2
 	}
 
-	if err = loadFields(&c.SpecificConditionRecord, TlvCardMap); err != nil {
-		return fmt.Errorf("Specific condition record load error: %v", err)
+	if opts.Ruleset == RulesetRU424 && opts.RU424 != nil {
+		report := c.AnalyzeRU424WithOptions(*opts.RU424)
+		c.Compliance = &report
+	} else if opts.Ruleset != "" {
+		report, err := c.AnalyzeCompliance(opts.Ruleset)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err == nil {
+			c.Compliance = &report
+		}
+	}
+	if opts.WorkingTime != nil {
+		report := c.AnalyzeWorkingTime(*opts.WorkingTime)
+		c.WorkingTime = &report
+	}
+	if opts.CountryDays != nil {
+		report := c.AnalyzeCountryDays(*opts.CountryDays)
+		c.CountryDays = &report
+	}
+	if opts.Consistency {
+		report := c.CheckConsistency()
+		c.Consistency = &report
 	}
 
-	return err
+	return firstErr
 }
 
 // метод для экспорта объекта ddd
-func (c *card) ExportToJson() (string, error) {
+func (c *Card) ExportToJson() (string, error) {
 	ddd_json, err := json.Marshal(c)
 
 	return string(ddd_json), err
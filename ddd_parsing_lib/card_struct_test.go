@@ -0,0 +1,80 @@
+package ddd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseBytes(t *testing.T) {
+	c, err := ParseBytes(buildDriverDDD())
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if c.Card.CardNumber != "D1234567890" || c.Driver.HolderSurname != "DOE" || c.Driver.HolderFirstNames != "JOHN" {
+		t.Errorf("unexpected card identification %+v %+v", c.Card, c.Driver)
+	}
+	if len(c.ActivityDailyRecords) != 5 || len(c.CardVehicleRecords) != 5 || len(c.PlaceRecords) != 10 {
+		t.Errorf("unexpected record counts: %d activity, %d vehicles, %d places",
+			len(c.ActivityDailyRecords), len(c.CardVehicleRecords), len(c.PlaceRecords))
+	}
+	if len(c.CardVehicleRecords) > 0 && (c.CardVehicleRecords[0].VehicleRegistrationNumber != "AB123" ||
+		!c.CardVehicleRecords[0].VehicleFirstUse.Equal(day0.Add(6*time.Hour))) {
+		t.Errorf("unexpected vehicle record %+v", c.CardVehicleRecords[0])
+	}
+}
+
+func TestParse(t *testing.T) {
+	ddd := buildDriverDDD()
+	fromReader, err := Parse(bytes.NewReader(ddd))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	fromBytes, _ := ParseBytes(ddd)
+
+	got, err := fromReader.ExportToJson()
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	expected, _ := fromBytes.ExportToJson()
+	if got != expected {
+		t.Errorf("Parse and ParseBytes results differ")
+	}
+	if !json.Valid([]byte(got)) {
+		t.Errorf("invalid json %s", got)
+	}
+}
+
+func TestParseControlActivityRecord(t *testing.T) {
+	control := day0.Add(9 * time.Hour)
+	// CardControlActivityDataRecord: тип контроля, время, номер карты контролера,
+	// номер ТС, начало и окончание периода выгрузки (46 байт)
+	record := append([]byte{0x12}, ts(control)...)
+	record = append(record, 0x03, 0x11)
+	record = append(record, strField("C000012345678901", 16)...)
+	record = append(record, 0x0D, 0x01)
+	record = append(record, strField("B-XY 123", 13)...)
+	record = append(record, ts(day0)...)
+	record = append(record, ts(day0.AddDate(0, 0, 1))...)
+
+	ddd := append(buildDriverDDD(), tlv([]byte{0x05, 0x08, 0x00}, record)...)
+	c, err := ParseBytes(ddd)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	r := c.CardControlActivityDataRecord
+	if len(r) != 1 {
+		t.Fatalf("expected 1 control record, got %+v", r)
+	}
+	if r[0].ControlTypeId != 0x12 || !r[0].ControlTime.Equal(control) || r[0].CardTypeId != 3 ||
+		r[0].CardIssuingMemberState != 0x11 || r[0].ControlCardNumber != "C000012345678901" {
+		t.Errorf("unexpected control data %+v", r[0])
+	}
+	if r[0].VehicleRegistrationNation != 0x0D || r[0].VehicleRegistrationNumber != "B-XY 123" {
+		t.Errorf("unexpected vehicle registration %d %q", r[0].VehicleRegistrationNation, r[0].VehicleRegistrationNumber)
+	}
+	if !r[0].ControlDownloadPeriodBegin.Equal(day0) || !r[0].ControlDownloadPeriodEnd.Equal(day0.AddDate(0, 0, 1)) {
+		t.Errorf("unexpected download period %v - %v", r[0].ControlDownloadPeriodBegin, r[0].ControlDownloadPeriodEnd)
+	}
+}
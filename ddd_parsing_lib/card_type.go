@@ -0,0 +1,131 @@
+package ddd
+
+import (
+	"encoding/json"
+)
+
+// Тип карты тахографа (TypeOfTachographCardId из EF_Application_Identification)
+type CardType int
+
+const (
+	CardTypeUnknown  CardType = 0
+	CardTypeDriver   CardType = 1
+	CardTypeWorkshop CardType = 2
+	CardTypeControl  CardType = 3
+	CardTypeCompany  CardType = 4
+)
+
+var cardTypeNames = map[CardType]string{
+	CardTypeUnknown:  "unknown",
+	CardTypeDriver:   "driver",
+	CardTypeWorkshop: "workshop",
+	CardTypeControl:  "control",
+	CardTypeCompany:  "company",
+}
+
+func (t CardType) String() string {
+	if name, ok := cardTypeNames[t]; ok {
+		return name
+	}
+	return cardTypeNames[CardTypeUnknown]
+}
+
+func (t CardType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Функция определяет тип карты по EF_Application_Identification. Для карт только
+// с приложением второго поколения используется EF_Application_Identification приложения G2.
+func detectCardType(tlvRecords map[string][]byte) CardType {
+	for _, tag := range []string{"0501", "0501" + gen2TagSuffix} {
+		if appId, ok := tlvRecords[tag]; ok && len(appId) > 0 {
+			if t := CardType(appId[0]); t >= CardTypeDriver && t <= CardTypeCompany {
+				return t
+			}
+			return CardTypeUnknown
+		}
+	}
+	return CardTypeUnknown
+}
+
+// Секции (EF) первого поколения, общие для всех типов карт
+var cardCommonSections = []string{"0002", "0005", "0501", "0520", "C100", "C108", "C200", "C208"}
+
+// Секции (EF) первого поколения, которые есть только на картах определенного типа
+var cardTypeSections = map[CardType][]string{
+	CardTypeDriver:   {"0502", "0503", "0504", "0505", "0506", "0507", "0508", "050E", "0521", "0522"},
+	CardTypeWorkshop: {"0502", "0503", "0504", "0505", "0506", "0507", "0508", "0509", "050A", "050B", "0522"},
+	CardTypeControl:  {"050C"},
+	CardTypeCompany:  {"050C"},
+}
+
+// Названия секций, которые отличаются у карт разных типов
+var cardTypeSectionNames = map[CardType]map[string]string{
+	CardTypeWorkshop: workshopSectionNames,
+	CardTypeControl:  controlSectionNames,
+	CardTypeCompany:  companySectionNames,
+}
+
+// Функция возвращает названия секций первого поколения для карты типа t.
+// Карта неизвестного типа разбирается как карта водителя.
+func cardSchemaSectionNames(t CardType) map[string]string {
+	if _, ok := cardTypeSections[t]; !ok {
+		t = CardTypeDriver
+	}
+
+	result := map[string]string{}
+	for _, tag := range append(append([]string{}, cardCommonSections...), cardTypeSections[t]...) {
+		if name, ok := cardTypeSectionNames[t][tag]; ok {
+			result[tag] = name
+		} else {
+			result[tag] = cardSectionNames[tag]
+		}
+	}
+	return result
+}
+
+// Представление карты для JSON: данные, которых нет на картах данного типа, не выводятся
+type cardAlias Card
+
+type cardJsonView struct {
+	*cardAlias
+	SessionOpen                   *SessionOpen                    `json:",omitempty"`
+	Driver                        *Driver                         `json:",omitempty"`
+	DLicense                      *DLicense                       `json:",omitempty"`
+	CardVehicleRecords            *CardVehicleRecords             `json:",omitempty"`
+	VehicleUsage                  *VehicleUsages                  `json:",omitempty"`
+	ActivityDailyRecords          *ActivityDailyRecords           `json:",omitempty"`
+	PlaceRecords                  *PlaceRecords                   `json:",omitempty"`
+	WorkShifts                    *WorkShifts                     `json:",omitempty"`
+	CardEventGroups               *CardEventGroups                `json:",omitempty"`
+	CardFaultGroups               *CardFaultGroups                `json:",omitempty"`
+	CardControlActivityDataRecord *CardControlActivityDataRecords `json:",omitempty"`
+	SpecificConditionRecord       *SpecificConditionRecords       `json:",omitempty"`
+	SpecificConditionIntervals    *SpecificConditionIntervals     `json:",omitempty"`
+}
+
+// Метод формирует JSON карты. Для карт мастерской, контрольных карт и карт предприятия
+// поля с данными водителя и его деятельности, которых нет на этих картах, не выводятся.
+func (c Card) MarshalJSON() ([]byte, error) {
+	switch c.Type {
+	case CardTypeWorkshop:
+		return json.Marshal(cardJsonView{
+			cardAlias:                     (*cardAlias)(&c),
+			SessionOpen:                   &c.SessionOpen,
+			CardVehicleRecords:            &c.CardVehicleRecords,
+			VehicleUsage:                  &c.VehicleUsage,
+			ActivityDailyRecords:          &c.ActivityDailyRecords,
+			PlaceRecords:                  &c.PlaceRecords,
+			WorkShifts:                    &c.WorkShifts,
+			CardEventGroups:               &c.CardEventGroups,
+			CardFaultGroups:               &c.CardFaultGroups,
+			CardControlActivityDataRecord: &c.CardControlActivityDataRecord,
+			SpecificConditionRecord:       &c.SpecificConditionRecord,
+			SpecificConditionIntervals:    &c.SpecificConditionIntervals,
+		})
+	case CardTypeControl, CardTypeCompany:
+		return json.Marshal(cardJsonView{cardAlias: (*cardAlias)(&c)})
+	default:
+		return json.Marshal((*cardAlias)(&c))
+	}
+}
@@ -0,0 +1,55 @@
+package ddd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectCardType(t *testing.T) {
+	tests := []struct {
+		name       string
+		tlvRecords map[string][]byte
+		expected   CardType
+	}{
+		{"driver", map[string][]byte{"0501": {1}}, CardTypeDriver},
+		{"company", map[string][]byte{"0501": {4}}, CardTypeCompany},
+		{"gen2 only", map[string][]byte{"050102": {2}}, CardTypeWorkshop},
+		{"gen1 first", map[string][]byte{"0501": {3}, "050102": {1}}, CardTypeControl},
+		{"invalid", map[string][]byte{"0501": {7}}, CardTypeUnknown},
+		{"empty", map[string][]byte{"0501": {}}, CardTypeUnknown},
+		{"missing", map[string][]byte{}, CardTypeUnknown},
+	}
+	for _, tt := range tests {
+		if got := detectCardType(tt.tlvRecords); got != tt.expected {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCardTypeJSON(t *testing.T) {
+	c, _ := ParseBytes(buildControlDDD(4))
+	js, _ := c.ExportToJson()
+	for _, bad := range []string{"\"Driver\"", "\"SessionOpen\"", "\"CardVehicleRecords\"", "\"Control\""} {
+		if strings.Contains(js, bad) {
+			t.Errorf("company card JSON has %s", bad)
+		}
+	}
+	if !strings.Contains(js, "\"Type\":\"company\"") || !strings.Contains(js, "\"Company\"") {
+		t.Errorf("unexpected company card JSON %s", js)
+	}
+	for _, s := range c.Report.Sections {
+		if s.Tag == "0504" {
+			t.Error("driver activity section in company card report")
+		}
+	}
+	c, _ = ParseBytes(buildWorkshopDDD())
+	js, _ = c.ExportToJson()
+	if strings.Contains(js, "\"Driver\"") || !strings.Contains(js, "\"ActivityDailyRecords\"") || !strings.Contains(js, "\"workshop\"") {
+		t.Errorf("unexpected workshop card JSON %s", js)
+	}
+	c, _ = ParseBytes(buildDriverDDD())
+	js, _ = c.ExportToJson()
+	if !strings.Contains(js, "\"Driver\"") || !strings.Contains(js, "\"driver\"") {
+		t.Errorf("unexpected driver card JSON %s", js)
+	}
+}
@@ -0,0 +1,66 @@
+package ddd
+
+import (
+	"time"
+)
+
+// Структуры карты мастерской первого поколения (приложение 1B)
+
+// Данные владельца карты мастерской (EF_Identification)
+type WorkshopCardHolder struct {
+	WorkshopName                string `tlv:"0520 36 65 string" json:"workshop_name"`
+	WorkshopAddress             string `tlv:"0520 36 101 string" json:"workshop_address"`
+	HolderSurname               string `tlv:"0520 36 137 string" json:"holder_surname"`
+	HolderFirstNames            string `tlv:"0520 36 173 string" json:"holder_first_names"`
+	CardHolderPreferredLanguage string `tlv:"0520 2 209 string" json:"card_holder_preferred_language"`
+}
+
+// Сводные данные о калибровках (заголовок EF_Calibration и EF_Card_Download карты мастерской)
+type WorkshopCalibrationData struct {
+	CalibrationTotalNumber         int `tlv:"050A 2 0 int" json:"calibration_total_number"`
+	CalibrationPointerNewestRecord int `tlv:"050A 1 2 int" json:"calibration_pointer_newest_record"`
+	NoOfCalibrationsSinceDownload  int `tlv:"0509 2 0 int 0" json:"no_of_calibrations_since_download"`
+}
+
+type WorkshopCalibrationRecord struct {
+	CalibrationPurpose             int         `tlv:"050A 1 0 int" json:"calibration_purpose"`
+	VehicleIdentificationNumber    string      `tlv:"050A 17 1 string" json:"vehicle_identification_number"`
+	VehicleRegistrationNation      int         `tlv:"050A 1 18 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso   *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber      string      `tlv:"050A 14 19 string" json:"vehicle_registration_number"`
+	WVehicleCharacteristicConstant int         `tlv:"050A 2 33 int" json:"w_vehicle_characteristic_constant"`
+	KConstantOfRecordingEquipment  int         `tlv:"050A 2 35 int" json:"k_constant_of_recording_equipment"`
+	LTyreCircumference             int         `tlv:"050A 2 37 int" json:"l_tyre_circumference"`
+	TyreSize                       string      `tlv:"050A 15 39 string" json:"tyre_size"`
+	AuthorisedSpeed                int         `tlv:"050A 1 54 int" json:"authorised_speed"`
+	OldOdometerValue               int         `tlv:"050A 3 55 int" json:"old_odometer_value"`
+	NewOdometerValue               int         `tlv:"050A 3 58 int" json:"new_odometer_value"`
+	OldTimeValue                   time.Time   `tlv:"050A 4 61 date" json:"old_time_value"`
+	NewTimeValue                   time.Time   `tlv:"050A 4 65 date" json:"new_time_value"`
+	NextCalibrationDate            time.Time   `tlv:"050A 4 69 date" json:"next_calibration_date"`
+	VuPartNumber                   string      `tlv:"050A 16 73 string" json:"vu_part_number"`
+	VuSerialNumber                 string      `tlv:"050A 8 89 hexadecimal" json:"vu_serial_number"`
+	SensorSerialNumber             string      `tlv:"050A 8 97 hexadecimal" json:"sensor_serial_number"`
+}
+
+type WorkshopCalibrationRecords []WorkshopCalibrationRecord
+
+// Данные для сопряжения датчика движения (EF_Sensor_Installation_Data),
+// хранятся в зашифрованном виде
+type SensorInstallationData struct {
+	SensorInstallationSecData string `tlv:"050B 16 0 hexadecimal" json:"sensor_installation_sec_data"`
+}
+
+type WorkshopCard struct {
+	Holder             WorkshopCardHolder
+	Calibration        WorkshopCalibrationData
+	CalibrationRecords WorkshopCalibrationRecords
+	SensorInstallation SensorInstallationData
+}
+
+// Названия секций (EF) карты мастерской
+var workshopSectionNames = map[string]string{
+	"0509": "EF_Card_Download (Workshop)",
+	"050A": "EF_Calibration",
+	"050B": "EF_Sensor_Installation_Data",
+}
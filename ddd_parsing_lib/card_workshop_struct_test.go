@@ -0,0 +1,85 @@
+package ddd
+
+import "testing"
+
+// Функция формирует выгрузку карты мастерской первого поколения с двумя записями калибровки
+func buildWorkshopDDD() []byte {
+	var out []byte
+	out = append(out, tlv([]byte{0x00, 0x02, 0x00}, make([]byte, 25))...)
+	out = append(out, tlv([]byte{0x00, 0x05, 0x00}, []byte{1, 2, 3, 4, 5, 6, 7, 8})...)
+	out = append(out, tlv([]byte{0x05, 0x01, 0x00}, []byte{0x02, 0x00, 0x02, 0x03, 0x00, 0x0C, 0x00, 0x04, 0x00, 0x06, 0x02})...)
+	ident := make([]byte, 211)
+	ident[0] = 0x11
+	copy(ident[1:], strField("W1234567890", 16))
+	copy(ident[65:], append([]byte{0x01}, strField("ACME TACHO", 35)...))
+	copy(ident[101:], append([]byte{0x01}, strField("MAIN ST 1", 35)...))
+	copy(ident[137:], append([]byte{0x01}, strField("SMITH", 35)...))
+	copy(ident[173:], append([]byte{0x01}, strField("JOHN", 35)...))
+	copy(ident[209:], "en")
+	out = append(out, tlv([]byte{0x05, 0x20, 0x00}, ident)...)
+	out = append(out, tlv([]byte{0x05, 0x09, 0x00}, []byte{0, 2})...)
+	cal := make([]byte, 3+105*4)
+	cal[1] = 2
+	cal[2] = 1
+	for i := 0; i < 2; i++ {
+		r := cal[3+105*i:]
+		r[0] = byte(i + 1)
+		copy(r[1:], "WDB12345678901234")
+		r[18] = 0x11
+		copy(r[19:], append([]byte{0x01}, strField("AB123", 13)...))
+		r[33], r[34] = 0x1F, 0x40
+		copy(r[39:], strField("315/70 R22.5", 15))
+		r[54] = 90
+		r[57] = 100
+		r[60] = byte(200 + i)
+		copy(r[65:], ts(day0))
+		copy(r[73:], strField("VU-PART", 16))
+	}
+	out = append(out, tlv([]byte{0x05, 0x0A, 0x00}, cal)...)
+	out = append(out, tlv([]byte{0x05, 0x0B, 0x00}, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})...)
+	out = append(out, tlv([]byte{0x05, 0x02, 0x00}, make([]byte, 432))...)
+	out = append(out, tlv([]byte{0x05, 0x03, 0x00}, make([]byte, 288))...)
+	out = append(out, tlv([]byte{0x05, 0x04, 0x00}, buildActivity(1))...)
+	out = append(out, tlv([]byte{0x05, 0x05, 0x00}, make([]byte, 126))...)
+	out = append(out, tlv([]byte{0x05, 0x06, 0x00}, make([]byte, 61))...)
+	out = append(out, tlv([]byte{0x05, 0x07, 0x00}, make([]byte, 19))...)
+	out = append(out, tlv([]byte{0x05, 0x08, 0x00}, make([]byte, 46))...)
+	out = append(out, tlv([]byte{0x05, 0x22, 0x00}, make([]byte, 10))...)
+	return out
+}
+
+func TestParseWorkshopCard(t *testing.T) {
+	f := buildWorkshopDDD()
+	c, err := ParseBytes(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := c.Workshop
+	if w == nil || w.Holder.WorkshopName != "ACME TACHO" || w.Holder.HolderSurname != "SMITH" || w.Calibration.CalibrationTotalNumber != 2 ||
+		w.Calibration.NoOfCalibrationsSinceDownload != 2 || len(w.CalibrationRecords) != 2 || w.CalibrationRecords[1].NewOdometerValue != 201 ||
+		w.CalibrationRecords[0].TyreSize != "315/70 R22.5" || w.CalibrationRecords[0].WVehicleCharacteristicConstant != 8000 {
+		t.Fatalf("unexpected workshop card data %+v", w)
+	}
+	if c.Driver.HolderSurname != "" {
+		t.Errorf("driver data filled from workshop card: %+v", c.Driver)
+	}
+	for _, s := range c.Report.Sections {
+		if s.Found && s.Status != SectionDecoded {
+			t.Errorf("section is not decoded: %+v", s)
+		}
+	}
+	if _, ok := c.Report.Section("050A"); !ok {
+		t.Error("calibration section is not in report")
+	}
+
+	for n := range f {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on file truncated to %d bytes: %v", n, r)
+				}
+			}()
+			ParseBytes(f[:n])
+		}()
+	}
+}
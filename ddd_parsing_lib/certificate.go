@@ -0,0 +1,354 @@
+package ddd
+
+import (
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Разобранный сертификат карты или удостоверяющего центра
+type Certificate struct {
+	// ссылка на владельца сертификата (CHR)
+	HolderReference string `json:"holder_reference,omitempty"`
+	// ссылка на удостоверяющий центр, выпустивший сертификат (CAR)
+	AuthorityReference string `json:"authority_reference,omitempty"`
+	// государство удостоверяющего центра из CAR
+	AuthorityNation string `json:"authority_nation,omitempty"`
+	// полномочия владельца (CHA) и тип оборудования из них
+	HolderAuthorisation string `json:"holder_authorisation,omitempty"`
+	EquipmentType       int    `json:"equipment_type,omitempty"`
+	// окончание срока действия (EOV), не заполняется для бессрочных сертификатов
+	EndOfValidity *time.Time `json:"end_of_validity,omitempty"`
+	// алгоритм и открытый ключ владельца
+	PublicKeyAlgorithm string `json:"public_key_algorithm,omitempty"`
+	PublicKey          string `json:"public_key,omitempty"`
+	PublicKeyExponent  int    `json:"public_key_exponent,omitempty"`
+	// для сертификатов ЕСТР: удалось ли восстановить подписанную часть сертификата.
+	// Без ключа издателя доступна только ссылка на УЦ.
+	Recovered bool `json:"recovered"`
+}
+
+// Сертификаты карты первого поколения
+type CardCertificates struct {
+	Card     *Certificate `json:"card,omitempty"`
+	CA       *Certificate `json:"ca,omitempty"`
+	CardGost *Certificate `json:"card_gost,omitempty"`
+	CAGost   *Certificate `json:"ca_gost,omitempty"`
+}
+
+// Сертификаты карты второго поколения
+type CardG2Certificates struct {
+	Card     *Certificate `json:"card,omitempty"`
+	CardSign *Certificate `json:"card_sign,omitempty"`
+	CA       *Certificate `json:"ca,omitempty"`
+	Link     *Certificate `json:"link,omitempty"`
+}
+
+// Смещения полей в восстановленном содержимом сертификата ЕСТР
+const (
+	estrCertCarOffset = 1
+	estrCertChaOffset = 9
+	estrCertEovOffset = 16
+	estrCertChrOffset = 20
+	estrKIDLen        = 8
+	certChaLen        = 7
+)
+
+// Функция возвращает время окончания действия сертификата, 0xFFFFFFFF означает бессрочный сертификат
+func certificateEndOfValidity(b []byte) *time.Time {
+	v := binary.BigEndian.Uint32(b)
+	if v == 0xFFFFFFFF {
+		return nil
+	}
+	t := time.Unix(int64(v), 0).UTC()
+	return &t
+}
+
+// Функция возвращает код государства из идентификатора ключа УЦ:
+// nationNumeric (1 байт), nationAlpha (3 байта), keySerialNumber, additionalInfo, caIdentifier
+func keyIdentifierNation(kid []byte) string {
+	if len(kid) < 4 {
+		return ""
+	}
+	return strings.TrimSpace(string(kid[1:4]))
+}
+
+// Метод заполняет полномочия владельца (CHA): идентификатор приложения и тип оборудования
+func (c *Certificate) setAuthorisation(cha []byte) {
+	c.HolderAuthorisation = hex.EncodeToString(cha)
+	if len(cha) == certChaLen {
+		c.EquipmentType = int(cha[certChaLen-1])
+	}
+}
+
+// Функция разбирает сертификат ЕСТР. Если известен ключ издателя, подписанная часть
+// сертификата восстанавливается, иначе заполняется только ссылка на УЦ (CAR').
+func decodeEstrCertificate(cert []byte, issuer *rsa.PublicKey) (*Certificate, []byte, error) {
+	if len(cert) != estrCertificateLen {
+		return nil, nil, fmt.Errorf("Invalid certificate length: %d", len(cert))
+	}
+
+	car := cert[estrCertificateLen-estrKIDLen:]
+	c := &Certificate{
+		AuthorityReference: hex.EncodeToString(car),
+		AuthorityNation:    keyIdentifierNation(car),
+		PublicKeyAlgorithm: "RSA",
+	}
+	if issuer == nil {
+		return c, nil, nil
+	}
+
+	// если подпись не соответствует ключу издателя, доступна только ссылка на УЦ
+	content, err := recoverEstrCertificate(cert, issuer)
+	if err != nil {
+		return c, nil, nil
+	}
+
+	c.Recovered = true
+	c.setAuthorisation(content[estrCertChaOffset : estrCertChaOffset+certChaLen])
+	c.EndOfValidity = certificateEndOfValidity(content[estrCertEovOffset:estrCertChrOffset])
+	c.HolderReference = hex.EncodeToString(content[estrCertChrOffset:estrCertModulusOffset])
+	c.PublicKey = hex.EncodeToString(content[estrCertModulusOffset:estrCertExponentOffset])
+	key, err := estrCertificateKey(content)
+	if err != nil {
+		return c, content, err
+	}
+	c.PublicKeyExponent = key.E
+
+	return c, content, nil
+}
+
+// Функция разбирает сертификат X.509 карты СКЗИ
+func decodeGostCertificate(der []byte) (*Certificate, error) {
+	_, tbs, err := parseGostCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	var issuer, subject pkix.RDNSequence
+	if _, err := asn1.Unmarshal(tbs.Issuer.FullBytes, &issuer); err != nil {
+		return nil, fmt.Errorf("Invalid certificate issuer: %v", err)
+	}
+	if _, err := asn1.Unmarshal(tbs.Subject.FullBytes, &subject); err != nil {
+		return nil, fmt.Errorf("Invalid certificate subject: %v", err)
+	}
+	var issuerName pkix.Name
+	issuerName.FillFromRDNSequence(&issuer)
+
+	c := &Certificate{
+		HolderReference:    subject.String(),
+		AuthorityReference: issuer.String(),
+		PublicKeyAlgorithm: tbs.PublicKey.Algorithm.Algorithm.String(),
+		Recovered:          true,
+	}
+	if len(issuerName.Country) > 0 {
+		c.AuthorityNation = issuerName.Country[0]
+	}
+
+	var notAfter time.Time
+	if _, err := asn1.Unmarshal(tbs.Validity.NotAfter.FullBytes, &notAfter); err == nil {
+		notAfter = notAfter.UTC()
+		c.EndOfValidity = &notAfter
+	}
+
+	key, err := tbs.publicKey()
+	if err != nil {
+		return c, err
+	}
+	point := make([]byte, 2*gostPointLen)
+	key.X.FillBytes(point[:gostPointLen])
+	key.Y.FillBytes(point[gostPointLen:])
+	c.PublicKey = hex.EncodeToString(point)
+
+	return c, nil
+}
+
+// Функция читает элемент BER-TLV и возвращает тэг, значение и остаток данных
+func readBerTlv(b []byte) (int, []byte, []byte, error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("Truncated BER-TLV")
+	}
+
+	// тэг: многобайтный, если младшие 5 бит первого байта равны 1
+	tag := int(b[0])
+	i := 1
+	if b[0]&0x1F == 0x1F {
+		for {
+			if i >= len(b) {
+				return 0, nil, nil, errors.New("Truncated BER-TLV tag")
+			}
+			tag = tag<<8 | int(b[i])
+			i++
+			if b[i-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+
+	if i >= len(b) {
+		return 0, nil, nil, errors.New("Truncated BER-TLV length")
+	}
+	length := int(b[i])
+	i++
+	if length&0x80 != 0 {
+		n := length & 0x7F
+		if n == 0 || n > 3 || i+n > len(b) {
+			return 0, nil, nil, errors.New("Invalid BER-TLV length")
+		}
+		length = 0
+		for _, v := range b[i : i+n] {
+			length = length<<8 | int(v)
+		}
+		i += n
+	}
+
+	if i+length > len(b) {
+		return 0, nil, nil, fmt.Errorf("Truncated BER-TLV value [tag:%X expected:%d actual:%d]", tag, length, len(b)-i)
+	}
+
+	return tag, b[i : i+length], b[i+length:], nil
+}
+
+// Функция разбирает содержимое составного BER-TLV элемента в карту тэг - значение
+func readBerTlvMap(b []byte) (map[int][]byte, error) {
+	result := map[int][]byte{}
+	for len(b) > 0 {
+		tag, val, rest, err := readBerTlv(b)
+		if err != nil {
+			return result, err
+		}
+		result[tag] = val
+		b = rest
+	}
+	return result, nil
+}
+
+// Тэги сертификата второго поколения (приложение 1C, дополнение 11)
+const (
+	cvTagCertificate = 0x7F21
+	cvTagBody        = 0x7F4E
+	cvTagCar         = 0x42
+	cvTagCha         = 0x5F4C
+	cvTagPublicKey   = 0x7F49
+	cvTagDomainParam = 0x06
+	cvTagPoint       = 0x86
+	cvTagChr         = 0x5F20
+	cvTagExpiration  = 0x5F24
+)
+
+// Функция разбирает сертификат второго поколения (card verifiable certificate).
+// Файл сертификата на карте может быть дополнен незначащими байтами после сертификата.
+func decodeCVCertificate(b []byte) (*Certificate, error) {
+	tag, val, _, err := readBerTlv(b)
+	if err != nil {
+		return nil, err
+	}
+	if tag != cvTagCertificate {
+		return nil, fmt.Errorf("Invalid certificate tag: %X", tag)
+	}
+
+	cert, err := readBerTlvMap(val)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readBerTlvMap(cert[cvTagBody])
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Certificate{
+		HolderReference:    hex.EncodeToString(body[cvTagChr]),
+		AuthorityReference: hex.EncodeToString(body[cvTagCar]),
+		AuthorityNation:    keyIdentifierNation(body[cvTagCar]),
+		Recovered:          true,
+	}
+	c.setAuthorisation(body[cvTagCha])
+	if exp := body[cvTagExpiration]; len(exp) == 4 {
+		c.EndOfValidity = certificateEndOfValidity(exp)
+	}
+
+	key, err := readBerTlvMap(body[cvTagPublicKey])
+	if err != nil {
+		return c, err
+	}
+	var oid asn1.ObjectIdentifier
+	// идентификатор параметров кривой хранится без ASN.1 заголовка
+	if _, err := asn1.Unmarshal(append([]byte{0x06, byte(len(key[cvTagDomainParam]))}, key[cvTagDomainParam]...), &oid); err == nil {
+		c.PublicKeyAlgorithm = oid.String()
+	}
+	c.PublicKey = hex.EncodeToString(key[cvTagPoint])
+
+	return c, nil
+}
+
+// Функция разбирает сертификаты карты первого поколения. Сертификаты ЕСТР восстанавливаются
+// по цепочке, если задан ключ ERCA. Ошибки разбора записываются в отчет.
+func decodeCardCertificates(tlvRecords map[string][]byte, opts ParseOptions, report *ParseReport) CardCertificates {
+	result := CardCertificates{}
+
+	if cert, ok := tlvRecords["C108"]; ok {
+		var content []byte
+		var err error
+		result.CA, content, err = decodeEstrCertificate(cert, opts.EuropeanRootKey)
+		if err != nil {
+			report.setStatus("C108", SectionPartial, err.Error())
+		}
+
+		var caKey *rsa.PublicKey
+		if content != nil {
+			caKey, _ = estrCertificateKey(content)
+		}
+		if cardCert, ok := tlvRecords["C100"]; ok {
+			result.Card, _, err = decodeEstrCertificate(cardCert, caKey)
+			if err != nil {
+				report.setStatus("C100", SectionPartial, err.Error())
+			}
+		}
+	} else if cert, ok := tlvRecords["C100"]; ok {
+		var err error
+		result.Card, _, err = decodeEstrCertificate(cert, nil)
+		if err != nil {
+			report.setStatus("C100", SectionPartial, err.Error())
+		}
+	}
+
+	for tag, target := range map[string]**Certificate{"C200": &result.CardGost, "C208": &result.CAGost} {
+		if cert, ok := tlvRecords[tag]; ok {
+			var err error
+			*target, err = decodeGostCertificate(cert)
+			if err != nil {
+				report.setStatus(tag, SectionPartial, err.Error())
+			}
+		}
+	}
+
+	return result
+}
+
+// Функция разбирает сертификаты карты второго поколения
+func decodeCardG2Certificates(tlvRecords map[string][]byte, report *ParseReport) CardG2Certificates {
+	result := CardG2Certificates{}
+
+	for tag, target := range map[string]**Certificate{
+		"C100": &result.Card,
+		"C101": &result.CardSign,
+		"C108": &result.CA,
+		"C109": &result.Link,
+	} {
+		if cert, ok := tlvRecords[tag+gen2TagSuffix]; ok {
+			var err error
+			*target, err = decodeCVCertificate(cert)
+			if err != nil {
+				report.setStatus(tag+gen2TagSuffix, SectionPartial, err.Error())
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package ddd
+
+import "testing"
+
+func TestDecodeGostCertificates(t *testing.T) {
+	f, _ := buildGostDDD(t, oidGostR3410_2012_256, false)
+	c, _ := ParseBytes(f)
+	cc := c.Certificates.CardGost
+	if cc == nil || cc.AuthorityNation != "RU" || cc.HolderReference != "CN=card" || cc.EndOfValidity == nil ||
+		cc.EndOfValidity.Year() != 2030 || len(cc.PublicKey) != 128 {
+		t.Fatalf("unexpected card certificate %+v", cc)
+	}
+	if c.Certificates.CAGost == nil || c.Certificates.CAGost.HolderReference != "CN=ca" {
+		t.Errorf("unexpected CA certificate %+v", c.Certificates.CAGost)
+	}
+}
+
+// Функция формирует сертификат второго поколения (CV) с ключом на кривой brainpoolP256r1,
+// дополненный нулями до размера файла сертификата на карте
+func buildCVCertificate() []byte {
+	body := []byte{}
+	body = append(body, 0x5F, 0x29, 1, 0)
+	body = append(body, 0x42, 8, 0xFD, 'E', 'C', ' ', 1, 0xFF, 0xFF, 1)
+	body = append(body, 0x5F, 0x4C, 7, 0xFF, 'S', 'M', 'R', 'D', 'T', 1)
+	pk := []byte{0x06, 9, 0x2B, 0x24, 0x03, 0x03, 0x02, 0x08, 0x01, 0x01, 0x07, 0x86, 3, 4, 5, 6}
+	body = append(body, 0x7F, 0x49, byte(len(pk)))
+	body = append(body, pk...)
+	body = append(body, 0x5F, 0x20, 8, 1, 2, 3, 4, 5, 6, 7, 8)
+	body = append(body, 0x5F, 0x25, 4, 0x60, 0, 0, 0, 0x5F, 0x24, 4, 0x70, 0, 0, 0)
+	inner := append([]byte{0x7F, 0x4E, 0x81, byte(len(body))}, body...)
+	inner = append(inner, 0x5F, 0x37, 4, 1, 2, 3, 4)
+	cert := append([]byte{0x7F, 0x21, 0x81, byte(len(inner))}, inner...)
+	return append(cert, make([]byte, 20)...)
+}
+
+func TestDecodeCVCertificate(t *testing.T) {
+	cert := buildCVCertificate()
+	c, err := decodeCVCertificate(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AuthorityNation != "EC" || c.HolderReference != "0102030405060708" || c.EquipmentType != 1 ||
+		c.PublicKeyAlgorithm != "1.3.36.3.3.2.8.1.1.7" || c.PublicKey != "040506" || c.EndOfValidity == nil {
+		t.Errorf("unexpected certificate %+v", c)
+	}
+
+	for n := range cert {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on certificate truncated to %d bytes: %v", n, r)
+				}
+			}()
+			decodeCVCertificate(cert[:n])
+		}()
+	}
+}
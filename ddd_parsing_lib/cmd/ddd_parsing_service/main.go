@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	ddd "github.com/kuznetsovin/go_tachograph_card/ddd_parsing_lib"
+)
+
+// настройки разбора ddd файлов
+var parseOptions ddd.ParseOptions
+
+// Функция читает из запроса настройки расчета рабочего времени (длительность расчетного периода,
+// ночное время и часовой пояс, в котором оно задано)
+func workingTimeOptions(r *http.Request) (*ddd.WorkingTimeOptions, error) {
+	opts := &ddd.WorkingTimeOptions{}
+	if weeks := r.FormValue("reference_weeks"); weeks != "" {
+		n, err := strconv.Atoi(weeks)
+		if err != nil {
+			return nil, err
+		}
+		opts.ReferenceWeeks = n
+	}
+	for _, p := range []struct {
+		name   string
+		target *time.Duration
+	}{
+		{"night_start", &opts.NightStart},
+		{"night_end", &opts.NightEnd},
+	} {
+		value := r.FormValue(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse("15:04", value)
+		if err != nil {
+			return nil, err
+		}
+		*p.target = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	if timezone := r.FormValue("timezone"); timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, err
+		}
+		opts.Location = loc
+	}
+
+	return opts, nil
+}
+
+// обработчик парсинга
+func parseDDDHandler(w http.ResponseWriter, r *http.Request) {
+	// получаем строку base64 с ddd файлом
+	b64_ddd := r.FormValue("ddd")
+	dddFile, err := base64.StdEncoding.DecodeString(b64_ddd)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// набор правил проверки режима труда и отдыха и настройки расчета рабочего времени задаются в каждом запросе
+	opts := parseOptions
+	opts.Ruleset = ddd.Ruleset(r.FormValue("ruleset"))
+	if opts.Ruleset != "" && !opts.Ruleset.Valid() {
+		http.Error(w, "unknown ruleset: "+r.FormValue("ruleset"), http.StatusBadRequest)
+		return
+	}
+	// норма рабочего времени за неделю по приказу № 424 задается в часах
+	if hours := r.FormValue("weekly_working_time"); hours != "" {
+		h, err := strconv.ParseFloat(hours, 64)
+		if err != nil || h <= 0 {
+			http.Error(w, "invalid weekly_working_time: "+hours, http.StatusBadRequest)
+			return
+		}
+		opts.RU424 = &ddd.RU424Options{WeeklyWorkingTime: int(h * 60)}
+	}
+	workingTime, err := workingTimeOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// ночное время используется и в отчете о рабочем времени, и в распределении по странам
+	if r.FormValue("working_time") != "" {
+		opts.WorkingTime = workingTime
+	}
+	if r.FormValue("country_days") != "" {
+		opts.CountryDays = workingTime
+	}
+	opts.Consistency = r.FormValue("consistency") != ""
+
+	//разбираем пришедший ddd файл, по умолчанию считаем что это выгрузка карты
+	var ddd_json string
+	var parseErr error
+	switch r.FormValue("type") {
+	case "vu":
+		var v *ddd.Vu
+		v, parseErr = ddd.ParseVuBytes(dddFile)
+		ddd_json, err = v.ExportToJson()
+	default:
+		var c *ddd.Card
+		c, parseErr = ddd.ParseBytesWithOptions(dddFile, opts)
+		ddd_json, err = c.ExportToJson()
+	}
+	if parseErr != nil {
+		log.Printf("DDD pasre error: %v", parseErr)
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(ddd_json))
+}
+
+func main() {
+	// разбираем параметры запуска
+	defaultLogFile := os.Args[0] + ".log"
+
+	port := flag.String("port", ":8000", "service port")
+	logfile := flag.String("log", defaultLogFile, "log file")
+	ercaFile := flag.String("erca", "", "european root key file (EC_PK) for signature verification")
+	gostRootFile := flag.String("gost-root", "", "root certificate file (DER or PEM) for SKZI card signature verification")
+	flag.Parse()
+
+	// загружаем корневой ключ для проверки подписей
+	if *ercaFile != "" {
+		ecpk, err := ioutil.ReadFile(*ercaFile)
+		if err != nil {
+			log.Fatalf("error reading european root key: %v", err)
+		}
+		parseOptions.EuropeanRootKey, err = ddd.ParseEuropeanRootKey(ecpk)
+		if err != nil {
+			log.Fatalf("error parsing european root key: %v", err)
+		}
+	}
+	if *gostRootFile != "" {
+		rootCert, err := ioutil.ReadFile(*gostRootFile)
+		if err != nil {
+			log.Fatalf("error reading GOST root certificate: %v", err)
+		}
+		parseOptions.GostRootKey, err = ddd.ParseGostRootCertificate(rootCert)
+		if err != nil {
+			log.Fatalf("error parsing GOST root certificate: %v", err)
+		}
+	}
+
+	// настраиваем логгер
+	f, err := os.OpenFile(*logfile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		log.Fatalf("error opening log file: %v", err)
+	}
+	defer f.Close()
+	log.SetOutput(f)
+
+	// запускаем сервис
+	http.HandleFunc("/", parseDDDHandler)
+	http.ListenAndServe(*port, nil)
+}
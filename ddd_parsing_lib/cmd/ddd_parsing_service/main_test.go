@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWorkingTimeOptions(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?reference_weeks=26&night_start=22:00&night_end=06:00&timezone=Europe/Moscow", nil)
+	opts, err := workingTimeOptions(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ReferenceWeeks != 26 || opts.NightStart != 22*time.Hour || opts.NightEnd != 6*time.Hour {
+		t.Errorf("unexpected options %+v", opts)
+	}
+	if opts.Location == nil || opts.Location.String() != "Europe/Moscow" {
+		t.Errorf("unexpected location %v", opts.Location)
+	}
+
+	opts, err = workingTimeOptions(httptest.NewRequest("GET", "/", nil))
+	if err != nil || opts.Location != nil {
+		t.Errorf("unexpected default options %+v: %v", opts, err)
+	}
+}
+
+func TestParseDDDHandlerBadRequest(t *testing.T) {
+	for _, query := range []string{"timezone=Mars/Olympus", "night_start=25:00", "ruleset=foo", "weekly_working_time=x"} {
+		values, _ := url.ParseQuery(query)
+		values.Set("working_time", "1")
+		w := httptest.NewRecorder()
+		parseDDDHandler(w, httptest.NewRequest("GET", "/?"+values.Encode(), nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package ddd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Типизированные справочники кодов приложения 1B/1C. В JSON код выводится числом, а его
+// символьное имя, не зависящее от языка, - в соседнем поле с суффиксом _name.
+// Названия на русском и английском языках доступны через метод Label.
+
+// Языки названий кодов
+const (
+	LangEn = "en"
+	LangRu = "ru"
+)
+
+// Описание кода справочника: символьное имя и названия на английском и русском языках
+type codeLabel struct {
+	name string
+	en   string
+	ru   string
+}
+
+var unknownCode = codeLabel{"UNKNOWN", "Unknown", "Неизвестно"}
+
+// Функция возвращает описание кода из справочника table
+func lookupCode(table map[int]codeLabel, code int) codeLabel {
+	if l, ok := table[code]; ok {
+		return l
+	}
+	return unknownCode
+}
+
+// Метод возвращает название кода на языке lang (по умолчанию английский)
+func (l codeLabel) label(lang string) string {
+	if lang == LangRu {
+		return l.ru
+	}
+	return l.en
+}
+
+// Функция заполняет поля с подсказкой `code` символьными именами кодов. Формат подсказки:
+// `code:"<поле с кодом>"`, поле с кодом может быть указателем (пустой указатель пропускается).
+func fillCodeNames(structVal reflect.Value) {
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		codeField := structType.Field(i).Tag.Get("code")
+		if codeField == "" {
+			continue
+		}
+
+		code := structVal.FieldByName(codeField)
+		if code.Kind() == reflect.Ptr {
+			if code.IsNil() {
+				continue
+			}
+			code = code.Elem()
+		}
+		if stringer, ok := code.Interface().(fmt.Stringer); ok {
+			structVal.Field(i).SetString(stringer.String())
+		}
+	}
+}
+
+// Вид деятельности водителя (ActivityChangeInfo)
+type ActivityKind int
+
+const (
+	ActivityBreakRest    ActivityKind = 0
+	ActivityAvailability ActivityKind = 1
+	ActivityWork         ActivityKind = 2
+	ActivityDriving      ActivityKind = 3
+)
+
+var activityKindLabels = map[int]codeLabel{
+	0: {"BREAK_REST", "Break/rest", "Перерыв/отдых"},
+	1: {"AVAILABILITY", "Availability", "Готовность"},
+	2: {"WORK", "Work", "Работа"},
+	3: {"DRIVING", "Driving", "Управление"},
+}
+
+func (k ActivityKind) String() string {
+	return lookupCode(activityKindLabels, int(k)).name
+}
+
+func (k ActivityKind) Label(lang string) string {
+	return lookupCode(activityKindLabels, int(k)).label(lang)
+}
+
+// Тип записи о начале или окончании ежедневного периода работы (EntryTypeDailyWorkPeriod)
+type EntryTypeDailyWorkPeriod int
+
+const (
+	EntryBeginRelatedToCard EntryTypeDailyWorkPeriod = 0
+	EntryEndRelatedToCard   EntryTypeDailyWorkPeriod = 1
+	EntryBeginManual        EntryTypeDailyWorkPeriod = 2
+	EntryEndManual          EntryTypeDailyWorkPeriod = 3
+	EntryBeginAssumedByVu   EntryTypeDailyWorkPeriod = 4
+	EntryEndAssumedByVu     EntryTypeDailyWorkPeriod = 5
+)
+
+var entryTypeLabels = map[int]codeLabel{
+	0: {"BEGIN_CARD", "Begin, related time = card insertion time or time of entry",
+		"Начало, время установки карты или время ввода"},
+	1: {"END_CARD", "End, related time = card withdrawal time or time of entry",
+		"Окончание, время извлечения карты или время ввода"},
+	2: {"BEGIN_MANUAL", "Begin, related time manually entered",
+		"Начало, время введено вручную"},
+	3: {"END_MANUAL", "End, related time manually entered",
+		"Окончание, время введено вручную"},
+	4: {"BEGIN_ASSUMED_BY_VU", "Begin, related time assumed by VU",
+		"Начало, время определено бортовым устройством"},
+	5: {"END_ASSUMED_BY_VU", "End, related time assumed by VU",
+		"Окончание, время определено бортовым устройством"},
+}
+
+func (e EntryTypeDailyWorkPeriod) String() string {
+	return lookupCode(entryTypeLabels, int(e)).name
+}
+
+func (e EntryTypeDailyWorkPeriod) Label(lang string) string {
+	return lookupCode(entryTypeLabels, int(e)).label(lang)
+}
+
+// Метод возвращает true, если запись отмечает начало ежедневного периода работы
+func (e EntryTypeDailyWorkPeriod) IsBegin() bool {
+	return e%2 == 0
+}
+
+// Коды специальных условий (SpecificConditionType). На картах и бортовых устройствах первого
+// поколения переправа на пароме или поезде отмечается одной записью с кодом 3, окончания
+// переправы (кода 4) нет. Во втором поколении коды 3 и 4 - начало и окончание переправы.
+const (
+	ConditionOutOfScopeBegin = 1
+	ConditionOutOfScopeEnd   = 2
+	ConditionFerryTrain      = 3
+	ConditionFerryTrainBegin = 3
+	ConditionFerryTrainEnd   = 4
+)
+
+// Тип специального условия первого поколения
+type SpecificConditionType int
+
+var specificConditionLabels = map[int]codeLabel{
+	0: {"RFU", "RFU", "Зарезервировано"},
+	1: {"OUT_OF_SCOPE_BEGIN", "Out of scope - Begin", "Вне сферы действия - начало"},
+	2: {"OUT_OF_SCOPE_END", "Out of scope - End", "Вне сферы действия - окончание"},
+	3: {"FERRY_TRAIN_CROSSING", "Ferry/Train crossing", "Паром/поезд"},
+}
+
+func (s SpecificConditionType) String() string {
+	return lookupCode(specificConditionLabels, int(s)).name
+}
+
+func (s SpecificConditionType) Label(lang string) string {
+	return lookupCode(specificConditionLabels, int(s)).label(lang)
+}
+
+// Тип специального условия второго поколения
+type SpecificConditionTypeG2 int
+
+var specificConditionLabelsG2 = map[int]codeLabel{
+	0: {"RFU", "RFU", "Зарезервировано"},
+	1: {"OUT_OF_SCOPE_BEGIN", "Out of scope - Begin", "Вне сферы действия - начало"},
+	2: {"OUT_OF_SCOPE_END", "Out of scope - End", "Вне сферы действия - окончание"},
+	3: {"FERRY_TRAIN_CROSSING_BEGIN", "Ferry/Train crossing - Begin", "Паром/поезд - начало"},
+	4: {"FERRY_TRAIN_CROSSING_END", "Ferry/Train crossing - End", "Паром/поезд - окончание"},
+}
+
+func (s SpecificConditionTypeG2) String() string {
+	return lookupCode(specificConditionLabelsG2, int(s)).name
+}
+
+func (s SpecificConditionTypeG2) Label(lang string) string {
+	return lookupCode(specificConditionLabelsG2, int(s)).label(lang)
+}
+
+// Тип контроля (ControlType). Битовая маска 'cvpdexxx': выгрузка карты, выгрузка бортового
+// устройства, печать, отображение на дисплее, проверка калибровки на дороге (только G2).
+type ControlType int
+
+const (
+	ControlCardDownloading     ControlType = 0x80
+	ControlVuDownloading       ControlType = 0x40
+	ControlPrinting            ControlType = 0x20
+	ControlDisplay             ControlType = 0x10
+	ControlCalibrationChecking ControlType = 0x08
+)
+
+var controlTypeLabels = map[int]codeLabel{
+	0x80: {"CARD_DOWNLOADING", "Card downloading", "Выгрузка данных карты"},
+	0x40: {"VU_DOWNLOADING", "VU downloading", "Выгрузка данных бортового устройства"},
+	0x20: {"PRINTING", "Printing", "Печать"},
+	0x10: {"DISPLAY", "Display", "Отображение на дисплее"},
+	0x08: {"CALIBRATION_CHECKING", "Roadside calibration checking", "Проверка калибровки на дороге"},
+}
+
+var controlTypeFlags = []int{0x80, 0x40, 0x20, 0x10, 0x08}
+
+// Метод возвращает описания установленных флагов контроля
+func (c ControlType) flags() []codeLabel {
+	result := []codeLabel{}
+	for _, flag := range controlTypeFlags {
+		if int(c)&flag != 0 {
+			result = append(result, controlTypeLabels[flag])
+		}
+	}
+	return result
+}
+
+// Метод возвращает символьные имена установленных флагов через "|", для пустой маски - NONE
+func (c ControlType) String() string {
+	names := []string{}
+	for _, l := range c.flags() {
+		names = append(names, l.name)
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, "|")
+}
+
+func (c ControlType) Label(lang string) string {
+	labels := []string{}
+	for _, l := range c.flags() {
+		labels = append(labels, l.label(lang))
+	}
+	return strings.Join(labels, ", ")
+}
+
+// Метод возвращает true, если в маске установлен флаг flag
+func (c ControlType) Has(flag ControlType) bool {
+	return c&flag != 0
+}
+
+// Тип оборудования (EquipmentType), в том числе тип карты тахографа
+type EquipmentType int
+
+var equipmentTypeLabels = map[int]codeLabel{
+	0:  {"RESERVED", "Reserved", "Зарезервировано"},
+	1:  {"DRIVER_CARD", "Driver card", "Карта водителя"},
+	2:  {"WORKSHOP_CARD", "Workshop card", "Карта мастерской"},
+	3:  {"CONTROL_CARD", "Control card", "Карта контролера"},
+	4:  {"COMPANY_CARD", "Company card", "Карта предприятия"},
+	5:  {"MANUFACTURING_CARD", "Manufacturing card", "Карта изготовителя"},
+	6:  {"VEHICLE_UNIT", "Vehicle unit", "Бортовое устройство"},
+	7:  {"MOTION_SENSOR", "Motion sensor", "Датчик движения"},
+	8:  {"GNSS_FACILITY", "GNSS facility", "Устройство GNSS"},
+	9:  {"REMOTE_COMMUNICATION_DEVICE", "Remote communication device", "Устройство дистанционной связи"},
+	10: {"ITS_INTERFACE_MODULE", "ITS interface module", "Модуль интерфейса ИТС"},
+	11: {"PLAQUE", "Plaque", "Табличка"},
+	12: {"M1N1_ADAPTER", "M1N1 adapter", "Адаптер M1N1"},
+	13: {"EUROPEAN_ROOT_CA", "European root CA", "Европейский корневой УЦ"},
+	14: {"MEMBER_STATE_CA", "Member state CA", "УЦ государства-члена"},
+	15: {"EXTERNAL_GNSS_CONNECTION", "External GNSS connection", "Внешнее подключение GNSS"},
+}
+
+func (e EquipmentType) String() string {
+	return lookupCode(equipmentTypeLabels, int(e)).name
+}
+
+func (e EquipmentType) Label(lang string) string {
+	return lookupCode(equipmentTypeLabels, int(e)).label(lang)
+}
@@ -0,0 +1,76 @@
+package ddd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeJson(t *testing.T) {
+	c, err := ParseBytes(buildDriverDDD())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Card.TypeOfTachographCardId != 1 || c.Card.TypeOfTachographCardName != "DRIVER_CARD" {
+		t.Errorf("unexpected card type %d %q", c.Card.TypeOfTachographCardId, c.Card.TypeOfTachographCardName)
+	}
+
+	aci := c.ActivityDailyRecords[0].ActivityChangeInfos[0]
+	b, err := json.Marshal(aci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf(`"activity_kind_id":%d,"activity_kind_name":"%s"`, aci.ActivityKindId, aci.ActivityKindId)
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected %s in %s", expected, b)
+	}
+
+	// в предыдущих версиях код выводился числом, такой JSON по-прежнему разбирается
+	if err := json.Unmarshal([]byte(`{"activity_kind_id":2}`), &aci); err != nil || aci.ActivityKindId != ActivityWork {
+		t.Errorf("unmarshal integer code: %v %d", err, aci.ActivityKindId)
+	}
+}
+
+func TestCodeLabels(t *testing.T) {
+	tests := []struct {
+		code     interface{ String() string }
+		expected string
+	}{
+		{ActivityKind(0), "BREAK_REST"},
+		{ActivityKind(7), "UNKNOWN"},
+		{ControlType(0xA0), "CARD_DOWNLOADING|PRINTING"},
+		{ControlType(0), "NONE"},
+		{EventFaultType(0x90), "MANUFACTURER_SPECIFIC"},
+		{EquipmentType(2), "WORKSHOP_CARD"},
+		{SpecificConditionType(ConditionFerryTrain), "FERRY_TRAIN_CROSSING"},
+		{SpecificConditionType(ConditionFerryTrainEnd), "UNKNOWN"},
+		{SpecificConditionTypeG2(ConditionFerryTrainBegin), "FERRY_TRAIN_CROSSING_BEGIN"},
+		{SpecificConditionTypeG2(ConditionFerryTrainEnd), "FERRY_TRAIN_CROSSING_END"},
+	}
+	for _, tt := range tests {
+		if name := tt.code.String(); name != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, name)
+		}
+	}
+
+	if label := SpecificConditionType(ConditionFerryTrain).Label(LangRu); label != "Паром/поезд" {
+		t.Errorf("unexpected label %s", label)
+	}
+}
+
+func TestFillCodeNames(t *testing.T) {
+	begin := EntryBeginManual
+	shift := WorkShift{BeginType: &begin}
+	fillCodeNames(reflect.ValueOf(&shift).Elem())
+	if shift.BeginTypeName != "BEGIN_MANUAL" || shift.EndTypeName != "" {
+		t.Errorf("unexpected names %q %q", shift.BeginTypeName, shift.EndTypeName)
+	}
+
+	rec := SpecificConditionRecordG2{SpecificConditionTypeId: ConditionFerryTrainEnd}
+	fillCodeNames(reflect.ValueOf(&rec).Elem())
+	if rec.SpecificConditionTypeName != "FERRY_TRAIN_CROSSING_END" {
+		t.Errorf("unexpected name %q", rec.SpecificConditionTypeName)
+	}
+}
@@ -0,0 +1,358 @@
+package ddd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Набор правил проверки режима труда и отдыха
+type Ruleset string
+
+const (
+	RulesetEU561 Ruleset = "eu561" // регламент (ЕС) 561/2006
+	RulesetRU424 Ruleset = "ru424" // приказ Минтранса России от 16.10.2020 № 424
+)
+
+// Функции проверки по наборам правил
+var complianceAnalyzers = map[Ruleset]func(ActivityDailyRecords, ...SpecificConditionInterval) ComplianceReport{
+	RulesetEU561: AnalyzeEU561,
+	RulesetRU424: AnalyzeRU424,
+}
+
+// Метод возвращает true, если набор правил поддерживается
+func (r Ruleset) Valid() bool {
+	_, ok := complianceAnalyzers[r]
+	return ok
+}
+
+// Функция проверяет соблюдение режима труда и отдыха по набору правил ruleset с учетом
+// интервалов специальных условий conditions
+func AnalyzeCompliance(records ActivityDailyRecords, ruleset Ruleset, conditions ...SpecificConditionInterval) (ComplianceReport, error) {
+	analyze, ok := complianceAnalyzers[ruleset]
+	if !ok {
+		return ComplianceReport{}, fmt.Errorf("Unknown compliance ruleset: %s", ruleset)
+	}
+
+	return analyze(records, conditions...), nil
+}
+
+// Метод проверяет деятельность карты по набору правил ruleset
+func (c *Card) AnalyzeCompliance(ruleset Ruleset) (ComplianceReport, error) {
+	return AnalyzeCompliance(c.activityRecords(), ruleset, c.specificConditions()...)
+}
+
+// Тяжесть нарушения по классификации приложения III директивы 2006/22/EC. Для правил,
+// в которых классификации нет, тяжесть определяется по величине отклонения от нормы.
+type Severity string
+
+const (
+	SeverityMinor       Severity = "minor"        // незначительное нарушение (MI)
+	SeveritySerious     Severity = "serious"      // серьезное нарушение (SI)
+	SeverityVerySerious Severity = "very_serious" // очень серьезное нарушение (VSI)
+	SeverityMostSerious Severity = "most_serious" // наиболее серьезное нарушение (MSI)
+)
+
+// Вид ежедневного или еженедельного отдыха, которым завершился период работы
+type RestType string
+
+const (
+	RestRegularDaily  RestType = "regular_daily"  // регулярный ежедневный отдых
+	RestReducedDaily  RestType = "reduced_daily"  // сокращенный ежедневный отдых
+	RestSplitDaily    RestType = "split_daily"    // ежедневный отдых, разделенный на две части
+	RestRegularWeekly RestType = "regular_weekly" // регулярный еженедельный отдых
+	RestReducedWeekly RestType = "reduced_weekly" // сокращенный еженедельный отдых
+	RestInsufficient  RestType = "insufficient"   // отдых меньше минимально допустимого
+	RestNone          RestType = "none"           // период не завершен (конец данных)
+)
+
+// Нарушение режима труда и отдыха. Продолжительности указываются в минутах.
+type Infringement struct {
+	Rule        string    `json:"rule"`
+	Description string    `json:"description"`
+	Severity    Severity  `json:"severity"`
+	Begin       time.Time `json:"begin"`
+	End         time.Time `json:"end"`
+	Limit       int       `json:"limit"`
+	Actual      int       `json:"actual"`
+}
+
+// Период работы между двумя ежедневными (еженедельными) отдыхами
+type DutyPeriod struct {
+	Begin        time.Time `json:"begin"`
+	End          time.Time `json:"end"`
+	Driving      int       `json:"driving"`
+	Work         int       `json:"work"`
+	Availability int       `json:"availability"`
+	RestDuration int       `json:"rest_duration"`
+	RestType     RestType  `json:"rest_type"`
+}
+
+// Суммарное время управления и рабочее время (управление, работа и готовность) за календарную
+// неделю (с понедельника 00:00 по воскресенье 24:00 UTC)
+type WeekTotal struct {
+	Begin       time.Time `json:"begin"`
+	Driving     int       `json:"driving"`
+	WorkingTime int       `json:"working_time"`
+}
+
+// Результат проверки соблюдения режима труда и отдыха
+type ComplianceReport struct {
+	Regulation    string         `json:"regulation"`
+	DutyPeriods   []DutyPeriod   `json:"duty_periods"`
+	Weeks         []WeekTotal    `json:"weeks"`
+	Infringements []Infringement `json:"infringements"`
+}
+
+// Метод добавляет нарушение в отчет
+func (r *ComplianceReport) add(i Infringement) {
+	r.Infringements = append(r.Infringements, i)
+}
+
+// Метод упорядочивает нарушения по времени начала
+func (r *ComplianceReport) sortInfringements() {
+	sortInfringements(r.Infringements)
+}
+
+// Функция упорядочивает нарушения по времени начала
+func sortInfringements(infringements []Infringement) {
+	sort.SliceStable(infringements, func(i, j int) bool {
+		return infringements[i].Begin.Before(infringements[j].Begin)
+	})
+}
+
+// Функция определяет тяжесть превышения нормы: значения меньше limits[0] - незначительное,
+// меньше limits[1] - серьезное, меньше limits[2] - очень серьезное, остальные - наиболее серьезное.
+func severityByExcess(actual int, limits [3]int) Severity {
+	switch {
+	case actual < limits[0]:
+		return SeverityMinor
+	case actual < limits[1]:
+		return SeveritySerious
+	case actual < limits[2]:
+		return SeverityVerySerious
+	default:
+		return SeverityMostSerious
+	}
+}
+
+// Функция определяет тяжесть недостатка отдыха: значения не меньше limits[0] - незначительное,
+// не меньше limits[1] - серьезное, остальные - очень серьезное.
+func severityByShortfall(actual int, limits [2]int) Severity {
+	switch {
+	case actual >= limits[0]:
+		return SeverityMinor
+	case actual >= limits[1]:
+		return SeveritySerious
+	default:
+		return SeverityVerySerious
+	}
+}
+
+// Функция определяет тяжесть превышения нормы limit для правил без классификации нарушений:
+// до 10% - незначительное, до 20% - серьезное, до 50% - очень серьезное, больше - наиболее серьезное
+func excessSeverity(actual int, limit int) Severity {
+	return severityByExcess(actual, excessLimits(limit))
+}
+
+// Функция возвращает границы тяжести превышения нормы limit для правил без классификации нарушений
+func excessLimits(limit int) [3]int {
+	return [3]int{limit + limit/10, limit + limit/5, limit + limit/2}
+}
+
+// Функция возвращает границы тяжести недостатка отдыха относительно нормы limit для правил без
+// классификации нарушений: до 10% - незначительное, до 25% - серьезное, больше - очень серьезное
+func shortfallLimits(limit int) [2]int {
+	return [2]int{limit - limit/10, limit - limit/4}
+}
+
+// Непрерывный период одного вида деятельности
+type activitySpan struct {
+	Start time.Time
+	End   time.Time
+	Kind  ActivityKind
+}
+
+func (s activitySpan) minutes() int {
+	return int(s.End.Sub(s.Start) / time.Minute)
+}
+
+// Функция собирает непрерывную шкалу деятельности из суточных записей. Записи упорядочиваются
+// по дате, периоды без данных (например, дни без записей) считаются отдыхом, соседние периоды
+// одного вида деятельности объединяются.
+func buildActivityTimeline(records ActivityDailyRecords) []activitySpan {
+	sorted := make(ActivityDailyRecords, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivityRecordDate.Before(sorted[j].ActivityRecordDate)
+	})
+
+	result := []activitySpan{}
+	for _, rec := range sorted {
+		for _, interval := range rec.ActivityIntervals {
+			span := activitySpan{interval.Start, interval.End, interval.ActivityKindId}
+			if len(result) > 0 {
+				last := result[len(result)-1]
+				// пересекающиеся записи (например, повтор суток) обрезаются
+				if span.Start.Before(last.End) {
+					span.Start = last.End
+				}
+				if !span.End.After(span.Start) {
+					continue
+				}
+				if span.Start.After(last.End) {
+					result = appendSpan(result, activitySpan{last.End, span.Start, ActivityBreakRest})
+				}
+			}
+			result = appendSpan(result, span)
+		}
+	}
+
+	return result
+}
+
+// Функция добавляет период s к шкале деятельности, объединяя его с последним периодом того же вида
+func appendSpan(spans []activitySpan, s activitySpan) []activitySpan {
+	if len(spans) > 0 {
+		last := &spans[len(spans)-1]
+		if !s.Start.After(last.End) && s.Kind == last.Kind {
+			if s.End.After(last.End) {
+				last.End = s.End
+			}
+			return spans
+		}
+	}
+	return append(spans, s)
+}
+
+// Функция объединяет соседние периоды одного вида деятельности
+func mergeSpans(spans []activitySpan) []activitySpan {
+	result := []activitySpan{}
+	for _, s := range spans {
+		result = appendSpan(result, s)
+	}
+	return result
+}
+
+// Функция собирает шкалу деятельности для проверки режима труда и отдыха: суточные записи
+// с наложенными интервалами специальных условий
+func buildComplianceTimeline(records ActivityDailyRecords, conditions SpecificConditionIntervals) []activitySpan {
+	return applySpecificConditions(buildActivityTimeline(records), conditions)
+}
+
+// Функция возвращает начало календарной недели (понедельник 00:00 UTC), в которую входит t
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// Функция подсчитывает время вида деятельности kind в периодах spans, попадающее в интервал [begin, end)
+func sumActivity(spans []activitySpan, kind ActivityKind, begin time.Time, end time.Time) int {
+	var result time.Duration
+	for _, s := range spans {
+		if s.Kind != kind {
+			continue
+		}
+		from, to := s.Start, s.End
+		if from.Before(begin) {
+			from = begin
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			result += to.Sub(from)
+		}
+	}
+	return int(result / time.Minute)
+}
+
+// Функция подсчитывает рабочее время (управление, работа и готовность) в интервале [begin, end)
+func sumWorkingTime(spans []activitySpan, begin time.Time, end time.Time) int {
+	return sumActivity(spans, ActivityDriving, begin, end) + sumActivity(spans, ActivityWork, begin, end) +
+		sumActivity(spans, ActivityAvailability, begin, end)
+}
+
+// Функция подсчитывает время управления и рабочее время по календарным неделям
+func weeklyTotals(spans []activitySpan) []WeekTotal {
+	result := []WeekTotal{}
+	if len(spans) == 0 {
+		return result
+	}
+
+	last := spans[len(spans)-1].End
+	for week := weekStart(spans[0].Start); week.Before(last); week = week.AddDate(0, 0, 7) {
+		end := week.AddDate(0, 0, 7)
+		result = append(result, WeekTotal{week, sumActivity(spans, ActivityDriving, week, end), sumWorkingTime(spans, week, end)})
+	}
+	return result
+}
+
+// Период непрерывного управления, не прерванного достаточным перерывом
+type drivingBlock struct {
+	Begin   time.Time
+	End     time.Time
+	Driving int
+}
+
+// Функция разбивает управление на периоды непрерывного управления. Период завершается перерывом
+// не короче breakLen или перерывом не короче splitSecond, которому в этом периоде предшествовал
+// перерыв не короче splitFirst.
+func uninterruptedDriving(spans []activitySpan, breakLen int, splitFirst int, splitSecond int) []drivingBlock {
+	result := []drivingBlock{}
+	block := drivingBlock{}
+	hasFirstPart := false
+
+	closeBlock := func() {
+		if block.Driving > 0 {
+			result = append(result, block)
+		}
+		block = drivingBlock{}
+		hasFirstPart = false
+	}
+
+	for _, s := range spans {
+		switch s.Kind {
+		case ActivityDriving:
+			if block.Driving == 0 {
+				block.Begin = s.Start
+			}
+			block.Driving += s.minutes()
+			block.End = s.End
+		case ActivityBreakRest:
+			d := s.minutes()
+			if d >= breakLen || (hasFirstPart && d >= splitSecond) {
+				closeBlock()
+			} else if d >= splitFirst && block.Driving > 0 {
+				hasFirstPart = true
+			}
+		}
+	}
+	closeBlock()
+
+	return result
+}
+
+// Функция возвращает продолжительность отдыха rest, использованного в течение 24 часов
+// после begin, и окончание этих 24 часов
+func restInWindow(begin time.Time, rest activitySpan) (int, time.Time) {
+	windowEnd := begin.Add(24 * time.Hour)
+	if !windowEnd.After(rest.Start) {
+		return 0, windowEnd
+	}
+	end := rest.End
+	if end.After(windowEnd) {
+		end = windowEnd
+	}
+	return int(end.Sub(rest.Start) / time.Minute), windowEnd
+}
+
+// Метод возвращает суточные записи о деятельности карты: первого поколения, а если их нет -
+// приложения второго поколения
+func (c *Card) activityRecords() ActivityDailyRecords {
+	if len(c.ActivityDailyRecords) == 0 && c.G2 != nil {
+		return c.G2.ActivityDailyRecords
+	}
+	return c.ActivityDailyRecords
+}
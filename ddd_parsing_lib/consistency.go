@@ -0,0 +1,323 @@
+package ddd
+
+import (
+	"sort"
+	"time"
+)
+
+// Проверки согласованности данных карты
+const (
+	CheckOdometerDecrease    = "ODOMETER_DECREASE"       // показания одометра ТС уменьшились
+	CheckVehicleOverlap      = "VEHICLE_SESSION_OVERLAP" // сеансы использования ТС пересекаются
+	CheckActivityDayDuration = "ACTIVITY_DAY_DURATION"   // время изменений деятельности за сутки некорректно
+	CheckNoCardDriving       = "NO_CARD_DRIVING"         // управление записано при извлеченной карте
+	CheckNoCardGap           = "NO_CARD_GAP"             // ТС перемещалось, пока карта была извлечена
+	CheckDayDistanceMismatch = "DAY_DISTANCE_MISMATCH"   // расстояние за сутки не совпадает с одометром
+	CheckSuspiciousEvent     = "SUSPICIOUS_EVENT"        // событие, указывающее на возможное вмешательство
+)
+
+// Допустимое расхождение расстояния за сутки и показаний одометра, км
+const dayDistanceTolerance = 1
+
+// События, указывающие на возможное вмешательство, сопоставляются с найденными несоответствиями
+var suspiciousEventTypes = map[EventFaultType]bool{
+	0x03: true, // перекрытие времени
+	0x04: true, // управление без соответствующей карты
+	0x05: true, // установка карты во время управления
+	0x06: true, // последний сеанс использования карты завершен неправильно
+	0x08: true, // прерывание электропитания
+}
+
+// Несоответствие в данных карты. Expected и Actual заполняются для проверок, сравнивающих
+// значения (показания одометра и расстояние в км, продолжительность в минутах).
+// Events - типы событий карты, период которых пересекается с периодом несоответствия.
+type ConsistencyIssue struct {
+	Check                     string           `json:"check"`
+	Description               string           `json:"description"`
+	Begin                     time.Time        `json:"begin"`
+	End                       time.Time        `json:"end"`
+	VehicleRegistrationNumber string           `json:"vehicle_registration_number,omitempty"`
+	Expected                  int              `json:"expected"`
+	Actual                    int              `json:"actual"`
+	Events                    []EventFaultType `json:"events,omitempty"`
+}
+
+// Результат проверки согласованности данных карты
+type ConsistencyReport struct {
+	Issues []ConsistencyIssue `json:"issues"`
+}
+
+// Функция проверяет согласованность данных карты и ищет признаки вмешательства: уменьшение
+// показаний одометра ТС, пересечение сеансов использования ТС, сутки с некорректным временем
+// изменений деятельности, управление при извлеченной карте, перемещение ТС между сеансами
+// использования карты в нем, расхождение расстояния за сутки и показаний одометра.
+// События "перекрытие времени", "управление без карты" и т.п. сопоставляются с найденными
+// несоответствиями, события без несоответствий выводятся отдельно.
+func CheckConsistency(vehicles CardVehicleRecords, days ActivityDailyRecords, events CardEventRecords) ConsistencyReport {
+	return checkConsistency(vehicles.sessions(), days, events)
+}
+
+// Функция проверяет согласованность данных приложения второго поколения (см. CheckConsistency)
+func CheckConsistencyG2(vehicles CardVehicleRecordsG2, days ActivityDailyRecords, events CardEventRecords) ConsistencyReport {
+	return checkConsistency(vehicles.sessions(), days, events)
+}
+
+// Метод проверяет согласованность данных карты: первого поколения, а если их нет - приложения
+// второго поколения
+func (c *Card) CheckConsistency() ConsistencyReport {
+	if len(c.CardVehicleRecords) == 0 && c.G2 != nil {
+		return CheckConsistencyG2(c.G2.CardVehicleRecords, c.G2.ActivityDailyRecords, c.G2.CardEventRecords)
+	}
+	return CheckConsistency(c.CardVehicleRecords, c.ActivityDailyRecords, c.CardEventRecords)
+}
+
+func checkConsistency(sessions []vehicleSession, days ActivityDailyRecords, events CardEventRecords) ConsistencyReport {
+	report := ConsistencyReport{Issues: []ConsistencyIssue{}}
+
+	sorted := make([]vehicleSession, len(sessions))
+	copy(sorted, sessions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].firstUse.Before(sorted[j].firstUse)
+	})
+
+	checkVehicleSessions(sorted, &report)
+	checkActivityDays(days, &report)
+	checkDayDistance(sorted, days, &report)
+	correlateEvents(events, &report)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Begin.Before(report.Issues[j].Begin)
+	})
+
+	return report
+}
+
+// Функция проверяет сеансы использования ТС, упорядоченные по времени начала: показания одометра
+// не уменьшаются, сеансы не пересекаются, а ТС, в которое карта вставлена повторно, не перемещалось
+// с момента ее извлечения. Каждый сеанс сравнивается с предыдущим завершенным сеансом того же ТС,
+// после незавершенного сеанса сравнение не выполняется. Показания одометра незавершенных сеансов
+// не проверяются, переход одометра через ноль не считается уменьшением показаний.
+func checkVehicleSessions(sessions []vehicleSession, report *ConsistencyReport) {
+	last := map[vehicleKey]vehicleSession{}
+	for i, s := range sessions {
+		if _, ok := odometerDistance(s.odometerBegin, s.odometerEnd); s.completed() && !ok {
+			report.Issues = append(report.Issues, ConsistencyIssue{
+				Check:                     CheckOdometerDecrease,
+				Description:               "Vehicle odometer at the end of use is less than at the beginning",
+				Begin:                     s.firstUse,
+				End:                       s.lastUse,
+				VehicleRegistrationNumber: s.number,
+				Expected:                  s.odometerBegin,
+				Actual:                    s.odometerEnd,
+			})
+		}
+
+		if i > 0 && s.firstUse.Before(sessions[i-1].lastUse) {
+			prev := sessions[i-1]
+			report.Issues = append(report.Issues, ConsistencyIssue{
+				Check:                     CheckVehicleOverlap,
+				Description:               "Vehicle use overlaps previous vehicle use (" + prev.number + ")",
+				Begin:                     s.firstUse,
+				End:                       prev.lastUse,
+				VehicleRegistrationNumber: s.number,
+			})
+		}
+
+		key := vehicleKey{s.nation, s.number}
+		prev, ok := last[key]
+		if s.completed() {
+			last[key] = s
+		} else {
+			delete(last, key)
+		}
+		if !ok {
+			continue
+		}
+		distance, ok := odometerDistance(prev.odometerEnd, s.odometerBegin)
+		switch {
+		case !ok:
+			report.Issues = append(report.Issues, ConsistencyIssue{
+				Check:                     CheckOdometerDecrease,
+				Description:               "Vehicle odometer decreased between consecutive uses",
+				Begin:                     prev.lastUse,
+				End:                       s.firstUse,
+				VehicleRegistrationNumber: s.number,
+				Expected:                  prev.odometerEnd,
+				Actual:                    s.odometerBegin,
+			})
+		case distance > 0:
+			report.Issues = append(report.Issues, ConsistencyIssue{
+				Check:                     CheckNoCardGap,
+				Description:               "Vehicle moved while the card was withdrawn",
+				Begin:                     prev.lastUse,
+				End:                       s.firstUse,
+				VehicleRegistrationNumber: s.number,
+				Expected:                  prev.odometerEnd,
+				Actual:                    s.odometerBegin,
+			})
+		}
+	}
+}
+
+// Функция проверяет суточные записи: время изменений деятельности корректно
+// и управление не записано при извлеченной карте
+func checkActivityDays(days ActivityDailyRecords, report *ConsistencyReport) {
+	for _, d := range days {
+		report.Issues = append(report.Issues, checkActivityDayDuration(d)...)
+
+		for _, interval := range d.ActivityIntervals {
+			if interval.ActivityKindId == ActivityDriving && !interval.CardInserted {
+				report.Issues = append(report.Issues, ConsistencyIssue{
+					Check:       CheckNoCardDriving,
+					Description: "Driving recorded while the card was not inserted",
+					Begin:       interval.Start,
+					End:         interval.End,
+					Actual:      interval.Duration,
+				})
+			}
+		}
+	}
+}
+
+// Функция проверяет продолжительность деятельности за сутки по исходному времени изменений
+// деятельности (интервалы ActivityIntervals ограничены пределами суток и не показывают ошибок):
+// первое изменение записано в 00:00, время изменений не убывает и не выходит за пределы суток.
+// Продолжительность считается от первого изменения до конца суток или до последнего изменения,
+// если оно позже.
+func checkActivityDayDuration(d ActivityDailyRecord) []ConsistencyIssue {
+	issues := []ConsistencyIssue{}
+	issue := func(description string, expected int, actual int) {
+		issues = append(issues, ConsistencyIssue{
+			Check:       CheckActivityDayDuration,
+			Description: description,
+			Begin:       d.ActivityRecordDate,
+			End:         d.ActivityRecordDate.Add(24 * time.Hour),
+			Expected:    expected,
+			Actual:      actual,
+		})
+	}
+
+	changes := d.ActivityChangeInfos
+	if len(changes) == 0 {
+		issue("No activity changes recorded for the day", minutesPerDay, 0)
+		return issues
+	}
+	first := changes[0].ActivityChangeInfoT
+	if first != 0 {
+		issue("First activity change of the day is not at 00:00", 0, first)
+	}
+	latest, ordered := first, true
+	for i := 1; i < len(changes); i++ {
+		t := changes[i].ActivityChangeInfoT
+		if ordered && t < changes[i-1].ActivityChangeInfoT {
+			issue("Activity change times are not in ascending order", changes[i-1].ActivityChangeInfoT, t)
+			ordered = false
+		}
+		if t > latest {
+			latest = t
+		}
+	}
+	end := minutesPerDay
+	if latest > end {
+		end = latest
+	}
+	if total := end - first; total > minutesPerDay {
+		issue("Total activity duration of the day exceeds 1440 minutes", minutesPerDay, total)
+	}
+
+	return issues
+}
+
+// Функция сравнивает расстояние за сутки с показаниями одометра. Проверяются только сутки,
+// все сеансы использования ТС в которые начались и закончились в эти сутки.
+func checkDayDistance(sessions []vehicleSession, days ActivityDailyRecords, report *ConsistencyReport) {
+	type dayOdometer struct {
+		distance int
+		complete bool
+	}
+	odometer := map[time.Time]*dayOdometer{}
+	for _, s := range sessions {
+		begin, end := dayStart(s.firstUse), dayStart(s.lastUse)
+		if !s.completed() {
+			end = begin
+		}
+		for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+			o, ok := odometer[day]
+			if !ok {
+				o = &dayOdometer{complete: true}
+				odometer[day] = o
+			}
+			distance, ok := odometerDistance(s.odometerBegin, s.odometerEnd)
+			if !begin.Equal(end) || !s.completed() || !ok {
+				o.complete = false
+				continue
+			}
+			o.distance += distance
+		}
+	}
+
+	for _, d := range days {
+		o, ok := odometer[dayStart(d.ActivityRecordDate)]
+		if !ok || !o.complete {
+			continue
+		}
+		diff := d.ActivityDayDistance - o.distance
+		if diff > dayDistanceTolerance || diff < -dayDistanceTolerance {
+			report.Issues = append(report.Issues, ConsistencyIssue{
+				Check:       CheckDayDistanceMismatch,
+				Description: "Daily distance differs from vehicle odometer values",
+				Begin:       d.ActivityRecordDate,
+				End:         d.ActivityRecordDate.Add(24 * time.Hour),
+				Expected:    o.distance,
+				Actual:      d.ActivityDayDistance,
+			})
+		}
+	}
+}
+
+// Функция сопоставляет события, указывающие на возможное вмешательство, с найденными
+// несоответствиями по пересечению периодов. События без несоответствий добавляются в отчет.
+func correlateEvents(events CardEventRecords, report *ConsistencyReport) {
+	for _, e := range events {
+		if !suspiciousEventTypes[e.EventTypeId] {
+			continue
+		}
+
+		correlated := false
+		for i := range report.Issues {
+			issue := &report.Issues[i]
+			if e.EventBeginTime.After(issue.End) || e.EventEndTime.Before(issue.Begin) {
+				continue
+			}
+			correlated = true
+			if !hasEventType(issue.Events, e.EventTypeId) {
+				issue.Events = append(issue.Events, e.EventTypeId)
+			}
+		}
+		if correlated {
+			continue
+		}
+		report.Issues = append(report.Issues, ConsistencyIssue{
+			Check:                     CheckSuspiciousEvent,
+			Description:               e.EventTypeId.Label(LangEn),
+			Begin:                     e.EventBeginTime,
+			End:                       e.EventEndTime,
+			VehicleRegistrationNumber: e.VehicleRegistrationNumber,
+			Events:                    []EventFaultType{e.EventTypeId},
+		})
+	}
+}
+
+// Метод проверяет, что время окончания сеанса записано
+func (s vehicleSession) completed() bool {
+	return !s.lastUse.Before(s.firstUse)
+}
+
+func hasEventType(types []EventFaultType, t EventFaultType) bool {
+	for _, existing := range types {
+		if existing == t {
+			return true
+		}
+	}
+	return false
+}
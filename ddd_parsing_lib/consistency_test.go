@@ -0,0 +1,265 @@
+package ddd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Функция формирует завершенный сеанс использования ТС number с hour до hour+2 ч первого дня
+func session(number string, hour int, begin int, end int) vehicleSession {
+	first := monday.Add(time.Duration(hour) * time.Hour)
+	return vehicleSession{nation: 0x0D, number: number, firstUse: first, lastUse: first.Add(2 * time.Hour),
+		odometerBegin: begin, odometerEnd: end}
+}
+
+// Функция подсчитывает несоответствия каждого вида
+func issueCounts(issues []ConsistencyIssue) map[string]int {
+	counts := map[string]int{}
+	for _, i := range issues {
+		counts[i.Check]++
+	}
+	return counts
+}
+
+func TestCheckVehicleSessions(t *testing.T) {
+	unfinished := session("AB123", 4, 300, 0)
+	unfinished.lastUse = time.Time{}
+
+	tests := []struct {
+		name     string
+		sessions []vehicleSession
+		expected map[string]int
+	}{
+		{
+			name:     "consecutive uses",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), session("AB123", 2, 300, 400)},
+			expected: map[string]int{},
+		},
+		{
+			name: "A-B-A compared with own previous use",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), session("XY987", 2, 5000, 5100),
+				session("AB123", 4, 300, 400)},
+			expected: map[string]int{},
+		},
+		{
+			name: "A-B-A moved without card",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), session("XY987", 2, 5000, 5100),
+				session("AB123", 4, 350, 400)},
+			expected: map[string]int{CheckNoCardGap: 1},
+		},
+		{
+			name: "A-B-A decreased",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), session("XY987", 2, 5000, 5100),
+				session("AB123", 4, 250, 400)},
+			expected: map[string]int{CheckOdometerDecrease: 1},
+		},
+		{
+			name:     "decrease within use",
+			sessions: []vehicleSession{session("AB123", 0, 300, 100)},
+			expected: map[string]int{CheckOdometerDecrease: 1},
+		},
+		{
+			name:     "rollover within and between uses",
+			sessions: []vehicleSession{session("AB123", 0, 999800, 999950), session("AB123", 2, 999950, 150), session("AB123", 4, 150, 300)},
+			expected: map[string]int{},
+		},
+		{
+			name:     "moved across rollover",
+			sessions: []vehicleSession{session("AB123", 0, 999800, 999950), session("AB123", 2, 50, 150)},
+			expected: map[string]int{CheckNoCardGap: 1},
+		},
+		{
+			name:     "after unfinished use",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), unfinished, session("AB123", 6, 900, 1000)},
+			expected: map[string]int{},
+		},
+		{
+			name:     "overlap",
+			sessions: []vehicleSession{session("AB123", 0, 100, 300), session("XY987", 1, 5000, 5100)},
+			expected: map[string]int{CheckVehicleOverlap: 1},
+		},
+	}
+	for _, tt := range tests {
+		report := ConsistencyReport{}
+		checkVehicleSessions(tt.sessions, &report)
+		if got := issueCounts(report.Issues); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected issues %v, got %+v", tt.name, tt.expected, report.Issues)
+		}
+	}
+}
+
+func TestCheckActivityDayDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		changes  [][2]int
+		expected []ConsistencyIssue
+	}{
+		{name: "whole day", changes: [][2]int{{0, 0}, {3, 360}, {0, 600}}, expected: []ConsistencyIssue{}},
+		{name: "changes in the same minute", changes: [][2]int{{0, 0}, {3, 360}, {2, 360}}, expected: []ConsistencyIssue{}},
+		{
+			name:    "no changes",
+			changes: [][2]int{},
+			expected: []ConsistencyIssue{
+				{Description: "No activity changes recorded for the day", Expected: 1440, Actual: 0},
+			},
+		},
+		{
+			name:    "first change after midnight",
+			changes: [][2]int{{0, 30}, {3, 360}},
+			expected: []ConsistencyIssue{
+				{Description: "First activity change of the day is not at 00:00", Expected: 0, Actual: 30},
+			},
+		},
+		{
+			name:    "not ascending",
+			changes: [][2]int{{0, 0}, {3, 600}, {0, 360}, {2, 300}},
+			expected: []ConsistencyIssue{
+				{Description: "Activity change times are not in ascending order", Expected: 600, Actual: 360},
+			},
+		},
+		{
+			name:    "beyond the end of day",
+			changes: [][2]int{{0, 0}, {3, 1400}, {0, 1500}},
+			expected: []ConsistencyIssue{
+				{Description: "Total activity duration of the day exceeds 1440 minutes", Expected: 1440, Actual: 1500},
+			},
+		},
+	}
+	for _, tt := range tests {
+		day := dayRecord(monday, tt.changes...)
+		got := checkActivityDayDuration(day)
+		for i := range tt.expected {
+			tt.expected[i].Check = CheckActivityDayDuration
+			tt.expected[i].Begin = monday
+			tt.expected[i].End = monday.Add(24 * time.Hour)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestCheckDayDistanceRollover(t *testing.T) {
+	sessions := []vehicleSession{session("AB123", 6, 999900, 50)}
+	tests := []struct {
+		distance int
+		expected map[string]int
+	}{
+		{distance: 150, expected: map[string]int{}},
+		{distance: 100, expected: map[string]int{CheckDayDistanceMismatch: 1}},
+	}
+	for _, tt := range tests {
+		day := dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 480})
+		day.ActivityDayDistance = tt.distance
+		report := ConsistencyReport{}
+		checkDayDistance(sessions, ActivityDailyRecords{day}, &report)
+		if got := issueCounts(report.Issues); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("distance %d: expected issues %v, got %+v", tt.distance, tt.expected, report.Issues)
+		}
+	}
+}
+
+func TestCheckActivityDays(t *testing.T) {
+	// управление 06:00-08:00 при извлеченной карте
+	noCard := dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 480})
+	noCard.ActivityChangeInfos[1].CardPositionId = 1
+	noCard.ActivityIntervals = buildActivityIntervals(monday, noCard.ActivityChangeInfos)
+
+	tests := []struct {
+		name     string
+		day      ActivityDailyRecord
+		expected map[string]int
+	}{
+		{name: "whole day", day: dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 600}), expected: map[string]int{}},
+		{name: "first change after midnight", day: dayRecord(monday, [2]int{0, 30}, [2]int{3, 360}), expected: map[string]int{CheckActivityDayDuration: 1}},
+		{name: "no changes", day: dayRecord(monday), expected: map[string]int{CheckActivityDayDuration: 1}},
+		{name: "driving without card", day: noCard, expected: map[string]int{CheckNoCardDriving: 1}},
+	}
+	for _, tt := range tests {
+		report := ConsistencyReport{}
+		checkActivityDays(ActivityDailyRecords{tt.day}, &report)
+		if got := issueCounts(report.Issues); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected issues %v, got %+v", tt.name, tt.expected, report.Issues)
+		}
+	}
+}
+
+func TestCheckDayDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []vehicleSession
+		distance int
+		expected map[string]int
+	}{
+		{name: "matches odometer", sessions: []vehicleSession{session("AB123", 6, 100, 300)}, distance: 201, expected: map[string]int{}},
+		{name: "differs from odometer", sessions: []vehicleSession{session("AB123", 6, 100, 300)}, distance: 150,
+			expected: map[string]int{CheckDayDistanceMismatch: 1}},
+		{name: "use continues next day", sessions: []vehicleSession{session("AB123", 22, 100, 300)}, distance: 150,
+			expected: map[string]int{}},
+	}
+	for _, tt := range tests {
+		day := dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 480})
+		day.ActivityDayDistance = tt.distance
+		report := ConsistencyReport{}
+		checkDayDistance(tt.sessions, ActivityDailyRecords{day}, &report)
+		if got := issueCounts(report.Issues); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected issues %v, got %+v", tt.name, tt.expected, report.Issues)
+		}
+	}
+}
+
+func TestCheckConsistencyEvents(t *testing.T) {
+	at := func(hour int) time.Time { return monday.Add(time.Duration(hour) * time.Hour) }
+	vehicles := CardVehicleRecords{
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 100,
+			VehicleOdometerEnd: 300, VehicleFirstUse: at(0), VehicleLastUse: at(2)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 350,
+			VehicleOdometerEnd: 400, VehicleFirstUse: at(4), VehicleLastUse: at(6)},
+	}
+	events := CardEventRecords{
+		{EventTypeId: 0x08, EventBeginTime: at(3), EventEndTime: at(3).Add(30 * time.Minute)},
+		{EventTypeId: 0x03, EventBeginTime: at(10), EventEndTime: at(11), VehicleRegistrationNumber: "AB123"},
+		{EventTypeId: 0x01, EventBeginTime: at(12), EventEndTime: at(13)},
+	}
+
+	report := CheckConsistency(vehicles, ActivityDailyRecords{}, events)
+	if len(report.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", report.Issues)
+	}
+	gap := report.Issues[0]
+	if gap.Check != CheckNoCardGap || gap.Expected != 300 || gap.Actual != 350 ||
+		!reflect.DeepEqual(gap.Events, []EventFaultType{0x08}) {
+		t.Errorf("unexpected gap issue %+v", gap)
+	}
+	event := report.Issues[1]
+	if event.Check != CheckSuspiciousEvent || !event.Begin.Equal(at(10)) || event.VehicleRegistrationNumber != "AB123" {
+		t.Errorf("unexpected event issue %+v", event)
+	}
+}
+
+func TestCheckConsistencyG2(t *testing.T) {
+	at := func(hour int) time.Time { return monday.Add(time.Duration(hour) * time.Hour) }
+	vehicles := CardVehicleRecordsG2{
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 100,
+			VehicleOdometerEnd: 300, VehicleFirstUse: at(0), VehicleLastUse: at(2), VehicleIdentificationNumber: "VIN1"},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 250,
+			VehicleOdometerEnd: 400, VehicleFirstUse: at(4), VehicleLastUse: at(6), VehicleIdentificationNumber: "VIN1"},
+	}
+	days := ActivityDailyRecords{dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 480})}
+	days[0].ActivityDayDistance = 350
+
+	report := CheckConsistencyG2(vehicles, days, CardEventRecords{})
+	if got := issueCounts(report.Issues); !reflect.DeepEqual(got, map[string]int{CheckOdometerDecrease: 1}) {
+		t.Fatalf("unexpected issues %+v", report.Issues)
+	}
+	if issue := report.Issues[0]; issue.Expected != 300 || issue.Actual != 250 || issue.VehicleRegistrationNumber != "AB123" {
+		t.Errorf("unexpected issue %+v", issue)
+	}
+
+	// карта только с приложением второго поколения проверяется по его записям
+	c := &Card{G2: &CardG2{CardVehicleRecords: vehicles, ActivityDailyRecords: days}}
+	if !reflect.DeepEqual(c.CheckConsistency(), report) {
+		t.Errorf("card report differs from G2 records report: %+v", c.CheckConsistency())
+	}
+}
@@ -0,0 +1,198 @@
+package ddd
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Смена страны: с момента Time водитель находится в стране Country (NationNumeric)
+type CountryChange struct {
+	Time    time.Time `json:"time"`
+	Country int       `json:"country"`
+}
+
+// Деятельность водителя за календарные сутки (UTC) в одной стране. Рабочим временем считаются
+// управление и работа, работа в ночное время считается по настройкам WorkingTimeOptions.
+// Продолжительности в минутах. Деятельность до первой записи о стране относится к стране 0.
+type CountryDay struct {
+	Date         time.Time   `json:"date"`
+	Country      int         `json:"country"`
+	CountryIso   *NationInfo `iso:"Country" json:"country_iso,omitempty"`
+	Driving      int         `json:"driving"`
+	Work         int         `json:"work"`
+	Availability int         `json:"availability"`
+	Rest         int         `json:"rest"`
+	WorkingTime  int         `json:"working_time"`
+	NightWork    int         `json:"night_work"`
+}
+
+// Итоги по стране: Days - количество суток, в которые водитель находился в стране,
+// WorkDays - количество суток с рабочим временем в стране
+type CountryTotal struct {
+	Country      int         `json:"country"`
+	CountryIso   *NationInfo `iso:"Country" json:"country_iso,omitempty"`
+	Days         int         `json:"days"`
+	WorkDays     int         `json:"work_days"`
+	Driving      int         `json:"driving"`
+	Work         int         `json:"work"`
+	Availability int         `json:"availability"`
+	Rest         int         `json:"rest"`
+	WorkingTime  int         `json:"working_time"`
+	NightWork    int         `json:"night_work"`
+}
+
+// Отчет о распределении деятельности водителя по странам
+type CountryReport struct {
+	Days      []CountryDay   `json:"days"`
+	Countries []CountryTotal `json:"countries"`
+}
+
+// Функция распределяет деятельность водителя по странам и календарным суткам. Страна определяется
+// по сменам страны changes (записи о начале и окончании ежедневного периода работы, пересечения
+// границ): деятельность относится к стране последней смены, предшествующей ей. Сутки, в которые
+// водитель находился в нескольких странах, выводятся отдельной строкой для каждой страны.
+func AnalyzeCountryDays(records ActivityDailyRecords, changes []CountryChange, opts WorkingTimeOptions) CountryReport {
+	opts = opts.withDefaults()
+	report := CountryReport{
+		Days:      []CountryDay{},
+		Countries: []CountryTotal{},
+	}
+
+	sorted := make([]CountryChange, len(changes))
+	copy(sorted, changes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	type dayKey struct {
+		date    time.Time
+		country int
+	}
+	days := map[dayKey]*CountryDay{}
+
+	for _, s := range buildActivityTimeline(records) {
+		for _, part := range splitByCountry(s, sorted) {
+			for day := dayStart(part.span.Start); day.Before(part.span.End); day = day.AddDate(0, 0, 1) {
+				seg := clipSpan(part.span, day, day.AddDate(0, 0, 1))
+				if !seg.End.After(seg.Start) {
+					continue
+				}
+
+				key := dayKey{day, part.country}
+				d, ok := days[key]
+				if !ok {
+					d = &CountryDay{Date: day, Country: part.country}
+					days[key] = d
+				}
+				switch seg.Kind {
+				case ActivityDriving:
+					d.Driving += seg.minutes()
+					d.NightWork += nightMinutes(seg, opts)
+				case ActivityWork:
+					d.Work += seg.minutes()
+					d.NightWork += nightMinutes(seg, opts)
+				case ActivityAvailability:
+					d.Availability += seg.minutes()
+				default:
+					d.Rest += seg.minutes()
+				}
+				d.WorkingTime = d.Driving + d.Work
+			}
+		}
+	}
+
+	for _, d := range days {
+		fillIsoCodes(reflect.ValueOf(d).Elem())
+		report.Days = append(report.Days, *d)
+	}
+	sort.Slice(report.Days, func(i, j int) bool {
+		if !report.Days[i].Date.Equal(report.Days[j].Date) {
+			return report.Days[i].Date.Before(report.Days[j].Date)
+		}
+		return report.Days[i].Country < report.Days[j].Country
+	})
+
+	totals := map[int]*CountryTotal{}
+	for _, d := range report.Days {
+		t, ok := totals[d.Country]
+		if !ok {
+			t = &CountryTotal{Country: d.Country, CountryIso: d.CountryIso}
+			totals[d.Country] = t
+		}
+		t.Days++
+		if d.WorkingTime > 0 {
+			t.WorkDays++
+		}
+		t.Driving += d.Driving
+		t.Work += d.Work
+		t.Availability += d.Availability
+		t.Rest += d.Rest
+		t.WorkingTime += d.WorkingTime
+		t.NightWork += d.NightWork
+	}
+	for _, t := range totals {
+		report.Countries = append(report.Countries, *t)
+	}
+	sort.Slice(report.Countries, func(i, j int) bool {
+		return report.Countries[i].Country < report.Countries[j].Country
+	})
+
+	return report
+}
+
+// Метод распределяет деятельность водителя по странам по данным карты
+func (c *Card) AnalyzeCountryDays(opts WorkingTimeOptions) CountryReport {
+	return AnalyzeCountryDays(c.activityRecords(), c.CountryChanges(), opts)
+}
+
+// Метод возвращает смены страны по записям о местах начала и окончания ежедневного периода
+// работы, а для карт второго поколения - также по записям о пересечении границ
+func (c *Card) CountryChanges() []CountryChange {
+	result := []CountryChange{}
+	for _, p := range c.PlaceRecords {
+		result = append(result, CountryChange{p.EntryTime, p.DailyWorkPeriodCountry})
+	}
+	if c.G2 != nil {
+		if len(c.PlaceRecords) == 0 {
+			for _, p := range c.G2.PlaceRecords {
+				result = append(result, CountryChange{p.EntryTime, p.DailyWorkPeriodCountry})
+			}
+		}
+		for _, b := range c.G2.BorderCrossingRecords {
+			result = append(result, CountryChange{b.GnssTimeStamp, b.CountryEntered})
+		}
+	}
+	return result
+}
+
+// Период деятельности в одной стране
+type countrySpan struct {
+	span    activitySpan
+	country int
+}
+
+// Функция разбивает период s по сменам страны changes, упорядоченным по времени
+func splitByCountry(s activitySpan, changes []CountryChange) []countrySpan {
+	country := 0
+	result := []countrySpan{}
+	for _, c := range changes {
+		if !c.Time.After(s.Start) {
+			country = c.Country
+			continue
+		}
+		if !c.Time.Before(s.End) {
+			break
+		}
+		result = append(result, countrySpan{activitySpan{s.Start, c.Time, s.Kind}, country})
+		s.Start = c.Time
+		country = c.Country
+	}
+	return append(result, countrySpan{s, country})
+}
+
+// Функция возвращает начало календарных суток (00:00 UTC), в которые входит t
+func dayStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,126 @@
+package ddd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeCountryDays(t *testing.T) {
+	records := ActivityDailyRecords{
+		// управление 02:00-10:00
+		dayRecord(monday, [2]int{0, 0}, [2]int{3, 120}, [2]int{0, 600}),
+		// работа 08:00-12:00
+		dayRecord(monday.AddDate(0, 0, 1), [2]int{0, 0}, [2]int{2, 480}, [2]int{0, 720}),
+	}
+	changes := []CountryChange{
+		// пересечение границы Франция - Германия в 06:00, смены страны не упорядочены
+		{monday.Add(6 * time.Hour), 0x0D},
+		{monday.Add(1 * time.Hour), 0x11},
+	}
+	r := AnalyzeCountryDays(records, changes, WorkingTimeOptions{})
+
+	expected := []CountryDay{
+		// деятельность до первой записи о стране относится к стране 0
+		{Date: monday, Country: 0, Rest: 60},
+		{Date: monday, Country: 0x0D, CountryIso: &NationInfo{"DE", "DEU", "D", "Germany"},
+			Driving: 240, Rest: 840, WorkingTime: 240},
+		// ночное время по умолчанию 00:00-04:00: управление 02:00-04:00
+		{Date: monday, Country: 0x11, CountryIso: &NationInfo{"FR", "FRA", "F", "France"},
+			Driving: 240, Rest: 60, WorkingTime: 240, NightWork: 120},
+		{Date: monday.AddDate(0, 0, 1), Country: 0x0D, CountryIso: &NationInfo{"DE", "DEU", "D", "Germany"},
+			Work: 240, Rest: 1200, WorkingTime: 240},
+	}
+	if !reflect.DeepEqual(r.Days, expected) {
+		t.Errorf("unexpected days:\n%+v\nexpected:\n%+v", r.Days, expected)
+	}
+
+	if len(r.Countries) != 3 {
+		t.Fatalf("expected 3 countries, got %+v", r.Countries)
+	}
+	de := r.Countries[1]
+	if de.Country != 0x0D || de.Days != 2 || de.WorkDays != 2 || de.WorkingTime != 480 || de.Rest != 2040 {
+		t.Errorf("unexpected totals %+v", de)
+	}
+	if r.Countries[0].Country != 0 || r.Countries[0].WorkDays != 0 || r.Countries[0].CountryIso != nil {
+		t.Errorf("unexpected totals %+v", r.Countries[0])
+	}
+}
+
+func TestSplitByCountry(t *testing.T) {
+	at := func(hour int) time.Time {
+		return monday.Add(time.Duration(hour) * time.Hour)
+	}
+	span := activitySpan{Start: at(8), End: at(16), Kind: ActivityDriving}
+
+	tests := []struct {
+		name     string
+		changes  []CountryChange
+		expected []countrySpan
+	}{
+		{name: "no changes", expected: []countrySpan{{span, 0}}},
+		{name: "country before span", changes: []CountryChange{{at(2), 0x11}},
+			expected: []countrySpan{{span, 0x11}}},
+		{name: "border crossing", changes: []CountryChange{{at(2), 0x11}, {at(12), 0x0D}},
+			expected: []countrySpan{
+				{activitySpan{at(8), at(12), ActivityDriving}, 0x11},
+				{activitySpan{at(12), at(16), ActivityDriving}, 0x0D},
+			}},
+		{name: "first record inside span", changes: []CountryChange{{at(10), 0x11}},
+			expected: []countrySpan{
+				{activitySpan{at(8), at(10), ActivityDriving}, 0},
+				{activitySpan{at(10), at(16), ActivityDriving}, 0x11},
+			}},
+		{name: "change at span end", changes: []CountryChange{{at(2), 0x11}, {at(16), 0x0D}},
+			expected: []countrySpan{{span, 0x11}}},
+	}
+	for _, tt := range tests {
+		if got := splitByCountry(span, tt.changes); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: got %+v, expected %+v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCardCountryChanges(t *testing.T) {
+	at := func(hour int) time.Time {
+		return monday.Add(time.Duration(hour) * time.Hour)
+	}
+	c := Card{
+		G2: &CardG2{
+			PlaceRecords: PlaceRecordsG2{
+				{EntryTime: at(6), DailyWorkPeriodCountry: 0x11},
+				{EntryTime: at(20), DailyWorkPeriodCountry: 0x0D},
+			},
+			BorderCrossingRecords: CardBorderCrossingRecords{
+				{CountryLeft: 0x11, CountryEntered: 0x0D, GnssTimeStamp: at(12)},
+			},
+		},
+	}
+	expected := []CountryChange{{at(6), 0x11}, {at(20), 0x0D}, {at(12), 0x0D}}
+	if got := c.CountryChanges(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("gen2 card: got %+v, expected %+v", got, expected)
+	}
+
+	// на карте второго поколения с приложением первого поколения места берутся из него
+	c.PlaceRecords = PlaceRecords{{EntryTime: at(7), DailyWorkPeriodCountry: 0x11}}
+	expected = []CountryChange{{at(7), 0x11}, {at(12), 0x0D}}
+	if got := c.CountryChanges(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("gen1 places: got %+v, expected %+v", got, expected)
+	}
+
+	// пересечение границы в середине суток дает две строки за эти сутки
+	records := ActivityDailyRecords{dayRecord(monday, [2]int{0, 0}, [2]int{3, 480}, [2]int{0, 960})}
+	r := AnalyzeCountryDays(records, c.CountryChanges(), WorkingTimeOptions{})
+	if len(r.Days) != 3 || r.Days[1].Country != 0x0D || r.Days[1].Driving != 240 ||
+		r.Days[2].Country != 0x11 || r.Days[2].Driving != 240 {
+		t.Errorf("unexpected days %+v", r.Days)
+	}
+
+	parsed, err := ParseBytesWithOptions(buildDriverDDD(), ParseOptions{CountryDays: &WorkingTimeOptions{}})
+	if err != nil || parsed.CountryDays == nil {
+		t.Fatalf("country report is not built: %v", err)
+	}
+	if len(parsed.CountryChanges()) != len(parsed.PlaceRecords) {
+		t.Errorf("expected %d country changes, got %d", len(parsed.PlaceRecords), len(parsed.CountryChanges()))
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package ddd
 
 import (
 	"bytes"
@@ -29,20 +29,89 @@ func recTblToTlvs(tag string, recTbl [][]byte) []map[string][]byte {
 
 // Функция для разбивки байтового массива в матрицу. Нужна для обработки
 // циклических записей в tlv выгрузке.
-func readFileRecords(bytes []byte, recordLen int, offsetBeginRecord int) [][]byte {
-
-	allFileRecords := bytes[offsetBeginRecord:]
+func readFileRecords(tag string, bytes []byte, recordLen int, offsetBeginRecord int) ([][]byte, error) {
 	var result [][]byte
 
-	beginIdx := 0
-	endIdx := recordLen
-	for beginIdx < len(allFileRecords) {
-		result = append(result, allFileRecords[beginIdx:endIdx])
-		beginIdx = endIdx
-		endIdx = endIdx + recordLen
+	// секция отсутствует в файле
+	if len(bytes) == 0 {
+		return result, nil
 	}
 
-	return result
+	if _, err := readBytes(tag, bytes, -1, offsetBeginRecord); err != nil {
+		return result, err
+	}
+
+	beginIdx := offsetBeginRecord
+	for beginIdx < len(bytes) {
+		rec, err := readBytes(tag, bytes, recordLen, beginIdx)
+		if err != nil {
+			return result, err
+		}
+		result = append(result, rec)
+		beginIdx = beginIdx + recordLen
+	}
+
+	return result, nil
+}
+
+// Функция возвращает количество записей каждого типа в файле событий или неисправностей
+// из EF_Application_Identification (offset - смещение NoOfEventsPerType или NoOfFaultsPerType).
+// Если секции нет, возвращается 0.
+func recordsPerType(tlvRecords map[string][]byte, offset int) int {
+	app := tlvRecords["0501"]
+	if offset >= len(app) {
+		return 0
+	}
+
+	return int(app[offset])
+}
+
+// Функция разбивает файл событий или неисправностей на блоки записей одного типа.
+// Каждый блок содержит perType записей. Если perType не задан или не согласуется с размером
+// файла, он вычисляется из количества блоков blocks, заданного для карты первого поколения.
+func readTypeBlocks(tag string, bytes []byte, recordLen int, perType int, blocks int) ([][][]byte, error) {
+	var result [][][]byte
+
+	records, readErr := readFileRecords(tag, bytes, recordLen, 0)
+	if len(records) == 0 {
+		return result, readErr
+	}
+
+	if perType <= 0 || len(records) % perType != 0 {
+		perType = len(records) / blocks
+		if perType == 0 || len(records) % blocks != 0 {
+			perType = len(records)
+		}
+	}
+
+	for begin := 0; begin < len(records); begin += perType {
+		end := begin + perType
+		if end > len(records) {
+			end = len(records)
+		}
+		result = append(result, records[begin:end])
+	}
+
+	return result, readErr
+}
+
+// Функция упорядочивает записи циклического файла от самой старой к самой новой.
+// newest - индекс самой новой записи из указателя в начале файла (например,
+// vehiclePointerNewestRecord). Самая старая запись следует за самой новой.
+// Если указатель выходит за пределы файла, записи возвращаются в порядке хранения и ошибка.
+func orderCyclicRecords(tag string, records [][]byte, newest int) ([][]byte, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+	if newest < 0 || newest >= len(records) {
+		return records, &SectionError{tag, fmt.Errorf("Newest record pointer out of range: %d", newest)}
+	}
+
+	result := make([][]byte, 0, len(records))
+	result = append(result, records[newest + 1:]...)
+	result = append(result, records[:newest + 1]...)
+
+	return result, nil
 }
 
 // Функция для разбивки поля ActivityDalyRecords по записям. Нужна для обработки
@@ -50,16 +119,27 @@ func readFileRecords(bytes []byte, recordLen int, offsetBeginRecord int) [][]byt
 // Фукция совершает 2 прохода прямой и обратный. Прямой проходит от указателя самой
 // старой записи (oldestPointer) к концу файла, а обратный -  от самой свежей записи (newestPointer)
 // к началу файла.
-func readActivityDailyRecs(bytesRec []byte, offset int) [][]byte {
+// При ошибке чтения возвращаются записи, которые удалось прочитать до нее.
+func readActivityDailyRecs(bytesRec []byte, offset int) ([][]byte, error) {
 	var result, resultBackward [][]byte
+	tag := "0504"
 	sizeLenBytes := 2
 
+	// секция отсутствует в файле
+	if len(bytesRec) == 0 {
+		return result, nil
+	}
+
 	// Первые 2 байта содержат размер предыдущей записи
 	// следующие 2 байта, размер текущей записи, поэтому смещение 4, чтобы удобнее получать
 	// непосредственное значение записи
 	prefixArdLensSize := 4
-	oldestPointer, _ := hexToInt(bytesRec[:2])
-	newestPointer, _ := hexToInt(bytesRec[2:prefixArdLensSize])
+	pointers, err := readBytes(tag, bytesRec, prefixArdLensSize, 0)
+	if err != nil {
+		return result, err
+	}
+	oldestPointer, _ := hexToInt(pointers[:2])
+	newestPointer, _ := hexToInt(pointers[2:])
 
 	if oldestPointer != 0 {
 		//если задан указатель на самую старую запись, то чтение начентся с нее
@@ -67,14 +147,17 @@ func readActivityDailyRecs(bytesRec []byte, offset int) [][]byte {
 	}
 
 	recordBegin := offset
-	activityADRs := bytes.TrimRight(bytesRec, string(0x00))
+	activityADRs := bytes.TrimRight(bytesRec, "\x00")
 	prevBlockLen := 0
 	activityDailyRecsLen := len(activityADRs)
 	// "прямой" проход
 	for recordBegin < activityDailyRecsLen {
-		lenBytesDelimiterIdx := recordBegin + sizeLenBytes
-		prevRecLen := activityADRs[recordBegin:lenBytesDelimiterIdx]
-		curRecLen := activityADRs[lenBytesDelimiterIdx : lenBytesDelimiterIdx + sizeLenBytes]
+		recLens, err := readBytes(tag, activityADRs, prefixArdLensSize, recordBegin)
+		if err != nil {
+			return result, err
+		}
+		prevRecLen := recLens[:sizeLenBytes]
+		curRecLen := recLens[sizeLenBytes:]
 
 		curBlockLen, _ := bytesToInt(curRecLen)
 		prevLenCurrentBlock, _ := bytesToInt(prevRecLen)
@@ -84,25 +167,37 @@ func readActivityDailyRecs(bytesRec []byte, offset int) [][]byte {
 			break
 		}
 
+		// длина записи не может быть меньше длины ее заголовка
+		if curBlockLen < prefixArdLensSize {
+			return result, newDecodeError(tag, activityADRs, prefixArdLensSize, recordBegin)
+		}
+
 		ardValueBeginOffset := recordBegin + prefixArdLensSize
 		// Т. к. записи начинаются с 4 байтов длин (см. prefixArdLensSize), то размер значения,
 		// текущего Ard, равен {длина записи} - 4 байта
-		ardValueEndOffset := ardValueBeginOffset + curBlockLen - prefixArdLensSize
+		ardValueLen := curBlockLen - prefixArdLensSize
+		ardValueEndOffset := ardValueBeginOffset + ardValueLen
 
 		activity := []byte{}
 		// обработка если запись выходит за пределы длины файла активностей
 		if ardValueEndOffset > activityDailyRecsLen {
 			//обрабатываем перенос в начало файла окночания записи об активности
-			activity = activityADRs[ardValueBeginOffset:]
+			activity = append(activity, activityADRs[ardValueBeginOffset:]...)
 			transferByteCount := ardValueEndOffset - activityDailyRecsLen
 
 			// перенесенные байты читаются от начала файла, за вычетом 4 байт
 			// (указатели на самую страрую и самую новую записи)
-			transferBytesOffset := prefixArdLensSize + transferByteCount
-			activity = append(activity, activityADRs[prefixArdLensSize:transferBytesOffset]...)
+			transfer, err := readBytes(tag, activityADRs, transferByteCount, prefixArdLensSize)
+			if err != nil {
+				return result, err
+			}
+			activity = append(activity, transfer...)
 		} else {
 			//если запись не выходит за пределы секции, то просто читаем активность
-			activity = activityADRs[ardValueBeginOffset:ardValueEndOffset]
+			activity, err = readBytes(tag, activityADRs, ardValueLen, ardValueBeginOffset)
+			if err != nil {
+				return result, err
+			}
 		}
 
 		result = append(result, activity)
@@ -115,27 +210,45 @@ func readActivityDailyRecs(bytesRec []byte, offset int) [][]byte {
 	if newestPointer < oldestPointer {
 		recordBegin = newestPointer + prefixArdLensSize
 		for {
-			lenRecsSep := recordBegin + sizeLenBytes
-			prevRecLen := activityADRs[recordBegin:lenRecsSep]
-			curRecLen := activityADRs[lenRecsSep : lenRecsSep + sizeLenBytes]
+			recLens, err := readBytes(tag, activityADRs, prefixArdLensSize, recordBegin)
+			if err != nil {
+				return result, err
+			}
+			prevRecLen := recLens[:sizeLenBytes]
+			curRecLen := recLens[sizeLenBytes:]
 
 			curBlockLen, _ := bytesToInt(curRecLen)
 			prevBlockLen, _ := bytesToInt(prevRecLen)
 
+			if curBlockLen < prefixArdLensSize {
+				return result, newDecodeError(tag, activityADRs, prefixArdLensSize, recordBegin)
+			}
+
 			ardValueBeginOffset := recordBegin + prefixArdLensSize
-			ardValueEndOffset := ardValueBeginOffset + curBlockLen - prefixArdLensSize
+			ard, err := readBytes(tag, activityADRs, curBlockLen - prefixArdLensSize, ardValueBeginOffset)
+			if err != nil {
+				return result, err
+			}
 
 			//записи записываются в обратной последовательности
-			resultBackward = append(resultBackward, activityADRs[ardValueBeginOffset:ardValueEndOffset])
+			resultBackward = append(resultBackward, ard)
+
+			// нулевая длина предыдущей записи приведет к зацикливанию
+			if prevBlockLen == 0 {
+				break
+			}
 
 			recordBegin = recordBegin - prevBlockLen
-			lenRecsSep = recordBegin + sizeLenBytes
+			lenRecsSep := recordBegin + sizeLenBytes
 
 			if recordBegin < 0 || lenRecsSep < 0 {
 				break
 			}
 
-			nextBlockLen := activityADRs[lenRecsSep : lenRecsSep + sizeLenBytes]
+			nextBlockLen, err := readBytes(tag, activityADRs, sizeLenBytes, lenRecsSep)
+			if err != nil {
+				return result, err
+			}
 			prevLenCurrentBlock, _ := hexToInt(nextBlockLen)
 
 			if prevLenCurrentBlock != prevBlockLen {
@@ -148,58 +261,90 @@ func readActivityDailyRecs(bytesRec []byte, offset int) [][]byte {
 		result = append(result, reverseBytes(resultBackward)...)
 	}
 
-	return result
+	return result, nil
 }
 
 // Функция перерабатывает ddd файл в словарь вида {Тэг: Значение}
 // Например { '0002': []byte{0x00, 0x01} }
-func extractFieldVals(ddd []byte) (map[string][]byte, error) {
+// Данные приложения второго поколения сохраняются под тэгом из 3 байт, например '050402'.
+// Подписи секций возвращаются отдельным словарем под полным тэгом из 3 байт,
+// например '050401' - подпись ЕСТР для секции '0504'.
+func extractFieldVals(ddd []byte) (map[string][]byte, map[string][]byte, error) {
 	/*
 	 */
 	var result = map[string][]byte{}
+	var signatures = map[string][]byte{}
 
 	offset := 0
 	tagCountBytes := 3
 	lenCountBytes := 2
 
 	for offset < len(ddd) {
-		tag := readBytes(ddd, tagCountBytes, offset)
+		tag, err := readBytes("", ddd, tagCountBytes, offset)
+		if err != nil {
+			return result, signatures, err
+		}
+		fieldName := fmt.Sprintf("%X", tag[:2])
+		// данные приложения Tachograph_G2 (третий байт 02) сохраняются под полным тэгом,
+		// чтобы не перезаписать данные первого поколения с тем же номером EF
+		if tag[2] == 0x02 {
+			fieldName = fmt.Sprintf("%X", tag)
+		}
 
 		offset = offset + tagCountBytes
 
-		// 81 - флаг подписи для СКЗИ, 01 - для ЕСТР
-		if tag[2] == 0x81 || tag[2] == 0x01 {
-			digitLenBytes := readBytes(ddd, lenCountBytes, offset)
-			digitLen, err := bytesToInt(digitLenBytes)
+		hex_len, err := readBytes(fieldName, ddd, lenCountBytes, offset)
+		if err != nil {
+			return result, signatures, err
+		}
+		intLen, err := bytesToInt(hex_len)
+		if err != nil {
+			return result, signatures, err
+		}
+		offset = offset + lenCountBytes
+
+		// 81 - флаг подписи для СКЗИ, 01 - для ЕСТР, 03 - для ЕСТР второго поколения
+		if tag[2] == 0x81 || tag[2] == 0x01 || tag[2] == 0x03 {
+			sign, err := readBytes(fieldName, ddd, intLen, offset)
 			if err != nil {
-				return result, err
+				return result, signatures, err
 			}
-			offset = offset + lenCountBytes + digitLen
+			signatures[fmt.Sprintf("%X", tag)] = sign
+			offset = offset + intLen
 			continue
 		}
 
-		hex_len := readBytes(ddd, lenCountBytes, offset)
-		intLen, err := bytesToInt(hex_len)
+		val, err := readBytes(fieldName, ddd, intLen, offset)
 		if err != nil {
-			return result, err
+			// сохраняем доступную часть обрезанной секции, чтобы разобрать из нее
+			// то, что возможно
+			result[fieldName] = ddd[offset:]
+			return result, signatures, err
 		}
-		offset = offset + lenCountBytes
-
-		val := readBytes(ddd, intLen, offset)
 		offset = offset + intLen
 
-		fieldName := fmt.Sprintf("%X", tag[:2])
 		result[fieldName] = val
 	}
-	return result, nil
+	return result, signatures, nil
 }
 
-// Функция для чтения среза из байтового массива.
-func readBytes(bytes []byte, count int, offset int) []byte {
+// Функция для чтения среза из байтового массива. Если count < 0,
+// то читаются все байты до конца массива.
+// При выходе за границы массива возвращается ошибка *DecodeError.
+func readBytes(tag string, bytes []byte, count int, offset int) ([]byte, error) {
+	if offset < 0 || offset > len(bytes) {
+		return nil, newDecodeError(tag, bytes, count, offset)
+	}
+
 	if count < 0 {
-		return bytes[offset:]
+		return bytes[offset:], nil
+	}
+
+	if offset + count > len(bytes) {
+		return nil, newDecodeError(tag, bytes, count, offset)
 	}
-	return bytes[offset : offset + count]
+
+	return bytes[offset : offset + count], nil
 }
 
 // Функция для преобразования 2-х байтного числа в int.
@@ -208,6 +353,9 @@ func bytesToInt(hexLen []byte) (int, error) {
 	if size_array > 2 {
 		return 0, errors.New("Hex len > 2 byte")
 	}
+	if size_array == 0 {
+		return 0, errors.New("Hex len is empty")
+	}
 
 	max_byte_num := size_array - 1
 	buf := new(bytes.Buffer)
@@ -277,8 +425,11 @@ func bitsToInt(Bits string, base int, bitLen int) (int, error) {
 }
 
 // Функция преобразования байтов и биты
-func bytesToBits(bytes []byte) string {
-	return fmt.Sprintf("%08b%08b", bytes[0], bytes[1])
+func bytesToBits(bytes []byte) (string, error) {
+	if len(bytes) < 2 {
+		return "", errors.New("Invalid length []byte for bits, need 2 bytes")
+	}
+	return fmt.Sprintf("%08b%08b", bytes[0], bytes[1]), nil
 }
 
 func byteToBool(b byte) (bool, error) {
@@ -314,6 +465,10 @@ func hexStringToUtf8(bytes_arr []byte) (string, error) {
 	var err error
 
 	result := ""
+	if len(bytes_arr) == 0 {
+		return result, err
+	}
+
 	encode_byte := bytes_arr[0]
 	switch encode_byte {
 	case 0x05:
@@ -337,8 +492,8 @@ func hexStringToUtf8(bytes_arr []byte) (string, error) {
 	}
 
 	result = strings.Trim(result, " ")
-	result = strings.Replace(result, string(0x00), "", -1)
-	result = strings.Replace(result, string(0x05), " ", -1)
+	result = strings.Replace(result, "\x00", "", -1)
+	result = strings.Replace(result, "\x05", " ", -1)
 
 	re := regexp.MustCompile("\\s+")
 	result = re.ReplaceAllString(result, " ")
@@ -366,6 +521,31 @@ func hexToInt(hexVal []byte) (int, error) {
 	return result, err
 }
 
+// Функция преобразования координаты GNSS (3 байта, формат ±DDMM.M * 10)
+// в градусы.
+func bytesToCoordinate(hexVal []byte) (float64, error) {
+	if len(hexVal) != 3 {
+		return 0, errors.New("Invalid length []byte for coordinate, need 3 bytes")
+	}
+
+	val := int32(hexVal[0]) << 16 | int32(hexVal[1]) << 8 | int32(hexVal[2])
+	// знаковое 24-х битное число
+	if val & 0x800000 != 0 {
+		val = val - 0x1000000
+	}
+
+	sign := 1.0
+	if val < 0 {
+		sign = -1.0
+		val = -val
+	}
+
+	degrees := float64(val / 1000)
+	minutes := float64(val % 1000) / 10
+
+	return sign * (degrees + minutes / 60), nil
+}
+
 func reverseBytes(numbers [][]byte) [][]byte {
 	for i := 0; i < len(numbers) / 2; i++ {
 		j := len(numbers) - i - 1
@@ -374,15 +554,43 @@ func reverseBytes(numbers [][]byte) [][]byte {
 	return numbers
 }
 
+// Функция разбирает строку изменений активности за день (2 байта записанные строкой
+// на каждое изменение) в список ActivityChangeInfo.
+func parseChangeInfos(recDate time.Time, activitiesS string) ([]ActivityChangeInfo, error) {
+	beginAciIdx := 0
+	nextAciIdx := beginAciIdx + 4 // т.к. 2 байта записанные строкой это 4 символа
+	aci := ActivityChangeInfo{}
+	result := []ActivityChangeInfo{}
+	if len(activitiesS) % 4 != 0 {
+		return result, fmt.Errorf("Invalid activity change info length: %d", len(activitiesS) / 2)
+	}
+	for beginAciIdx < len(activitiesS) {
+		currentACI := activitiesS[beginAciIdx:nextAciIdx]
+		if err := parseChangeInfoData(recDate, currentACI, &aci); err != nil {
+			return result, err
+		}
+
+		beginAciIdx = nextAciIdx
+		nextAciIdx = nextAciIdx + 4
+
+		result = append(result, aci)
+	}
+
+	return result, nil
+}
+
 // функция загрузки информации об активности за день по водителю
-func parseChangeInfoData(recDate time.Time, currentACI string, aci *activityChangeInfo) error {
+func parseChangeInfoData(recDate time.Time, currentACI string, aci *ActivityChangeInfo) error {
 
 	r, err := hexdemicalToBytes(currentACI)
 	if err != nil {
 		return fmt.Errorf("Can't decode activity change info: %v", err)
 	}
 
-	aciBytes := bytesToBits(r)
+	aciBytes, err := bytesToBits(r)
+	if err != nil {
+		return fmt.Errorf("Can't decode activity change info: %v", err)
+	}
 
 	flag, err := byteToBool(aciBytes[0])
 	if err != nil {
@@ -419,7 +627,8 @@ func parseChangeInfoData(recDate time.Time, currentACI string, aci *activityChan
 		return fmt.Errorf("Can't get ActivityKindId from activity change info: %v", err)
 	}
 
-	aci.ActivityKindId = kindId
+	aci.ActivityKindId = ActivityKind(kindId)
+	aci.ActivityKindName = aci.ActivityKindId.String()
 	minutes, err := bitsToInt(aciBytes[5:], 2, 12)
 	if err != nil {
 		return fmt.Errorf("Can't get ActivityChangeInfoT from activity change info: %v", err)
@@ -0,0 +1,140 @@
+package ddd
+
+import (
+	"errors"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestReadBytesOutOfBounds(t *testing.T) {
+	data := []byte{1, 2, 3, 4}
+	tests := []struct {
+		count    int
+		offset   int
+		expected int
+		actual   int
+	}{
+		{count: 5, offset: 0, expected: 5, actual: 4},
+		{count: 2, offset: 3, expected: 2, actual: 1},
+		{count: 1, offset: 4, expected: 1, actual: 0},
+		{count: 1, offset: 10, expected: 1, actual: 0},
+	}
+	for _, tt := range tests {
+		_, err := readBytes("0504", data, tt.count, tt.offset)
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("count %d offset %d: expected DecodeError, got %v", tt.count, tt.offset, err)
+		}
+		if decodeErr.Tag != "0504" || decodeErr.Offset != tt.offset || decodeErr.Expected != tt.expected ||
+			decodeErr.Actual != tt.actual {
+			t.Errorf("count %d offset %d: unexpected error %+v", tt.count, tt.offset, decodeErr)
+		}
+	}
+
+	if b, err := readBytes("0504", data, -1, 2); err != nil || len(b) != 2 {
+		t.Errorf("read to the end: %v %v", b, err)
+	}
+}
+
+func TestExtractFieldValsTruncated(t *testing.T) {
+	ddd := append(tlv([]byte{0x00, 0x02, 0x00}, make([]byte, 25)), tlv([]byte{0x05, 0x04, 0x00}, make([]byte, 100))...)
+
+	// обрезаны данные секции: предыдущие секции сохраняются
+	fields, _, err := extractFieldVals(ddd[:len(ddd)-40])
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected DecodeError, got %v", err)
+	}
+	if decodeErr.Tag != "0504" || decodeErr.Offset != 35 || decodeErr.Expected != 100 || decodeErr.Actual != 60 {
+		t.Errorf("unexpected error %+v", decodeErr)
+	}
+	if len(fields["0002"]) != 25 {
+		t.Errorf("unexpected fields: 0002 %d bytes", len(fields["0002"]))
+	}
+
+	// обрезан заголовок tlv записи
+	_, _, err = extractFieldVals(ddd[:31])
+	if !errors.As(err, &decodeErr) || decodeErr.Tag != "" || decodeErr.Offset != 30 || decodeErr.Actual != 1 {
+		t.Errorf("unexpected header error %v", err)
+	}
+}
+
+func TestParseBytesTruncated(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ddd := buildDriverDDD()
+	if _, err := ParseBytes(ddd); err != nil {
+		t.Fatalf("full file: %v", err)
+	}
+	// границы tlv записей: файл, обрезанный по границе, разбирается без ошибки чтения
+	boundaries := map[int]bool{}
+	for offset := 0; offset < len(ddd); offset += 5 + int(ddd[offset+3])<<8 + int(ddd[offset+4]) {
+		boundaries[offset] = true
+	}
+	for n := 1; n < len(ddd); n++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on file truncated to %d bytes: %v", n, r)
+				}
+			}()
+			if _, err := ParseBytes(ddd[:n]); err == nil && !boundaries[n] {
+				t.Fatalf("file truncated to %d bytes parsed without error", n)
+			}
+		}()
+	}
+}
+
+func TestParseBytesCorrupted(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ddd := buildDriverDDD()
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 2000; n++ {
+		corrupted := append([]byte{}, ddd...)
+		for k := 0; k < 1+r.Intn(8); k++ {
+			corrupted[r.Intn(len(corrupted))] = byte(r.Intn(256))
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on corrupted file %d: %v", n, r)
+				}
+			}()
+			ParseBytes(corrupted)
+		}()
+	}
+}
+
+func TestOrderCyclicRecords(t *testing.T) {
+	records := [][]byte{{2}, {3}, {0}, {1}}
+	tests := []struct {
+		newest   int
+		expected []byte
+		err      bool
+	}{
+		{newest: 1, expected: []byte{0, 1, 2, 3}},
+		{newest: 3, expected: []byte{2, 3, 0, 1}},
+		{newest: 0, expected: []byte{3, 0, 1, 2}},
+		{newest: 4, expected: []byte{2, 3, 0, 1}, err: true},
+		{newest: -1, expected: []byte{2, 3, 0, 1}, err: true},
+	}
+	for _, tt := range tests {
+		ordered, err := orderCyclicRecords("0506", records, tt.newest)
+		if (err != nil) != tt.err {
+			t.Errorf("newest %d: unexpected error %v", tt.newest, err)
+		}
+		got := []byte{}
+		for _, r := range ordered {
+			got = append(got, r[0])
+		}
+		if string(got) != string(tt.expected) {
+			t.Errorf("newest %d: got order %v, expected %v", tt.newest, got, tt.expected)
+		}
+	}
+}
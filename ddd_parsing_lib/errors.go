@@ -0,0 +1,95 @@
+package ddd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ошибка чтения данных за границами секции ddd файла.
+// Возникает при разборе обрезанных или поврежденных файлов.
+type DecodeError struct {
+	Tag      string // тэг секции (EF), при чтении заголовка tlv записи пустой
+	Offset   int    // смещение, с которого производилось чтение
+	Expected int    // ожидаемое количество байт
+	Actual   int    // количество байт, доступных начиная со смещения
+}
+
+func (e *DecodeError) Error() string {
+	tag := e.Tag
+	if tag == "" {
+		tag = "header"
+	}
+	return fmt.Sprintf("Can't read section [name:%s offset:%d expected:%d actual:%d]",
+		tag, e.Offset, e.Expected, e.Actual)
+}
+
+// Функция создает ошибку чтения для массива data длинной count байт начиная с offset
+func newDecodeError(tag string, data []byte, count int, offset int) *DecodeError {
+	actual := len(data) - offset
+	if actual < 0 {
+		actual = 0
+	}
+
+	return &DecodeError{
+		Tag:      tag,
+		Offset:   offset,
+		Expected: count,
+		Actual:   actual,
+	}
+}
+
+// Ошибка загрузки данных секции ddd файла
+type SectionError struct {
+	Tag string // тэг секции (EF)
+	Err error  // исходная ошибка
+}
+
+func (e *SectionError) Error() string {
+	return fmt.Sprintf("Section [name:%s] load error: %v", e.Tag, e.Err)
+}
+
+// Список ошибок загрузки полей структуры. Используется, чтобы при ошибке
+// в одном поле продолжить загрузку остальных.
+type loadErrors []error
+
+func (e loadErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Функция возвращает тэг секции, при загрузке которой возникла ошибка.
+// Если тэг определить нельзя, то возвращается пустая строка.
+func errorTag(err error) string {
+	switch e := err.(type) {
+	case *DecodeError:
+		return e.Tag
+	case *SectionError:
+		return e.Tag
+	}
+	return ""
+}
+
+// Функция раскладывает ошибку загрузки на список ошибок по отдельным полям
+func splitErrors(err error) []error {
+	if errs, ok := err.(loadErrors); ok {
+		return errs
+	}
+	return []error{err}
+}
+
+// Функция добавляет к тэгам секций в ошибке суффикс. Используется при загрузке данных
+// приложения второго поколения, тэги которых приводятся к номерам EF перед загрузкой.
+func suffixErrorTags(err error, suffix string) error {
+	for _, e := range splitErrors(err) {
+		switch t := e.(type) {
+		case *DecodeError:
+			t.Tag = t.Tag + suffix
+		case *SectionError:
+			t.Tag = t.Tag + suffix
+		}
+	}
+	return err
+}
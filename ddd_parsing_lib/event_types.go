@@ -0,0 +1,154 @@
+package ddd
+
+// Тип события или неисправности (EventFaultType) по приложению 1B и 1C
+type EventFaultType int
+
+var eventFaultTypeLabels = map[int]codeLabel{
+	0x00: {"NO_FURTHER_DETAILS", "No further details", "Без уточнения"},
+	0x01: {"INSERTION_OF_NON_VALID_CARD", "Insertion of a non valid card", "Установка недействительной карты"},
+	0x02: {"CARD_CONFLICT", "Card conflict", "Конфликт карт"},
+	0x03: {"TIME_OVERLAP", "Time overlap", "Перекрытие времени"},
+	0x04: {"DRIVING_WITHOUT_APPROPRIATE_CARD", "Driving without an appropriate card",
+		"Управление без соответствующей карты"},
+	0x05: {"CARD_INSERTION_WHILE_DRIVING", "Card insertion while driving", "Установка карты во время управления"},
+	0x06: {"LAST_CARD_SESSION_NOT_CORRECTLY_CLOSED", "Last card session not correctly closed",
+		"Последний сеанс использования карты завершен неправильно"},
+	0x07: {"OVER_SPEEDING", "Over speeding", "Превышение скорости"},
+	0x08: {"POWER_SUPPLY_INTERRUPTION", "Power supply interruption", "Прерывание электропитания"},
+	0x09: {"MOTION_DATA_ERROR", "Motion data error", "Ошибка данных о движении"},
+	0x0A: {"VEHICLE_MOTION_CONFLICT", "Vehicle motion conflict", "Конфликт данных о движении ТС"},
+	0x0B: {"TIME_CONFLICT", "Time conflict", "Конфликт времени"},
+	0x0C: {"REMOTE_COMMUNICATION_ERROR", "Communication error with the remote communication facility",
+		"Ошибка связи с устройством дистанционной связи"},
+	0x0D: {"ABSENCE_OF_GNSS_POSITION", "Absence of position information from GNSS receiver",
+		"Отсутствие данных о местоположении от приемника GNSS"},
+	0x0E: {"EXTERNAL_GNSS_COMMUNICATION_ERROR", "Communication error with the external GNSS facility",
+		"Ошибка связи с внешним устройством GNSS"},
+	0x10: {"VU_SECURITY_BREACH_ATTEMPT", "Vehicle unit security breach attempt, no further details",
+		"Попытка нарушения защиты бортового устройства без уточнения"},
+	0x11: {"MOTION_SENSOR_AUTHENTICATION_FAILURE", "Motion sensor authentication failure",
+		"Ошибка аутентификации датчика движения"},
+	0x12: {"CARD_AUTHENTICATION_FAILURE", "Tachograph card authentication failure",
+		"Ошибка аутентификации карты тахографа"},
+	0x13: {"UNAUTHORISED_MOTION_SENSOR_CHANGE", "Unauthorised change of motion sensor",
+		"Несанкционированная замена датчика движения"},
+	0x14: {"CARD_DATA_INPUT_INTEGRITY_ERROR", "Card data input integrity error",
+		"Ошибка целостности данных, вводимых с карты"},
+	0x15: {"STORED_USER_DATA_INTEGRITY_ERROR", "Stored user data integrity error",
+		"Ошибка целостности хранимых данных пользователя"},
+	0x16: {"INTERNAL_DATA_TRANSFER_ERROR", "Internal data transfer error", "Ошибка внутренней передачи данных"},
+	0x17: {"UNAUTHORISED_CASE_OPENING", "Unauthorised case opening", "Несанкционированное вскрытие корпуса"},
+	0x18: {"HARDWARE_SABOTAGE", "Hardware sabotage", "Повреждение аппаратных средств"},
+	0x19: {"GNSS_TAMPER_DETECTION", "Tamper detection of GNSS", "Обнаружение вмешательства в работу GNSS"},
+	0x1A: {"EXTERNAL_GNSS_AUTHENTICATION_FAILURE", "External GNSS facility authentication failure",
+		"Ошибка аутентификации внешнего устройства GNSS"},
+	0x1B: {"EXTERNAL_GNSS_CERTIFICATE_EXPIRED", "External GNSS facility certificate expired",
+		"Истек срок действия сертификата внешнего устройства GNSS"},
+	0x20: {"SENSOR_SECURITY_BREACH_ATTEMPT", "Sensor security breach attempt, no further details",
+		"Попытка нарушения защиты датчика движения без уточнения"},
+	0x21: {"SENSOR_AUTHENTICATION_FAILURE", "Sensor authentication failure", "Ошибка аутентификации датчика"},
+	0x22: {"SENSOR_STORED_DATA_INTEGRITY_ERROR", "Sensor stored data integrity error",
+		"Ошибка целостности данных, хранящихся в датчике"},
+	0x23: {"SENSOR_INTERNAL_DATA_TRANSFER_ERROR", "Sensor internal data transfer error",
+		"Ошибка внутренней передачи данных датчика"},
+	0x24: {"SENSOR_UNAUTHORISED_CASE_OPENING", "Sensor unauthorised case opening",
+		"Несанкционированное вскрытие корпуса датчика"},
+	0x25: {"SENSOR_HARDWARE_SABOTAGE", "Sensor hardware sabotage", "Повреждение аппаратных средств датчика"},
+	0x30: {"RECORDING_EQUIPMENT_FAULT", "Recording equipment fault, no further details",
+		"Неисправность контрольного устройства без уточнения"},
+	0x31: {"VU_INTERNAL_FAULT", "VU internal fault", "Внутренняя неисправность бортового устройства"},
+	0x32: {"PRINTER_FAULT", "Printer fault", "Неисправность принтера"},
+	0x33: {"DISPLAY_FAULT", "Display fault", "Неисправность дисплея"},
+	0x34: {"DOWNLOADING_FAULT", "Downloading fault", "Неисправность выгрузки данных"},
+	0x35: {"SENSOR_FAULT", "Sensor fault", "Неисправность датчика"},
+	0x36: {"INTERNAL_GNSS_RECEIVER_FAULT", "Internal GNSS receiver fault", "Неисправность внутреннего приемника GNSS"},
+	0x37: {"EXTERNAL_GNSS_FAULT", "External GNSS facility fault", "Неисправность внешнего устройства GNSS"},
+	0x38: {"REMOTE_COMMUNICATION_FAULT", "Remote communication facility fault",
+		"Неисправность устройства дистанционной связи"},
+	0x39: {"ITS_INTERFACE_FAULT", "ITS interface fault", "Неисправность интерфейса ИТС"},
+	0x40: {"CARD_FAULT", "Card fault, no further details", "Неисправность карты без уточнения"},
+}
+
+var (
+	eventFaultRfu                  = codeLabel{"RFU", "RFU", "Зарезервировано"}
+	eventFaultManufacturerSpecific = codeLabel{"MANUFACTURER_SPECIFIC", "Manufacturer specific",
+		"Определяется изготовителем"}
+)
+
+// Названия групп, в которые объединяются типы событий безопасности и неисправностей
+var eventFaultGroupLabels = map[int]codeLabel{
+	0x10: {"VU_SECURITY_BREACH_ATTEMPT", "Vehicle unit security breach attempts",
+		"Попытки нарушения защиты бортового устройства"},
+	0x20: {"SENSOR_SECURITY_BREACH_ATTEMPT", "Motion sensor security breach attempts",
+		"Попытки нарушения защиты датчика движения"},
+	0x30: {"RECORDING_EQUIPMENT_FAULT", "Recording equipment faults", "Неисправности контрольного устройства"},
+	0x40: {"CARD_FAULT", "Card faults", "Неисправности карты"},
+}
+
+// Порядок групп событий и неисправностей на карте первого поколения (приложение 1B, требования 204 и 207).
+// Используется для определения типа группы, в которой нет ни одной записи.
+var (
+	gen1EventGroupTypes = []EventFaultType{0x03, 0x05, 0x06, 0x08, 0x09, 0x10}
+	gen1FaultGroupTypes = []EventFaultType{0x30, 0x40}
+)
+
+func (t EventFaultType) code() codeLabel {
+	if l, ok := eventFaultTypeLabels[int(t)]; ok {
+		return l
+	}
+	if t < 0x80 {
+		return eventFaultRfu
+	}
+	return eventFaultManufacturerSpecific
+}
+
+func (t EventFaultType) String() string {
+	return t.code().name
+}
+
+func (t EventFaultType) Label(lang string) string {
+	return t.code().label(lang)
+}
+
+// Функция возвращает название типа события или неисправности на английском языке
+func EventFaultTypeName(id int) string {
+	return EventFaultType(id).Label(LangEn)
+}
+
+// Метод возвращает тип группы, к которой относится тип события или неисправности.
+// Общие события (0x00-0x0F) образуют отдельные группы, остальные объединяются по старшему полубайту.
+func (t EventFaultType) Group() EventFaultType {
+	if t < 0x10 {
+		return t
+	}
+
+	return t &^ 0x0F
+}
+
+// Метод возвращает название группы событий или неисправностей на языке lang
+func (t EventFaultType) GroupLabel(lang string) string {
+	if l, ok := eventFaultGroupLabels[int(t.Group())]; ok {
+		return l.label(lang)
+	}
+
+	return t.Group().Label(lang)
+}
+
+// Функция возвращает название группы событий или неисправностей на английском языке
+func EventFaultGroupName(groupType int) string {
+	return EventFaultType(groupType).GroupLabel(LangEn)
+}
+
+// Функция определяет тип группы по первой записи, а для пустой группы - по ее позиции на карте
+// первого поколения. Если тип определить нельзя, возвращается -1.
+func detectEventFaultGroupType(firstTypeId EventFaultType, hasRecords bool, index int, blocks int,
+	gen1Types []EventFaultType) EventFaultType {
+	if hasRecords {
+		return firstTypeId.Group()
+	}
+	if blocks == len(gen1Types) {
+		return gen1Types[index]
+	}
+
+	return -1
+}
@@ -0,0 +1,100 @@
+package ddd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Функция формирует данные карты первого поколения с двумя записями на тип события
+// и тремя записями на тип неисправности: событие 0x05, событие безопасности 0x13
+// и неисправность карты 0x40
+func buildEventFaultRecords() map[string][]byte {
+	ev := make([]byte, 24*2*6)
+	copy(ev[24*2:], append([]byte{0x05}, ts(day0)...))
+	copy(ev[24*11:], append([]byte{0x13}, ts(day0.Add(time.Hour))...))
+	fl := make([]byte, 24*3*2)
+	copy(fl[24*3:], append([]byte{0x40}, ts(day0)...))
+	return map[string][]byte{"0501": {1, 0, 2, 2, 3}, "0502": ev, "0503": fl}
+}
+
+func TestCardEventGroups(t *testing.T) {
+	recs := buildEventFaultRecords()
+
+	var groups CardEventGroups
+	if err := loadFields(&groups, recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 6 {
+		t.Fatalf("expected 6 event groups, got %d", len(groups))
+	}
+	// пустая группа получает тип по своей позиции на карте
+	if groups[0].EventTypeId != 0x03 || len(groups[0].Records) != 0 {
+		t.Errorf("unexpected empty group %+v", groups[0])
+	}
+	if groups[1].EventTypeId != 0x05 || groups[1].GroupName != "Card insertion while driving" || len(groups[1].Records) != 1 {
+		t.Errorf("unexpected event group %+v", groups[1])
+	}
+	g := groups[5]
+	if g.EventTypeId != 0x10 || g.GroupName != "Vehicle unit security breach attempts" || len(g.Records) != 1 ||
+		g.Records[0].EventTypeId != 0x13 {
+		t.Errorf("unexpected security breach group %+v", g)
+	}
+
+	var faults CardFaultGroups
+	if err := loadFields(&faults, recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(faults) != 2 || faults[0].GroupName != "Recording equipment faults" || faults[1].FaultTypeId != 0x40 ||
+		len(faults[1].Records) != 1 {
+		t.Errorf("unexpected fault groups %+v", faults)
+	}
+
+	// плоские списки составляются из записей групп в порядке групп
+	events := groups.Records()
+	if len(events) != 2 || events[0].EventTypeId != 0x05 || events[1].EventTypeId != 0x13 {
+		t.Errorf("unexpected event records %+v", events)
+	}
+	if records := faults.Records(); len(records) != 1 || records[0].FaultTypeId != 0x40 {
+		t.Errorf("unexpected fault records %+v", records)
+	}
+}
+
+func TestCardEventJson(t *testing.T) {
+	dumps := map[string][]byte{
+		"driver":   buildDriverDDD(),
+		"workshop": buildWorkshopDDD(),
+		"gen2":     append(buildDriverDDD(), buildG2Part()...),
+	}
+	for name, d := range dumps {
+		c, _ := ParseBytes(d)
+		if name == "driver" && len(c.CardEventRecords) == 0 {
+			t.Errorf("%s: flat event list is not built", name)
+		}
+		// записи выводятся только в группах
+		js, _ := c.ExportToJson()
+		if strings.Contains(js, "\"CardEventRecords\"") || strings.Contains(js, "\"CardFaultRecords\"") {
+			t.Errorf("%s: flat event and fault lists in JSON", name)
+		}
+		if !strings.Contains(js, "\"CardEventGroups\"") || !strings.Contains(js, "\"CardFaultGroups\"") {
+			t.Errorf("%s: event and fault groups not found in JSON", name)
+		}
+	}
+}
+
+func TestEventFaultTypeName(t *testing.T) {
+	tests := []struct {
+		id       int
+		expected string
+	}{
+		{0x05, "Card insertion while driving"},
+		{0x40, "Card fault, no further details"},
+		{0x0F, "RFU"},
+		{0x90, "Manufacturer specific"},
+	}
+	for _, tt := range tests {
+		if got := EventFaultTypeName(tt.id); got != tt.expected {
+			t.Errorf("%#x: got %q, expected %q", tt.id, got, tt.expected)
+		}
+	}
+}
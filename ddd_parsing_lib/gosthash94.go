@@ -0,0 +1,167 @@
+package ddd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Реализация функции хэширования ГОСТ Р 34.11-94 (используется с подписью ГОСТ Р 34.10-2001).
+// Блоки сообщения, состояние и хэш-код хранятся как 32 байта, младший байт - первый.
+
+type gostHash94Block [32]byte
+
+// Таблица замен ГОСТ 28147-89, K1 - для младших 4 бит
+type gost28147SBox [8][16]byte
+
+// Таблица замен id-GostR3411-94-CryptoProParamSet (RFC 4357)
+var gostHash94CryptoProSBox = gost28147SBox{
+	{0xA, 0x4, 0x5, 0x6, 0x8, 0x1, 0x3, 0x7, 0xD, 0xC, 0xE, 0x0, 0x9, 0x2, 0xB, 0xF},
+	{0x5, 0xF, 0x4, 0x0, 0x2, 0xD, 0xB, 0x9, 0x1, 0x7, 0x6, 0x3, 0xC, 0xE, 0xA, 0x8},
+	{0x7, 0xF, 0xC, 0xE, 0x9, 0x4, 0x1, 0x0, 0x3, 0xB, 0x5, 0x2, 0x6, 0xA, 0x8, 0xD},
+	{0x4, 0xA, 0x7, 0xC, 0x0, 0xF, 0x2, 0x8, 0xE, 0x1, 0x6, 0x5, 0xD, 0xB, 0x9, 0x3},
+	{0x7, 0x6, 0x4, 0xB, 0x9, 0xC, 0x2, 0xA, 0x1, 0x8, 0x0, 0xE, 0xF, 0xD, 0x3, 0x5},
+	{0x7, 0x6, 0x2, 0x4, 0xD, 0x9, 0xF, 0x0, 0xA, 0x1, 0x5, 0xB, 0x8, 0xE, 0xC, 0x3},
+	{0xD, 0xE, 0x4, 0x1, 0x7, 0x0, 0x5, 0xA, 0x3, 0xC, 0x8, 0xF, 0x6, 0x2, 0x9, 0xB},
+	{0x1, 0x3, 0xA, 0x9, 0x5, 0xB, 0x4, 0xF, 0x8, 0x6, 0x7, 0xE, 0xD, 0x0, 0x2, 0xC},
+}
+
+// Константа C3 генерации ключей
+var gostHash94C3 = gostHash94Block{
+	0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x00, 0xFF, 0x00,
+	0x00, 0xFF, 0xFF, 0x00, 0xFF, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0x00, 0xFF,
+}
+
+// Шифрование блока по ГОСТ 28147-89 в режиме простой замены
+func gost28147Encrypt(sbox *gost28147SBox, key []byte, block []byte) []byte {
+	var k [8]uint32
+	for i := range k {
+		k[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	n1 := binary.LittleEndian.Uint32(block)
+	n2 := binary.LittleEndian.Uint32(block[4:])
+
+	for round := 0; round < 32; round++ {
+		idx := round % 8
+		if round >= 24 {
+			idx = 7 - idx
+		}
+		t := n1 + k[idx]
+		var s uint32
+		for i := 0; i < 8; i++ {
+			s |= uint32(sbox[i][(t>>(4*uint(i)))&0xF]) << (4 * uint(i))
+		}
+		n1, n2 = bits.RotateLeft32(s, 11)^n2, n1
+	}
+
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint32(out, n2)
+	binary.LittleEndian.PutUint32(out[4:], n1)
+	return out
+}
+
+func gostHash94Xor(a, b gostHash94Block) gostHash94Block {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+// Сложение блоков по модулю 2^256
+func gostHash94Add(a, b gostHash94Block) gostHash94Block {
+	carry := 0
+	for i := range a {
+		sum := int(a[i]) + int(b[i]) + carry
+		a[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return a
+}
+
+// Преобразование A: (y4 || y3 || y2 || y1) -> (y1 ^ y2) || y4 || y3 || y2
+func gostHash94A(y gostHash94Block) gostHash94Block {
+	var r gostHash94Block
+	copy(r[:24], y[8:])
+	for i := 0; i < 8; i++ {
+		r[24+i] = y[i] ^ y[8+i]
+	}
+	return r
+}
+
+// Перестановка байт P: байт i + 4k результата - байт 8i + k исходного блока
+func gostHash94P(y gostHash94Block) gostHash94Block {
+	var r gostHash94Block
+	for i := 0; i < 4; i++ {
+		for k := 0; k < 8; k++ {
+			r[i+4*k] = y[8*i+k]
+		}
+	}
+	return r
+}
+
+// Преобразование ψ над 16-битными словами: y1 ^ y2 ^ y3 ^ y4 ^ y13 ^ y16 || y16 || ... || y2
+func gostHash94Psi(y gostHash94Block) gostHash94Block {
+	var r gostHash94Block
+	copy(r[:30], y[2:])
+	for _, i := range []int{0, 1, 2, 3, 12, 15} {
+		r[30] ^= y[2*i]
+		r[31] ^= y[2*i+1]
+	}
+	return r
+}
+
+// Шаговая функция хэширования
+func gostHash94Step(sbox *gost28147SBox, h, m gostHash94Block) gostHash94Block {
+	var keys [4]gostHash94Block
+	u, v := h, m
+	keys[0] = gostHash94P(gostHash94Xor(u, v))
+	for j := 1; j < 4; j++ {
+		u = gostHash94A(u)
+		if j == 2 {
+			u = gostHash94Xor(u, gostHash94C3)
+		}
+		v = gostHash94A(gostHash94A(v))
+		keys[j] = gostHash94P(gostHash94Xor(u, v))
+	}
+
+	var s gostHash94Block
+	for i := 0; i < 4; i++ {
+		copy(s[8*i:], gost28147Encrypt(sbox, keys[i][:], h[8*i:8*i+8]))
+	}
+
+	for i := 0; i < 12; i++ {
+		s = gostHash94Psi(s)
+	}
+	s = gostHash94Psi(gostHash94Xor(m, s))
+	s = gostHash94Xor(h, s)
+	for i := 0; i < 61; i++ {
+		s = gostHash94Psi(s)
+	}
+	return s
+}
+
+// Функция вычисляет хэш-код ГОСТ Р 34.11-94 с таблицей замен sbox. Неполный последний блок
+// дополняется нулями, после блоков сообщения обрабатываются длина сообщения в битах
+// и контрольная сумма блоков.
+func gostHash94(data []byte, sbox *gost28147SBox) []byte {
+	var h, sum, length gostHash94Block
+	bitLen := uint64(len(data)) * 8
+
+	for len(data) > 0 {
+		var m gostHash94Block
+		n := copy(m[:], data)
+		data = data[n:]
+		h = gostHash94Step(sbox, h, m)
+		sum = gostHash94Add(sum, m)
+	}
+
+	binary.LittleEndian.PutUint64(length[:], bitLen)
+	h = gostHash94Step(sbox, h, length)
+	h = gostHash94Step(sbox, h, sum)
+
+	return h[:]
+}
+
+// Функция вычисляет хэш-код ГОСТ Р 34.11-94 с параметрами КриптоПро
+func gostHash94CryptoPro(data []byte) []byte {
+	return gostHash94(data, &gostHash94CryptoProSBox)
+}
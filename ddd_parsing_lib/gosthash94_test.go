@@ -0,0 +1,52 @@
+package ddd
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Таблица замен id-GostR3411-94-TestParamSet, с которой приведены примеры RFC 5831
+var gostHash94TestSBox = gost28147SBox{
+	{0x4, 0xA, 0x9, 0x2, 0xD, 0x8, 0x0, 0xE, 0x6, 0xB, 0x1, 0xC, 0x7, 0xF, 0x5, 0x3},
+	{0xE, 0xB, 0x4, 0xC, 0x6, 0xD, 0xF, 0xA, 0x2, 0x3, 0x8, 0x1, 0x0, 0x7, 0x5, 0x9},
+	{0x5, 0x8, 0x1, 0xD, 0xA, 0x3, 0x4, 0x2, 0xE, 0xF, 0xC, 0x7, 0x6, 0x0, 0x9, 0xB},
+	{0x7, 0xD, 0xA, 0x1, 0x0, 0x8, 0x9, 0xF, 0xE, 0x4, 0x6, 0xC, 0xB, 0x2, 0x5, 0x3},
+	{0x6, 0xC, 0x7, 0x1, 0x5, 0xF, 0xD, 0x8, 0x4, 0xA, 0x9, 0xE, 0x0, 0x3, 0xB, 0x2},
+	{0x4, 0xB, 0xA, 0x0, 0x7, 0x2, 0x1, 0xD, 0x3, 0x6, 0x8, 0x5, 0x9, 0xC, 0xF, 0xE},
+	{0xD, 0xB, 0x4, 0x1, 0x3, 0xF, 0x5, 0x9, 0x0, 0xA, 0xE, 0x7, 0x6, 0x8, 0x2, 0xC},
+	{0x1, 0xF, 0xD, 0x0, 0x5, 0x7, 0xA, 0x4, 0x9, 0x2, 0x3, 0xE, 0x6, 0xB, 0x8, 0xC},
+}
+
+// Примеры RFC 5831 (раздел 7.3) с тестовой таблицей замен
+func TestGostHash94TestParamSet(t *testing.T) {
+	tests := []struct {
+		message string
+		digest  string
+	}{
+		{"This is message, length=32 bytes", "b1c466d37519b82e8319819ff32595e047a28cb6f83eff1c6916a815a637fffa"},
+		{"Suppose the original message has length = 50 bytes",
+			"471aba57a60a770d3a76130635c1fbea4ef14de51f78b4ae57dd893b62f55208"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(gostHash94([]byte(tt.message), &gostHash94TestSBox)); got != tt.digest {
+			t.Errorf("%q: got %s, expected %s", tt.message, got, tt.digest)
+		}
+	}
+}
+
+func TestGostHash94CryptoPro(t *testing.T) {
+	tests := []struct {
+		message string
+		digest  string
+	}{
+		{"", "981e5f3ca30c841487830f84fb433e13ac1101569b9c13584ac483234cd656c0"},
+		{"a", "e74c52dd282183bf37af0079c9f78055715a103f17e3133ceff1aacf2f403011"},
+		{"The quick brown fox jumps over the lazy dog", "9004294a361a508c586fe53d1f1b02746765e71b765472786e4770d565830a76"},
+		{"GOST R 34.10-2001", "8590d60b132f7f2a931c2154dfdacf9ae5334619b77aac9f716f48adf19ed02c"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(gostHash94CryptoPro([]byte(tt.message))); got != tt.digest {
+			t.Errorf("%q: got %s, expected %s", tt.message, got, tt.digest)
+		}
+	}
+}
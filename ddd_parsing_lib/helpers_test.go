@@ -0,0 +1,197 @@
+package ddd
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Функция формирует tlv запись ddd файла
+func tlv(tag []byte, val []byte) []byte {
+	out := append([]byte{}, tag...)
+	l := make([]byte, 2)
+	binary.BigEndian.PutUint16(l, uint16(len(val)))
+	out = append(out, l...)
+	return append(out, val...)
+}
+
+// Функция кодирует время в формате TimeReal
+func ts(t time.Time) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()))
+	return b
+}
+
+// Функция кодирует изменение деятельности (ActivityChangeInfo)
+func aci(slot, crew, inserted, kind, minutes int) []byte {
+	v := slot<<15 | crew<<14 | inserted<<13 | kind<<11 | minutes
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+var day0 = time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+
+// Функция формирует циклический буфер суточных записей о деятельности
+func buildActivity(days int) []byte {
+	buf := make([]byte, 4)
+	prev := 0
+	var recs []byte
+	for d := 0; d < days; d++ {
+		var acts []byte
+		acts = append(acts, aci(0, 0, 1, 0, 0)...)
+		acts = append(acts, aci(0, 0, 1, 3, 6*60)...)
+		acts = append(acts, aci(0, 0, 1, 1, 7*60)...)
+		acts = append(acts, aci(0, 0, 1, 3, 11*60+30)...)
+		acts = append(acts, aci(0, 0, 1, 0, 12*60)...)
+		acts = append(acts, aci(0, 0, 1, 3, 12*60+45)...)
+		acts = append(acts, aci(0, 0, 1, 0, 17*60)...)
+		l := 4 + 4 + 2 + 2 + len(acts)
+		r := []byte{byte(prev >> 8), byte(prev), byte(l >> 8), byte(l)}
+		r = append(r, ts(day0.AddDate(0, 0, d))...)
+		r = append(r, 0x00, byte(d+1), 0x01, 0x90)
+		r = append(r, acts...)
+		recs = append(recs, r...)
+		prev = l
+	}
+	newest := len(recs) - prev
+	buf[0], buf[1] = 0, 0
+	buf[2], buf[3] = byte(newest>>8), byte(newest)
+	buf = append(buf, recs...)
+	return append(buf, make([]byte, 64)...)
+}
+
+// Функция дополняет строку пробелами до длины n
+func strField(s string, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	copy(b, s)
+	return b
+}
+
+// Функция формирует записи об использовании ТС (CardVehicleRecord)
+func buildVehicles(n int) []byte {
+	out := []byte{0x00, byte(n - 1)}
+	odo := 1000
+	for i := 0; i < n; i++ {
+		r := []byte{0, byte(odo >> 8), byte(odo), 0, byte((odo + 400) >> 8), byte(odo + 400)}
+		r = append(r, ts(day0.AddDate(0, 0, i).Add(6*time.Hour))...)
+		r = append(r, ts(day0.AddDate(0, 0, i).Add(17*time.Hour))...)
+		r = append(r, 0x11)
+		r = append(r, append([]byte{0x01}, strField("AB123", 13)...)...)
+		r = append(r, 0x00, 0x01)
+		out = append(out, r...)
+		odo += 400
+	}
+	return append(out, make([]byte, 31*2)...)
+}
+
+// Функция формирует записи о местах начала и окончания ежедневного периода работы
+func buildPlaces(n int) []byte {
+	out := []byte{byte(n - 1)}
+	for i := 0; i < n; i++ {
+		d := day0.AddDate(0, 0, i/2)
+		h := 6 * time.Hour
+		tp := 0
+		if i%2 == 1 {
+			h = 17 * time.Hour
+			tp = 1
+		}
+		r := append(ts(d.Add(h)), byte(tp), 0x11, 0x00, 0, 0x03, 0xE8)
+		out = append(out, r...)
+	}
+	return append(out, make([]byte, 10*2)...)
+}
+
+// Функция формирует ddd файл карты водителя первого поколения: 5 суток деятельности,
+// 5 записей об использовании ТС, 10 записей о местах и одно событие
+func buildDriverDDD() []byte {
+	var out []byte
+	out = append(out, tlv([]byte{0x00, 0x02, 0x00}, make([]byte, 25))...)
+	out = append(out, tlv([]byte{0x00, 0x05, 0x00}, []byte{1, 2, 3, 4, 5, 6, 7, 8})...)
+	out = append(out, tlv([]byte{0x05, 0x01, 0x00}, []byte{0x01, 0x00, 0x02, 0x06, 0x00, 0x0C, 0x00, 0x18, 0x00, 0x70})...)
+	ident := make([]byte, 143)
+	ident[0] = 0x11
+	copy(ident[1:], strField("D1234567890", 16))
+	copy(ident[65:], append([]byte{0x01}, strField("DOE", 35)...))
+	copy(ident[101:], append([]byte{0x01}, strField("JOHN", 35)...))
+	copy(ident[137:], []byte{0x19, 0x80, 0x01, 0x02})
+	out = append(out, tlv([]byte{0x05, 0x20, 0x00}, ident)...)
+	out = append(out, tlv([]byte{0x05, 0x20, 0x01}, make([]byte, 128))...)
+	out = append(out, tlv([]byte{0x05, 0x0E, 0x00}, ts(day0))...)
+	out = append(out, tlv([]byte{0x05, 0x21, 0x00}, make([]byte, 53))...)
+	ev := make([]byte, 24*12*6)
+	copy(ev[24:], append([]byte{0x02}, ts(day0.Add(8*time.Hour))...))
+	out = append(out, tlv([]byte{0x05, 0x02, 0x00}, ev)...)
+	out = append(out, tlv([]byte{0x05, 0x03, 0x00}, make([]byte, 24*24))...)
+	out = append(out, tlv([]byte{0x05, 0x04, 0x00}, buildActivity(5))...)
+	out = append(out, tlv([]byte{0x05, 0x05, 0x00}, buildVehicles(5))...)
+	out = append(out, tlv([]byte{0x05, 0x06, 0x00}, buildPlaces(10))...)
+	out = append(out, tlv([]byte{0x05, 0x07, 0x00}, make([]byte, 19))...)
+	out = append(out, tlv([]byte{0x05, 0x08, 0x00}, make([]byte, 46))...)
+	out = append(out, tlv([]byte{0x05, 0x22, 0x00}, make([]byte, 280))...)
+	return out
+}
+
+// Понедельник, с которого начинаются данные тестов анализаторов режима труда и отдыха
+var monday = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Функция формирует суточную запись из изменений деятельности {вид, минута начала}
+func dayRecord(date time.Time, changes ...[2]int) ActivityDailyRecord {
+	acis := []ActivityChangeInfo{}
+	for _, c := range changes {
+		acis = append(acis, ActivityChangeInfo{ActivityKindId: ActivityKind(c[0]), ActivityChangeInfoT: c[1]})
+	}
+	r := ActivityDailyRecord{ActivityRecordDate: date, ActivityChangeInfos: acis}
+	r.ActivityIntervals = buildActivityIntervals(date, acis)
+	r.ActivityTotals = calcActivityTotals(r.ActivityIntervals)
+	return r
+}
+
+// Функция формирует суточные записи за days дней начиная с begin: первые workdays дней
+// каждой недели содержат изменения деятельности changes, остальные - только отдых
+func workDays(begin time.Time, days int, workdays int, changes ...[2]int) ActivityDailyRecords {
+	records := ActivityDailyRecords{}
+	for d := 0; d < days; d++ {
+		date := begin.AddDate(0, 0, d)
+		if d%7 >= workdays {
+			records = append(records, dayRecord(date, [2]int{0, 0}))
+			continue
+		}
+		records = append(records, dayRecord(date, changes...))
+	}
+	return records
+}
+
+// Ожидаемые значения нарушения правила
+type expectedInfringement struct {
+	actual   int
+	severity Severity
+}
+
+// Функция проверяет количество нарушений каждого правила и значения первого нарушения правил из details
+func checkInfringements(t *testing.T, name string, report ComplianceReport, counts map[string]int,
+	details map[string]expectedInfringement) {
+	t.Helper()
+	got := map[string]int{}
+	for _, i := range report.Infringements {
+		got[i.Rule]++
+	}
+	if !reflect.DeepEqual(got, counts) {
+		t.Errorf("%s: expected infringements %v, got %v", name, counts, got)
+	}
+
+	for rule, expected := range details {
+		for _, i := range report.Infringements {
+			if i.Rule != rule {
+				continue
+			}
+			if i.Actual != expected.actual || i.Severity != expected.severity {
+				t.Errorf("%s: %s expected %d %s, got %d %s", name, rule, expected.actual, expected.severity,
+					i.Actual, i.Severity)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package ddd
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Сведения о стране по коду NationNumeric приложения 1B: коды ISO 3166-1 и
+// отличительный знак транспортных средств в международном движении
+type NationInfo struct {
+	Alpha2 string `json:"alpha2"`
+	Alpha3 string `json:"alpha3"`
+	Sign   string `json:"sign"`
+	Name   string `json:"name"`
+}
+
+// Сведения о регионе по коду RegionNumeric приложения 1B: код ISO 3166-2 и название.
+// Регионы определены только для Испании.
+type RegionInfo struct {
+	Code string `json:"code"`
+	Sign string `json:"sign"`
+	Name string `json:"name"`
+}
+
+// Код Испании в NationNumeric
+const nationSpain = 0x0F
+
+var nations = map[int]NationInfo{
+	0x01: {"AT", "AUT", "A", "Austria"},
+	0x02: {"AL", "ALB", "AL", "Albania"},
+	0x03: {"AD", "AND", "AND", "Andorra"},
+	0x04: {"AM", "ARM", "ARM", "Armenia"},
+	0x05: {"AZ", "AZE", "AZ", "Azerbaijan"},
+	0x06: {"BE", "BEL", "B", "Belgium"},
+	0x07: {"BG", "BGR", "BG", "Bulgaria"},
+	0x08: {"BA", "BIH", "BIH", "Bosnia and Herzegovina"},
+	0x09: {"BY", "BLR", "BY", "Belarus"},
+	0x0A: {"CH", "CHE", "CH", "Switzerland"},
+	0x0B: {"CY", "CYP", "CY", "Cyprus"},
+	0x0C: {"CZ", "CZE", "CZ", "Czech Republic"},
+	0x0D: {"DE", "DEU", "D", "Germany"},
+	0x0E: {"DK", "DNK", "DK", "Denmark"},
+	0x0F: {"ES", "ESP", "E", "Spain"},
+	0x10: {"EE", "EST", "EST", "Estonia"},
+	0x11: {"FR", "FRA", "F", "France"},
+	0x12: {"FI", "FIN", "FIN", "Finland"},
+	0x13: {"LI", "LIE", "FL", "Liechtenstein"},
+	0x14: {"FO", "FRO", "FR", "Faroe Islands"},
+	0x15: {"GB", "GBR", "UK", "United Kingdom"},
+	0x16: {"GE", "GEO", "GE", "Georgia"},
+	0x17: {"GR", "GRC", "GR", "Greece"},
+	0x18: {"HU", "HUN", "H", "Hungary"},
+	0x19: {"HR", "HRV", "HR", "Croatia"},
+	0x1A: {"IT", "ITA", "I", "Italy"},
+	0x1B: {"IE", "IRL", "IRL", "Ireland"},
+	0x1C: {"IS", "ISL", "IS", "Iceland"},
+	0x1D: {"KZ", "KAZ", "KZ", "Kazakhstan"},
+	0x1E: {"LU", "LUX", "L", "Luxembourg"},
+	0x1F: {"LT", "LTU", "LT", "Lithuania"},
+	0x20: {"LV", "LVA", "LV", "Latvia"},
+	0x21: {"MT", "MLT", "M", "Malta"},
+	0x22: {"MC", "MCO", "MC", "Monaco"},
+	0x23: {"MD", "MDA", "MD", "Moldova"},
+	0x24: {"MK", "MKD", "MK", "North Macedonia"},
+	0x25: {"NO", "NOR", "N", "Norway"},
+	0x26: {"NL", "NLD", "NL", "Netherlands"},
+	0x27: {"PT", "PRT", "P", "Portugal"},
+	0x28: {"PL", "POL", "PL", "Poland"},
+	0x29: {"RO", "ROU", "RO", "Romania"},
+	0x2A: {"SM", "SMR", "RSM", "San Marino"},
+	0x2B: {"RU", "RUS", "RUS", "Russian Federation"},
+	0x2C: {"SE", "SWE", "S", "Sweden"},
+	0x2D: {"SK", "SVK", "SK", "Slovakia"},
+	0x2E: {"SI", "SVN", "SLO", "Slovenia"},
+	0x2F: {"TM", "TKM", "TM", "Turkmenistan"},
+	0x30: {"TR", "TUR", "TR", "Turkey"},
+	0x31: {"UA", "UKR", "UA", "Ukraine"},
+	0x32: {"VA", "VAT", "V", "Vatican City"},
+	0x33: {"YU", "YUG", "YU", "Yugoslavia"},
+	0x34: {"ME", "MNE", "MNE", "Montenegro"},
+	0x35: {"RS", "SRB", "SRB", "Serbia"},
+	0x36: {"UZ", "UZB", "UZ", "Uzbekistan"},
+	0x37: {"TJ", "TJK", "TJ", "Tajikistan"},
+	0x38: {"KG", "KGZ", "KG", "Kyrgyz Republic"},
+	// группы стран не имеют кодов ISO
+	0xFD: {"", "", "EC", "European Community"},
+	0xFE: {"", "", "EUR", "Rest of Europe"},
+	0xFF: {"", "", "WLD", "Rest of the world"},
+}
+
+var spainRegions = map[int]RegionInfo{
+	0x01: {"ES-AN", "AN", "Andalucía"},
+	0x02: {"ES-AR", "AR", "Aragón"},
+	0x03: {"ES-AS", "AST", "Asturias"},
+	0x04: {"ES-CB", "C", "Cantabria"},
+	0x05: {"ES-CT", "CAT", "Cataluña"},
+	0x06: {"ES-CL", "CL", "Castilla-León"},
+	0x07: {"ES-CM", "CM", "Castilla-La-Mancha"},
+	0x08: {"ES-VC", "CV", "Valencia"},
+	0x09: {"ES-EX", "EXT", "Extremadura"},
+	0x0A: {"ES-GA", "G", "Galicia"},
+	0x0B: {"ES-IB", "IB", "Baleares"},
+	0x0C: {"ES-CN", "IC", "Canarias"},
+	0x0D: {"ES-RI", "LR", "La Rioja"},
+	0x0E: {"ES-MD", "M", "Madrid"},
+	0x0F: {"ES-MC", "MU", "Murcia"},
+	0x10: {"ES-NC", "NA", "Navarra"},
+	0x11: {"ES-PV", "PV", "País Vasco"},
+}
+
+// Функция возвращает сведения о стране по коду NationNumeric.
+// Для кода 0 (нет информации) и неизвестных кодов возвращается false.
+func LookupNation(code int) (NationInfo, bool) {
+	n, ok := nations[code]
+	return n, ok
+}
+
+// Функция возвращает сведения о регионе по коду RegionNumeric. Регионы определены
+// только для Испании, поэтому для других стран (nation) возвращается false.
+func LookupRegion(nation int, code int) (RegionInfo, bool) {
+	if nation != nationSpain {
+		return RegionInfo{}, false
+	}
+	r, ok := spainRegions[code]
+	return r, ok
+}
+
+// Функция заполняет поля с подсказкой `iso` сведениями о стране или регионе.
+// Формат подсказки: `iso:"<поле с кодом страны>"` для поля *NationInfo и
+// `iso:"<поле с кодом региона> <поле с кодом страны>"` для поля *RegionInfo.
+func fillIsoCodes(structVal reflect.Value) {
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		config := strings.Fields(structType.Field(i).Tag.Get("iso"))
+		if len(config) == 0 {
+			continue
+		}
+
+		field := structVal.Field(i)
+		code := int(structVal.FieldByName(config[0]).Int())
+		switch field.Interface().(type) {
+		case *NationInfo:
+			if n, ok := LookupNation(code); ok {
+				field.Set(reflect.ValueOf(&n))
+			}
+		case *RegionInfo:
+			if len(config) < 2 {
+				continue
+			}
+			if r, ok := LookupRegion(int(structVal.FieldByName(config[1]).Int()), code); ok {
+				field.Set(reflect.ValueOf(&r))
+			}
+		}
+	}
+}
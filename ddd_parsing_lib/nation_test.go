@@ -0,0 +1,55 @@
+package ddd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNationIsoCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		nation   int
+		regionId int
+		expected *NationInfo
+		region   *RegionInfo
+	}{
+		{"gen1 code", 0x2B, 0, &NationInfo{"RU", "RUS", "RUS", "Russian Federation"}, nil},
+		{"european community", 0xFD, 0, &NationInfo{"", "", "EC", "European Community"}, nil},
+		{"rest of europe", 0xFE, 0, &NationInfo{"", "", "EUR", "Rest of Europe"}, nil},
+		{"rest of the world", 0xFF, 0, &NationInfo{"", "", "WLD", "Rest of the world"}, nil},
+		{"spanish region", 0x0F, 0x05, &NationInfo{"ES", "ESP", "E", "Spain"}, &RegionInfo{"ES-CT", "CAT", "Cataluña"}},
+		{"region outside spain", 0x0D, 0x05, &NationInfo{"DE", "DEU", "D", "Germany"}, nil},
+		{"no information", 0x00, 0, nil, nil},
+		{"unknown code", 0x80, 0x30, nil, nil},
+	}
+	for _, tt := range tests {
+		p := PlaceRecord{DailyWorkPeriodCountry: tt.nation, DailyWorkPeriodRegion: tt.regionId}
+		fillIsoCodes(reflect.ValueOf(&p).Elem())
+		if !reflect.DeepEqual(p.DailyWorkPeriodCountryIso, tt.expected) {
+			t.Errorf("%s: got nation %+v, expected %+v", tt.name, p.DailyWorkPeriodCountryIso, tt.expected)
+		}
+		if !reflect.DeepEqual(p.DailyWorkPeriodRegionIso, tt.region) {
+			t.Errorf("%s: got region %+v, expected %+v", tt.name, p.DailyWorkPeriodRegionIso, tt.region)
+		}
+	}
+}
+
+func TestNationIsoJson(t *testing.T) {
+	var p PlaceRecord
+	data := []byte{0, 0, 0, 0, 0, 0x0D, 0x05, 0, 0, 0}
+	if err := loadFields(&p, map[string][]byte{"0506": data}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(p)
+	expected := `"daily_work_period_country_iso":{"alpha2":"DE","alpha3":"DEU","sign":"D","name":"Germany"}`
+	if !strings.Contains(string(b), expected) || strings.Contains(string(b), "region_iso") {
+		t.Errorf("unexpected place record JSON %s", b)
+	}
+
+	c, _ := ParseBytes(buildDriverDDD())
+	if c.Card.CardIssuingMemberStateIso == nil || c.Card.CardIssuingMemberStateIso.Alpha2 != "FR" {
+		t.Errorf("unexpected card issuing member state %+v", c.Card.CardIssuingMemberStateIso)
+	}
+}
@@ -0,0 +1,30 @@
+package ddd
+
+import (
+	"crypto/rsa"
+)
+
+// Настройки разбора ddd файла
+type ParseOptions struct {
+	// Открытый ключ европейского корневого центра сертификации (ERCA), используется для
+	// проверки подписей ЕСТР карт первого поколения. Если ключ не задан, подписи не проверяются.
+	EuropeanRootKey *rsa.PublicKey
+	// Открытый ключ доверенного корневого центра для проверки подписей карт СКЗИ (ГОСТ Р 34.10).
+	// Если ключ не задан, подписи карт СКЗИ не проверяются.
+	GostRootKey *GostPublicKey
+	// Набор правил проверки режима труда и отдыха (RulesetEU561, RulesetRU424). Если задан,
+	// отчет о нарушениях по данным о деятельности водителя записывается в Card.Compliance.
+	Ruleset Ruleset
+	// Настройки проверки по приказу Минтранса России № 424 для набора правил RulesetRU424.
+	// Если не заданы, используются нормы по умолчанию.
+	RU424 *RU424Options
+	// Настройки расчета рабочего времени по директиве 2002/15/EC. Если заданы,
+	// отчет о рабочем времени записывается в Card.WorkingTime.
+	WorkingTime *WorkingTimeOptions
+	// Настройки распределения деятельности водителя по странам и суткам (используется ночное время).
+	// Если заданы, отчет записывается в Card.CountryDays.
+	CountryDays *WorkingTimeOptions
+	// Проверка согласованности данных карты (признаки вмешательства). Если задана,
+	// отчет о найденных несоответствиях записывается в Card.Consistency.
+	Consistency bool
+}
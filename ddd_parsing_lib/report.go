@@ -0,0 +1,224 @@
+package ddd
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Статус обработки секции (EF) ddd файла
+type SectionStatus string
+
+const (
+	SectionDecoded  SectionStatus = "decoded"   // секция разобрана полностью
+	SectionPartial  SectionStatus = "partial"   // секция разобрана частично
+	SectionSkipped  SectionStatus = "skipped"   // секция есть в файле, но не разобрана
+	SectionNotFound SectionStatus = "not_found" // секции нет в файле
+)
+
+// Результат проверки подписи секции
+type SignatureStatus string
+
+const (
+	SignatureValid       SignatureStatus = "valid"        // подпись верна
+	SignatureInvalid     SignatureStatus = "invalid"      // подпись неверна
+	SignatureMissing     SignatureStatus = "missing"      // подписи нет в файле
+	SignatureNotVerified SignatureStatus = "not_verified" // подпись не проверена, например, из-за ошибки в сертификатах
+	SignatureUnsupported SignatureStatus = "unsupported"  // алгоритм подписи или ключа не поддерживается
+)
+
+// Названия секций (EF) карты по приложению 1B
+var cardSectionNames = map[string]string{
+	"0002": "EF_ICC",
+	"0005": "EF_IC",
+	"0501": "EF_Application_Identification",
+	"0502": "EF_Events_Data",
+	"0503": "EF_Faults_Data",
+	"0504": "EF_Driver_Activity_Data",
+	"0505": "EF_Vehicles_Used",
+	"0506": "EF_Places",
+	"0507": "EF_Current_Usage",
+	"0508": "EF_Control_Activity_Data",
+	"050E": "EF_Card_Download",
+	"0520": "EF_Identification",
+	"0521": "EF_Driving_Licence_Info",
+	"0522": "EF_Specific_Conditions",
+	"C100": "EF_Card_Certificate",
+	"C108": "EF_CA_Certificate",
+	"C200": "EF_Card_Certificate_GOST",
+	"C208": "EF_CA_Certificate_GOST",
+}
+
+// Результат обработки одной секции ddd файла
+type SectionReport struct {
+	Tag    string        `json:"tag"`
+	Name   string        `json:"name"`
+	Found  bool          `json:"found"`
+	Status SectionStatus `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	// результат проверки подписи, заполняется если проверка подписей включена
+	Signature       SignatureStatus `json:"signature,omitempty"`
+	SignatureReason string          `json:"signature_reason,omitempty"`
+}
+
+// Отчет о разборе ddd файла по секциям
+type ParseReport struct {
+	Sections []SectionReport `json:"sections"`
+}
+
+// Метод возвращает отчет по секции с тэгом tag
+func (r *ParseReport) Section(tag string) (SectionReport, bool) {
+	for _, s := range r.Sections {
+		if s.Tag == tag {
+			return s, true
+		}
+	}
+	return SectionReport{}, false
+}
+
+// Метод проверяет, что все найденные в файле секции разобраны полностью
+func (r *ParseReport) IsComplete() bool {
+	for _, s := range r.Sections {
+		if s.Found && s.Status != SectionDecoded {
+			return false
+		}
+	}
+	return true
+}
+
+// порядок статусов, более "плохой" статус не перезаписывается более "хорошим"
+var sectionStatusRank = map[SectionStatus]int{
+	SectionNotFound: 0,
+	SectionDecoded:  1,
+	SectionPartial:  2,
+	SectionSkipped:  3,
+}
+
+// Метод устанавливает статус секции. Если у секции уже указан более "плохой" статус,
+// то он сохраняется.
+func (r *ParseReport) setStatus(tag string, status SectionStatus, reason string) {
+	// части блоков ВБУ хранятся под тэгами вида <блок>:<часть>
+	tag = strings.SplitN(tag, ":", 2)[0]
+	for i := range r.Sections {
+		s := &r.Sections[i]
+		if s.Tag != tag {
+			continue
+		}
+		// для отсутствующих секций статус не меняется
+		if !s.Found {
+			return
+		}
+		if sectionStatusRank[status] >= sectionStatusRank[s.Status] {
+			s.Status = status
+			if reason != "" {
+				s.Reason = reason
+			}
+		}
+		return
+	}
+}
+
+// Метод записывает результат проверки подписи секции
+// Отсутствие подписи не перезаписывает результат, полученный другим алгоритмом проверки.
+func (r *ParseReport) setSignature(tag string, status SignatureStatus, reason string) {
+	for i := range r.Sections {
+		if r.Sections[i].Tag == tag {
+			if status == SignatureMissing && r.Sections[i].Signature != "" {
+				return
+			}
+			r.Sections[i].Signature = status
+			r.Sections[i].SignatureReason = reason
+			return
+		}
+	}
+}
+
+// Метод проверяет, что все проверенные подписи секций верны
+func (r *ParseReport) SignaturesValid() bool {
+	for _, s := range r.Sections {
+		if s.Signature != "" && s.Signature != SignatureValid {
+			return false
+		}
+	}
+	return true
+}
+
+// Функция объединяет несколько списков названий секций
+func mergeSectionNames(names ...map[string]string) map[string]string {
+	if len(names) == 1 {
+		return names[0]
+	}
+
+	result := map[string]string{}
+	for _, n := range names {
+		for tag, name := range n {
+			result[tag] = name
+		}
+	}
+	return result
+}
+
+// Функция создает отчет по известным секциям (names) и секциям, найденным в файле
+func newParseReport(names map[string]string, tlvRecords map[string][]byte) ParseReport {
+	report := ParseReport{}
+
+	var tags []string
+	for tag := range names {
+		tags = append(tags, tag)
+	}
+	for tag := range tlvRecords {
+		if _, ok := names[tag]; !ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		s := SectionReport{Tag: tag, Name: names[tag], Status: SectionNotFound}
+		if _, ok := tlvRecords[tag]; ok {
+			s.Found = true
+			if s.Name == "" {
+				s.Status = SectionSkipped
+				s.Reason = "unknown section"
+			} else {
+				s.Status = SectionDecoded
+			}
+		}
+		report.Sections = append(report.Sections, s)
+	}
+
+	return report
+}
+
+// Метод отмечает в отчете результат загрузки группы секций. target - структура или
+// список записей, в которые загружались данные, err - ошибка загрузки.
+func (r *ParseReport) addLoadResult(tags []string, target interface{}, err error) {
+	if err == nil {
+		return
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	emptyList := v.Kind() == reflect.Slice && v.Len() == 0
+
+	for _, e := range splitErrors(err) {
+		tag := errorTag(e)
+		if tag == "" && len(tags) == 1 {
+			tag = tags[0]
+		}
+
+		if tag == "" {
+			for _, t := range tags {
+				r.setStatus(t, SectionPartial, e.Error())
+			}
+			continue
+		}
+
+		// если из секции не загружено ни одной записи, то она считается пропущенной,
+		// ошибка в части блока (тэг вида <блок>:<часть>) делает блок неполным
+		status := SectionPartial
+		if emptyList && !strings.Contains(tag, ":") {
+			status = SectionSkipped
+		}
+		r.setStatus(tag, status, e.Error())
+	}
+}
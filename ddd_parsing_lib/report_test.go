@@ -0,0 +1,67 @@
+package ddd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseReport(t *testing.T) {
+	c, err := ParseBytes(buildDriverDDD())
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if !c.Report.IsComplete() {
+		t.Errorf("expected complete report, got %+v", c.Report)
+	}
+	tests := []struct {
+		tag    string
+		found  bool
+		status SectionStatus
+	}{
+		{tag: "0504", found: true, status: SectionDecoded},
+		{tag: "0520", found: true, status: SectionDecoded},
+		{tag: "C100", found: false, status: SectionNotFound},
+	}
+	for _, tt := range tests {
+		s, ok := c.Report.Section(tt.tag)
+		if !ok || s.Found != tt.found || s.Status != tt.status {
+			t.Errorf("section %s: expected %v %s, got %+v", tt.tag, tt.found, tt.status, s)
+		}
+	}
+}
+
+func TestParseReportTruncated(t *testing.T) {
+	ddd := buildDriverDDD()
+	vehicles := bytes.Index(ddd, []byte{0x05, 0x05, 0x00})
+	c, err := ParseBytes(ddd[:vehicles+5+2+31*2+10])
+	if err == nil {
+		t.Fatalf("expected error for truncated file")
+	}
+	if c.Report.IsComplete() {
+		t.Errorf("expected incomplete report")
+	}
+	// данные до обрезанной секции разобраны полностью
+	if len(c.ActivityDailyRecords) != 5 {
+		t.Errorf("expected 5 activity records, got %d", len(c.ActivityDailyRecords))
+	}
+	expected := map[string]SectionStatus{"0504": SectionDecoded, "0506": SectionNotFound}
+	for tag, status := range expected {
+		if s, _ := c.Report.Section(tag); s.Status != status {
+			t.Errorf("section %s: expected %s, got %+v", tag, status, s)
+		}
+	}
+	if s, _ := c.Report.Section("0505"); s.Status == SectionDecoded || s.Reason == "" {
+		t.Errorf("section 0505: expected partial or skipped with reason, got %+v", s)
+	}
+}
+
+func TestParseReportUnknownSection(t *testing.T) {
+	c, err := ParseBytes(append(buildDriverDDD(), tlv([]byte{0x7F, 0x01, 0x00}, []byte{1, 2, 3})...))
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	s, ok := c.Report.Section("7F01")
+	if !ok || !s.Found || s.Status != SectionSkipped || s.Reason != "unknown section" {
+		t.Errorf("unexpected unknown section report %+v", s)
+	}
+}
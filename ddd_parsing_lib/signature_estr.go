@@ -0,0 +1,160 @@
+package ddd
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Размеры элементов сертификата ЕСТР по приложению 1B (дополнение 11)
+const (
+	estrKeyIdLen       = 8
+	estrModulusLen     = 128
+	estrExponentLen    = 8
+	estrRootKeyLen     = estrKeyIdLen + estrModulusLen + estrExponentLen
+	estrSignatureLen   = 128
+	estrCertificateLen = 194
+	estrCnLen          = 58
+	estrHashLen        = 20
+	// смещения ключа в восстановленном содержимом сертификата C':
+	// CPI(1) CAR(8) CHA(7) EOV(4) CHR(8) n(128) e(8)
+	estrCertModulusOffset  = 28
+	estrCertExponentOffset = estrCertModulusOffset + estrModulusLen
+)
+
+// Секции карты первого поколения, данные которых подписываются картой
+var gen1SignedSections = []string{
+	"0501", "0502", "0503", "0504", "0505", "0506", "0507", "0508", "0509", "050A", "050B", "050C", "050E",
+	"0520", "0521", "0522",
+}
+
+// Функция разбирает открытый ключ европейского корневого центра (файл EC_PK):
+// идентификатор ключа (8 байт), модуль (128 байт) и экспоненту (8 байт).
+func ParseEuropeanRootKey(ecpk []byte) (*rsa.PublicKey, error) {
+	if len(ecpk) != estrRootKeyLen {
+		return nil, fmt.Errorf("Invalid european root key length: %d", len(ecpk))
+	}
+
+	return rsaPublicKey(ecpk[estrKeyIdLen:estrKeyIdLen+estrModulusLen], ecpk[estrKeyIdLen+estrModulusLen:])
+}
+
+// Функция создает открытый ключ RSA из модуля и экспоненты
+func rsaPublicKey(modulus []byte, exponent []byte) (*rsa.PublicKey, error) {
+	e := new(big.Int).SetBytes(exponent)
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("Invalid RSA exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(e.Int64())}, nil
+}
+
+// Функция восстанавливает содержимое сертификата ЕСТР (подпись по ISO/IEC 9796-2 с частичным
+// восстановлением сообщения) ключом издателя и возвращает содержимое сертификата C'.
+func recoverEstrCertificate(cert []byte, issuer *rsa.PublicKey) ([]byte, error) {
+	if len(cert) != estrCertificateLen {
+		return nil, fmt.Errorf("Invalid certificate length: %d", len(cert))
+	}
+
+	sign := cert[:estrSignatureLen]
+	cn := cert[estrSignatureLen : estrSignatureLen+estrCnLen]
+
+	s := new(big.Int).SetBytes(sign)
+	if s.Cmp(issuer.N) >= 0 {
+		return nil, errors.New("Certificate signature is out of range")
+	}
+	sr := new(big.Int).Exp(s, big.NewInt(int64(issuer.E)), issuer.N).Bytes()
+
+	// Sr = 6A || Cr' || H' || BC
+	if len(sr) != estrSignatureLen || sr[0] != 0x6A || sr[len(sr)-1] != 0xBC {
+		return nil, errors.New("Invalid certificate signature format")
+	}
+	cr := sr[1 : len(sr)-1-estrHashLen]
+	hash := sr[len(sr)-1-estrHashLen : len(sr)-1]
+
+	content := append(append([]byte{}, cr...), cn...)
+	sum := sha1.Sum(content)
+	if !bytes.Equal(sum[:], hash) {
+		return nil, errors.New("Certificate hash mismatch")
+	}
+
+	return content, nil
+}
+
+// Функция возвращает открытый ключ владельца из восстановленного содержимого сертификата
+func estrCertificateKey(content []byte) (*rsa.PublicKey, error) {
+	return rsaPublicKey(content[estrCertModulusOffset:estrCertExponentOffset],
+		content[estrCertExponentOffset:estrCertExponentOffset+estrExponentLen])
+}
+
+// Функция восстанавливает сертификат ключом издателя и возвращает ключ владельца
+func recoverEstrCertificateKey(cert []byte, issuer *rsa.PublicKey) (*rsa.PublicKey, error) {
+	content, err := recoverEstrCertificate(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	return estrCertificateKey(content)
+}
+
+// Функция проверяет цепочку сертификатов ЕСТР: сертификат государства-члена (C108)
+// ключом ERCA и сертификат карты (C100) ключом государства-члена.
+// Возвращает открытый ключ карты.
+func verifyEstrChain(tlvRecords map[string][]byte, root *rsa.PublicKey, report *ParseReport) (*rsa.PublicKey, error) {
+	caCert, ok := tlvRecords["C108"]
+	if !ok {
+		return nil, errors.New("CA certificate not found")
+	}
+	cardCert, ok := tlvRecords["C100"]
+	if !ok {
+		return nil, errors.New("Card certificate not found")
+	}
+
+	caKey, err := recoverEstrCertificateKey(caCert, root)
+	if err != nil {
+		report.setSignature("C108", SignatureInvalid, err.Error())
+		return nil, fmt.Errorf("CA certificate: %v", err)
+	}
+	report.setSignature("C108", SignatureValid, "")
+
+	cardKey, err := recoverEstrCertificateKey(cardCert, caKey)
+	if err != nil {
+		report.setSignature("C100", SignatureInvalid, err.Error())
+		return nil, fmt.Errorf("Card certificate: %v", err)
+	}
+	report.setSignature("C100", SignatureValid, "")
+
+	return cardKey, nil
+}
+
+// Функция проверяет подписи ЕСТР секций карты первого поколения и записывает
+// результат в отчет. Подпись секции - RSA PKCS#1 v1.5 от хэша SHA-1 данных секции.
+func verifyEstrSignatures(tlvRecords map[string][]byte, signatures map[string][]byte,
+	root *rsa.PublicKey, report *ParseReport) {
+	cardKey, chainErr := verifyEstrChain(tlvRecords, root, report)
+
+	for _, tag := range gen1SignedSections {
+		data, ok := tlvRecords[tag]
+		if !ok {
+			continue
+		}
+
+		sign, ok := signatures[tag+"01"]
+		switch {
+		case !ok:
+			report.setSignature(tag, SignatureMissing, "")
+		case chainErr != nil:
+			report.setSignature(tag, SignatureNotVerified, chainErr.Error())
+		default:
+			hash := sha1.Sum(data)
+			if err := rsa.VerifyPKCS1v15(cardKey, crypto.SHA1, hash[:], sign); err != nil {
+				report.setSignature(tag, SignatureInvalid, err.Error())
+			} else {
+				report.setSignature(tag, SignatureValid, "")
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package ddd
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/binary"
+	"math/big"
+	"testing"
+)
+
+// Функция возвращает модуль и экспоненту ключа в формате сертификата ЕСТР
+func estrKeyBytes(k *rsa.PublicKey) ([]byte, []byte) {
+	n := make([]byte, 128)
+	k.N.FillBytes(n)
+	e := make([]byte, 8)
+	binary.BigEndian.PutUint64(e, uint64(k.E))
+	return n, e
+}
+
+// Функция формирует сертификат ЕСТР ключа subject, подписанный ключом issuer
+// по ISO/IEC 9796-2 с частичным восстановлением сообщения
+func estrCert(issuer *rsa.PrivateKey, subject *rsa.PublicKey) []byte {
+	n, e := estrKeyBytes(subject)
+	c := make([]byte, 28)
+	c[0] = 1
+	copy(c[1:9], []byte{0x11, 'R', 'U', 'S', 1, 0, 0, 1})
+	copy(c[9:16], []byte{0xFF, 'T', 'A', 'C', 'H', 'O', 1})
+	copy(c[16:20], []byte{0x70, 0, 0, 0})
+	copy(c[20:28], []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	c = append(c, n...)
+	c = append(c, e...)
+	h := sha1.Sum(c)
+	sr := []byte{0x6A}
+	sr = append(sr, c[:106]...)
+	sr = append(sr, h[:]...)
+	sr = append(sr, 0xBC)
+	s := new(big.Int).Exp(new(big.Int).SetBytes(sr), issuer.D, issuer.N)
+	sign := make([]byte, 128)
+	s.FillBytes(sign)
+	return append(append(sign, c[106:]...), c[1:9]...)
+}
+
+func genKey(t *testing.T) *rsa.PrivateKey {
+	k, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// Функция формирует файл карты водителя, подписанный ключом карты, с цепочкой сертификатов
+// до сгенерированного корневого ключа. При tamper данные секции 0504 изменяются после подписи.
+func buildSignedDDD(t *testing.T, tamper bool) ([]byte, *rsa.PublicKey) {
+	erca, ms, card := genKey(t), genKey(t), genKey(t)
+	src := buildDriverDDD()
+	var out []byte
+	for off := 0; off+5 <= len(src); {
+		tag := src[off : off+3]
+		l := int(binary.BigEndian.Uint16(src[off+3 : off+5]))
+		val := append([]byte{}, src[off+5:off+5+l]...)
+		off += 5 + l
+		if tag[2] == 0x01 {
+			continue
+		}
+		h := sha1.Sum(val)
+		sig, _ := rsa.SignPKCS1v15(nil, card, crypto.SHA1, h[:])
+		if tamper && tag[0] == 0x05 && tag[1] == 0x04 {
+			val[10] ^= 0xFF
+		}
+		out = append(out, tlv(tag, val)...)
+		if tag[0] == 0x05 {
+			out = append(out, tlv([]byte{tag[0], tag[1], 0x01}, sig)...)
+		}
+	}
+	out = append(out, tlv([]byte{0xC1, 0x00, 0x00}, estrCert(ms, &card.PublicKey))...)
+	out = append(out, tlv([]byte{0xC1, 0x08, 0x00}, estrCert(erca, &ms.PublicKey))...)
+	root := make([]byte, 8)
+	n, e := estrKeyBytes(&erca.PublicKey)
+	root = append(append(root, n...), e...)
+	k, err := ParseEuropeanRootKey(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, k
+}
+
+// Функция возвращает отчет по секции
+func sec(r ParseReport, tag string) SectionReport {
+	s, _ := r.Section(tag)
+	return s
+}
+
+func TestVerifyEstrSignatures(t *testing.T) {
+	f, root := buildSignedDDD(t, false)
+	c, err := ParseBytesWithOptions(f, ParseOptions{EuropeanRootKey: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range c.Report.Sections {
+		if s.Found && s.Tag != "0002" && s.Tag != "0005" && s.Signature != SignatureValid {
+			t.Errorf("%s: %s %s", s.Tag, s.Signature, s.SignatureReason)
+		}
+	}
+	if !c.Report.SignaturesValid() {
+		t.Error("expected all signatures to be valid")
+	}
+
+	f, root = buildSignedDDD(t, true)
+	c, _ = ParseBytesWithOptions(f, ParseOptions{EuropeanRootKey: root})
+	if sec(c.Report, "0504").Signature != SignatureInvalid || sec(c.Report, "0505").Signature != SignatureValid {
+		t.Errorf("tampered section: %+v", sec(c.Report, "0504"))
+	}
+
+	// цепочка сертификатов не проверяется другим корневым ключом
+	f, _ = buildSignedDDD(t, false)
+	c, _ = ParseBytesWithOptions(f, ParseOptions{EuropeanRootKey: root})
+	if sec(c.Report, "C108").Signature != SignatureInvalid || sec(c.Report, "0504").Signature != SignatureNotVerified {
+		t.Errorf("wrong root: %+v %+v", sec(c.Report, "C108"), sec(c.Report, "0504"))
+	}
+	// без корневого ключа подписи не проверяются
+	c, _ = ParseBytes(f)
+	if sec(c.Report, "0504").Signature != "" {
+		t.Error("signatures verified without root key")
+	}
+}
+
+func TestDecodeEstrCertificates(t *testing.T) {
+	f, root := buildSignedDDD(t, false)
+	c, _ := ParseBytesWithOptions(f, ParseOptions{EuropeanRootKey: root})
+	cc := c.Certificates.Card
+	if cc == nil || !cc.Recovered || cc.AuthorityNation != "RUS" || cc.EquipmentType != 1 || cc.EndOfValidity == nil || cc.PublicKeyExponent != 65537 {
+		t.Fatalf("unexpected card certificate %+v", cc)
+	}
+	c, _ = ParseBytes(f)
+	cc = c.Certificates.Card
+	if cc == nil || cc.Recovered || cc.AuthorityNation != "RUS" || cc.EndOfValidity != nil {
+		t.Fatalf("unexpected card certificate %+v", cc)
+	}
+	if !c.Report.IsComplete() {
+		t.Fatalf("incomplete report: %+v", c.Report)
+	}
+}
+
+func TestRecoverEstrCertificate(t *testing.T) {
+	issuer, subject := genKey(t), genKey(t)
+	cert := estrCert(issuer, &subject.PublicKey)
+
+	key, err := recoverEstrCertificateKey(cert, &issuer.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.N.Cmp(subject.N) != 0 || key.E != subject.E {
+		t.Errorf("recovered key differs from the certified key")
+	}
+
+	// изменение открытой части сертификата (Cn') нарушает хэш
+	tampered := append([]byte{}, cert...)
+	tampered[estrSignatureLen] ^= 0xFF
+	if _, err := recoverEstrCertificate(tampered, &issuer.PublicKey); err == nil {
+		t.Error("tampered certificate recovered")
+	}
+	if _, err := recoverEstrCertificate(cert, &subject.PublicKey); err == nil {
+		t.Error("certificate recovered with a wrong issuer key")
+	}
+	if _, err := recoverEstrCertificate(cert[:100], &issuer.PublicKey); err == nil {
+		t.Error("short certificate recovered")
+	}
+}
@@ -0,0 +1,413 @@
+package ddd
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Параметры эллиптической кривой ГОСТ Р 34.10 в форме Вейерштрасса y^2 = x^3 + ax + b (mod p)
+type gostCurve struct {
+	P, A, B, Q, X, Y *big.Int
+}
+
+func gostHex(s string) *big.Int {
+	v, _ := new(big.Int).SetString(s, 16)
+	return v
+}
+
+func newGostCurve(p, b, q, x, y string) *gostCurve {
+	c := &gostCurve{P: gostHex(p), B: gostHex(b), Q: gostHex(q), X: gostHex(x), Y: gostHex(y)}
+	c.A = new(big.Int).Sub(c.P, big.NewInt(3))
+	return c
+}
+
+var (
+	gostCurveCryptoProA = newGostCurve(
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFD97",
+		"A6",
+		"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF6C611070995AD10045841B09B761B893",
+		"1",
+		"8D91E471E0989CDA27DF505A453F2B7635294F2DDF23E3B122ACC99C9E9F1E14")
+	gostCurveCryptoProB = newGostCurve(
+		"8000000000000000000000000000000000000000000000000000000000000C99",
+		"3E1AF419A269A5F866A7D3C25C3DF80AE979259373FF2B182F49D4CE7E1BBC8B",
+		"800000000000000000000000000000015F700CFFF1A624E5E497161BCC8A198F",
+		"1",
+		"3FA8124359F96680B83D1C3EB2C070E5C545C9858D03ECFB744BF8D717717EFC")
+	gostCurveCryptoProC = newGostCurve(
+		"9B9F605F5A858107AB1EC85E6B41C8AACF846E86789051D37998F7B9022D759B",
+		"805A",
+		"9B9F605F5A858107AB1EC85E6B41C8AA582CA3511EDDFB74F02F3A6598980BB9",
+		"0",
+		"41ECE55743711A8C3CBF3783CD08C0EE4D4DC440D4641A8F366E550DFDB3BB67")
+)
+
+// Наборы параметров кривых по OID (RFC 4357, Р 1323565.1.024-2019)
+var gostCurves = map[string]*gostCurve{
+	"1.2.643.2.2.35.1":    gostCurveCryptoProA,
+	"1.2.643.2.2.35.2":    gostCurveCryptoProB,
+	"1.2.643.2.2.35.3":    gostCurveCryptoProC,
+	"1.2.643.2.2.36.0":    gostCurveCryptoProA,
+	"1.2.643.2.2.36.1":    gostCurveCryptoProC,
+	"1.2.643.7.1.2.1.1.2": gostCurveCryptoProA,
+	"1.2.643.7.1.2.1.1.3": gostCurveCryptoProB,
+	"1.2.643.7.1.2.1.1.4": gostCurveCryptoProC,
+}
+
+// Алгоритмы открытого ключа и подписи
+const (
+	oidGostR3410_2001         = "1.2.643.2.2.19"
+	oidGostR3410_2012_256     = "1.2.643.7.1.1.1.1"
+	oidGostR3411_94_R3410     = "1.2.643.2.2.3"
+	oidGostR3411_2012_R3410   = "1.2.643.7.1.1.3.2"
+	oidGostR3411_94_CryptoPro = "1.2.643.2.2.30.1"
+	gostPointLen              = 32
+)
+
+// Ошибка для алгоритмов, проверка которых не поддерживается
+var errGostUnsupported = errors.New("Unsupported GOST algorithm")
+
+// Открытый ключ ГОСТ Р 34.10 длиной 256 бит
+type GostPublicKey struct {
+	X, Y *big.Int
+	// OID алгоритма ключа: ГОСТ Р 34.10-2001 или ГОСТ Р 34.10-2012
+	Algorithm string
+	curve     *gostCurve
+}
+
+// Сложение точек кривой в аффинных координатах, nil - бесконечно удаленная точка
+func (c *gostCurve) add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1 == nil {
+		return x2, y2
+	}
+	if x2 == nil {
+		return x1, y1
+	}
+
+	var num, den *big.Int
+	if x1.Cmp(x2) == 0 {
+		sum := new(big.Int).Add(y1, y2)
+		if sum.Mod(sum, c.P).Sign() == 0 {
+			return nil, nil
+		}
+		// касательная: (3x^2 + a) / 2y
+		num = new(big.Int).Mul(x1, x1)
+		num.Mul(num, big.NewInt(3)).Add(num, c.A)
+		den = new(big.Int).Lsh(y1, 1)
+	} else {
+		num = new(big.Int).Sub(y2, y1)
+		den = new(big.Int).Sub(x2, x1)
+	}
+	den.Mod(den, c.P)
+	l := num.Mul(num, new(big.Int).ModInverse(den, c.P))
+	l.Mod(l, c.P)
+
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1).Sub(x3, x2).Mod(x3, c.P)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l).Sub(y3, y1).Mod(y3, c.P)
+
+	return x3, y3
+}
+
+// Умножение точки кривой на число
+func (c *gostCurve) mul(x, y, k *big.Int) (*big.Int, *big.Int) {
+	var rx, ry *big.Int
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		rx, ry = c.add(rx, ry, rx, ry)
+		if k.Bit(i) == 1 {
+			rx, ry = c.add(rx, ry, x, y)
+		}
+	}
+	return rx, ry
+}
+
+// Проверка принадлежности точки кривой
+func (c *gostCurve) isOnCurve(x, y *big.Int) bool {
+	if x.Sign() < 0 || x.Cmp(c.P) >= 0 || y.Sign() < 0 || y.Cmp(c.P) >= 0 {
+		return false
+	}
+	l := new(big.Int).Mul(y, y)
+	r := new(big.Int).Mul(x, x)
+	r.Mul(r, x).Add(r, new(big.Int).Mul(c.A, x)).Add(r, c.B)
+
+	return l.Sub(l, r).Mod(l, c.P).Sign() == 0
+}
+
+// Функция переворачивает порядок байт (в ГОСТ структурах числа хранятся в little-endian)
+func reverseOctets(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+// Метод проверяет подпись (r, s) хэш-кода digest по ГОСТ Р 34.10.
+// Хэш-код интерпретируется как число в little-endian, как его возвращает функция хэширования.
+func (k *GostPublicKey) verify(digest []byte, r, s *big.Int) bool {
+	c := k.curve
+	if r.Sign() <= 0 || r.Cmp(c.Q) >= 0 || s.Sign() <= 0 || s.Cmp(c.Q) >= 0 {
+		return false
+	}
+
+	e := new(big.Int).SetBytes(reverseOctets(digest))
+	e.Mod(e, c.Q)
+	if e.Sign() == 0 {
+		e.SetInt64(1)
+	}
+	v := new(big.Int).ModInverse(e, c.Q)
+	z1 := new(big.Int).Mul(s, v)
+	z1.Mod(z1, c.Q)
+	z2 := new(big.Int).Mul(r, v)
+	z2.Neg(z2).Mod(z2, c.Q)
+
+	x1, y1 := c.mul(c.X, c.Y, z1)
+	x2, y2 := c.mul(k.X, k.Y, z2)
+	x, _ := c.add(x1, y1, x2, y2)
+	if x == nil {
+		return false
+	}
+
+	return x.Mod(x, c.Q).Cmp(r) == 0
+}
+
+// Функция разбирает подпись ГОСТ Р 34.10: 64 байта s || r, числа в big-endian. Так подпись
+// представлена и в сертификатах (RFC 4491), и в подписях секций карты СКЗИ (PKCS#11, CKM_GOSTR3410).
+func gostSignature(sign []byte) (*big.Int, *big.Int, error) {
+	if len(sign) != 2*gostPointLen {
+		return nil, nil, fmt.Errorf("Invalid signature length: %d", len(sign))
+	}
+	s := new(big.Int).SetBytes(sign[:gostPointLen])
+	r := new(big.Int).SetBytes(sign[gostPointLen:])
+
+	return r, s, nil
+}
+
+// Метод вычисляет хэш-код данных алгоритмом, соответствующим ключу: ГОСТ Р 34.11-94
+// для ключей ГОСТ Р 34.10-2001, ГОСТ Р 34.11-2012 для ключей ГОСТ Р 34.10-2012
+func (k *GostPublicKey) digest(data []byte) []byte {
+	if k.Algorithm == oidGostR3410_2001 {
+		return gostHash94CryptoPro(data)
+	}
+	return streebog256(data)
+}
+
+// Структуры сертификата X.509 с ключом ГОСТ Р 34.10 (RFC 4491)
+type gostCertificate struct {
+	TBSCertificate     asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type gostTBSCertificate struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           gostValidity
+	Subject            asn1.RawValue
+	PublicKey          gostPublicKeyInfo
+	IssuerUniqueId     asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueId    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"optional,explicit,tag:3"`
+}
+
+type gostValidity struct {
+	NotBefore, NotAfter asn1.RawValue
+}
+
+type gostPublicKeyInfo struct {
+	Algorithm gostKeyAlgorithm
+	PublicKey asn1.BitString
+}
+
+type gostKeyAlgorithm struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters gostKeyParameters
+}
+
+type gostKeyParameters struct {
+	PublicKeyParamSet asn1.ObjectIdentifier
+	DigestParamSet    asn1.ObjectIdentifier `asn1:"optional"`
+}
+
+// Функция разбирает сертификат X.509 с ключом ГОСТ. Файл сертификата на карте имеет
+// фиксированный размер, поэтому данные после сертификата игнорируются.
+func parseGostCertificate(der []byte) (*gostCertificate, *gostTBSCertificate, error) {
+	cert := &gostCertificate{}
+	if _, err := asn1.Unmarshal(der, cert); err != nil {
+		return nil, nil, fmt.Errorf("Invalid GOST certificate: %v", err)
+	}
+
+	tbs := &gostTBSCertificate{}
+	if _, err := asn1.Unmarshal(cert.TBSCertificate.FullBytes, tbs); err != nil {
+		return nil, nil, fmt.Errorf("Invalid GOST certificate: %v", err)
+	}
+
+	return cert, tbs, nil
+}
+
+// Метод возвращает открытый ключ из сертификата
+func (tbs *gostTBSCertificate) publicKey() (*GostPublicKey, error) {
+	alg := tbs.PublicKey.Algorithm.Algorithm.String()
+	if alg != oidGostR3410_2001 && alg != oidGostR3410_2012_256 {
+		return nil, fmt.Errorf("%w: public key %s", errGostUnsupported, alg)
+	}
+
+	params := tbs.PublicKey.Algorithm.Parameters
+	curve, ok := gostCurves[params.PublicKeyParamSet.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w: curve %s", errGostUnsupported, params.PublicKeyParamSet)
+	}
+	// для ГОСТ Р 34.11-94 поддерживается только таблица замен КриптоПро
+	if alg == oidGostR3410_2001 && len(params.DigestParamSet) > 0 && params.DigestParamSet.String() != oidGostR3411_94_CryptoPro {
+		return nil, fmt.Errorf("%w: digest parameters %s", errGostUnsupported, params.DigestParamSet)
+	}
+
+	// ключ хранится как OCTET STRING с координатами x и y в little-endian
+	var point []byte
+	if _, err := asn1.Unmarshal(tbs.PublicKey.PublicKey.Bytes, &point); err != nil {
+		return nil, fmt.Errorf("Invalid public key: %v", err)
+	}
+	if len(point) != 2*gostPointLen {
+		return nil, fmt.Errorf("Invalid public key length: %d", len(point))
+	}
+
+	key := &GostPublicKey{
+		X:         new(big.Int).SetBytes(reverseOctets(point[:gostPointLen])),
+		Y:         new(big.Int).SetBytes(reverseOctets(point[gostPointLen:])),
+		Algorithm: alg,
+		curve:     curve,
+	}
+	if !curve.isOnCurve(key.X, key.Y) {
+		return nil, errors.New("Public key is not on curve")
+	}
+
+	return key, nil
+}
+
+// Функция проверяет подпись сертификата ключом издателя и возвращает ключ владельца
+func verifyGostCertificate(der []byte, issuer *GostPublicKey) (*GostPublicKey, error) {
+	cert, tbs, err := parseGostCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	var digest []byte
+	switch alg := cert.SignatureAlgorithm.Algorithm.String(); alg {
+	case oidGostR3411_94_R3410:
+		digest = gostHash94CryptoPro(cert.TBSCertificate.FullBytes)
+	case oidGostR3411_2012_R3410:
+		digest = streebog256(cert.TBSCertificate.FullBytes)
+	default:
+		return nil, fmt.Errorf("%w: signature %s", errGostUnsupported, alg)
+	}
+
+	r, s, err := gostSignature(cert.SignatureValue.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Certificate: %v", err)
+	}
+
+	if !issuer.verify(digest, r, s) {
+		return nil, errors.New("Certificate signature is invalid")
+	}
+
+	return tbs.publicKey()
+}
+
+// Функция разбирает сертификат корневого центра СКЗИ (DER или PEM) и возвращает его ключ.
+// Подпись корневого сертификата не проверяется, он считается доверенным.
+func ParseGostRootCertificate(cert []byte) (*GostPublicKey, error) {
+	if block, _ := pem.Decode(cert); block != nil {
+		cert = block.Bytes
+	}
+
+	_, tbs, err := parseGostCertificate(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return tbs.publicKey()
+}
+
+// Функция возвращает статус подписи для ошибки проверки сертификата
+func gostErrorStatus(err error) SignatureStatus {
+	if errors.Is(err, errGostUnsupported) {
+		return SignatureUnsupported
+	}
+	return SignatureInvalid
+}
+
+// Функция проверяет цепочку сертификатов карты СКЗИ: сертификат УЦ (C208) доверенным
+// корневым ключом и сертификат карты (C200) ключом УЦ. Возвращает открытый ключ карты.
+func verifyGostChain(tlvRecords map[string][]byte, root *GostPublicKey, report *ParseReport) (*GostPublicKey, error) {
+	caCert, ok := tlvRecords["C208"]
+	if !ok {
+		return nil, errors.New("GOST CA certificate not found")
+	}
+	cardCert, ok := tlvRecords["C200"]
+	if !ok {
+		return nil, errors.New("GOST card certificate not found")
+	}
+
+	caKey, err := verifyGostCertificate(caCert, root)
+	if err != nil {
+		report.setSignature("C208", gostErrorStatus(err), err.Error())
+		return nil, fmt.Errorf("GOST CA certificate: %w", err)
+	}
+	report.setSignature("C208", SignatureValid, "")
+
+	cardKey, err := verifyGostCertificate(cardCert, caKey)
+	if err != nil {
+		report.setSignature("C200", gostErrorStatus(err), err.Error())
+		return nil, fmt.Errorf("GOST card certificate: %w", err)
+	}
+	report.setSignature("C200", SignatureValid, "")
+
+	return cardKey, nil
+}
+
+// Функция проверяет подписи секций карты СКЗИ (тэг с признаком 0x81) и записывает
+// результат в отчет. Подпись секции вычисляется от хэш-кода данных секции.
+func verifyGostSignatures(tlvRecords map[string][]byte, signatures map[string][]byte,
+	root *GostPublicKey, report *ParseReport) {
+	cardKey, chainErr := verifyGostChain(tlvRecords, root, report)
+
+	for _, tag := range gen1SignedSections {
+		data, ok := tlvRecords[tag]
+		if !ok {
+			continue
+		}
+
+		sign, ok := signatures[tag+"81"]
+		if !ok {
+			report.setSignature(tag, SignatureMissing, "")
+			continue
+		}
+		if chainErr != nil {
+			status := SignatureNotVerified
+			if errors.Is(chainErr, errGostUnsupported) {
+				status = SignatureUnsupported
+			}
+			report.setSignature(tag, status, chainErr.Error())
+			continue
+		}
+		r, s, err := gostSignature(sign)
+		if err != nil {
+			report.setSignature(tag, SignatureInvalid, err.Error())
+			continue
+		}
+
+		if cardKey.verify(cardKey.digest(data), r, s) {
+			report.setSignature(tag, SignatureValid, "")
+		} else {
+			report.setSignature(tag, SignatureInvalid, "Signature is invalid")
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package ddd
+
+import (
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// Закрытый ключ ГОСТ Р 34.10 для формирования тестовых подписей
+type gostPrivateKey struct {
+	d   *big.Int
+	pub *GostPublicKey
+}
+
+// Функция генерирует ключ алгоритма alg на кривой КриптоПро A
+func genGost(t *testing.T, alg string) *gostPrivateKey {
+	c := gostCurveCryptoProA
+	d, _ := rand.Int(rand.Reader, new(big.Int).Sub(c.Q, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+	x, y := c.mul(c.X, c.Y, d)
+	return &gostPrivateKey{d, &GostPublicKey{X: x, Y: y, Algorithm: alg, curve: c}}
+}
+
+// Метод подписывает хэш-код digest по ГОСТ Р 34.10 со случайным k
+func (p *gostPrivateKey) sign(digest []byte) (*big.Int, *big.Int) {
+	c := p.pub.curve
+	e := new(big.Int).SetBytes(reverseOctets(digest))
+	e.Mod(e, c.Q)
+	if e.Sign() == 0 {
+		e.SetInt64(1)
+	}
+	for {
+		k, _ := rand.Int(rand.Reader, c.Q)
+		if k.Sign() == 0 {
+			continue
+		}
+		x, _ := c.mul(c.X, c.Y, k)
+		r := new(big.Int).Mod(x, c.Q)
+		if r.Sign() == 0 {
+			continue
+		}
+		s := new(big.Int).Mul(r, p.d)
+		s.Add(s, new(big.Int).Mul(k, e)).Mod(s, c.Q)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s
+	}
+}
+
+// Подписываемая часть сертификата X.509 для формирования тестовых сертификатов
+type gostTestTBS struct {
+	Version   int `asn1:"optional,explicit,default:0,tag:0"`
+	Serial    *big.Int
+	SigAlg    pkix.AlgorithmIdentifier
+	Issuer    pkix.RDNSequence
+	Validity  struct{ A, B time.Time }
+	Subject   pkix.RDNSequence
+	PublicKey gostPublicKeyInfo
+}
+
+// OID ключа, параметров хэш-функции и подписи для алгоритмов ГОСТ Р 34.10-2001 и 34.10-2012
+var gostTestOids = map[string]struct {
+	key, digest, signature asn1.ObjectIdentifier
+}{
+	oidGostR3410_2001: {asn1.ObjectIdentifier{1, 2, 643, 2, 2, 19}, asn1.ObjectIdentifier{1, 2, 643, 2, 2, 30, 1},
+		asn1.ObjectIdentifier{1, 2, 643, 2, 2, 3}},
+	oidGostR3410_2012_256: {asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 1}, asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 2, 2},
+		asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 3, 2}},
+	"2001-test-params": {asn1.ObjectIdentifier{1, 2, 643, 2, 2, 19}, asn1.ObjectIdentifier{1, 2, 643, 2, 2, 30, 0},
+		asn1.ObjectIdentifier{1, 2, 643, 2, 2, 3}},
+}
+
+// Функция возвращает число в big-endian длиной 32 байта
+func fill32(v *big.Int) []byte {
+	b := make([]byte, 32)
+	v.FillBytes(b)
+	return b
+}
+
+// Функция формирует сертификат X.509 ключа subj, подписанный ключом issuer,
+// дополненный нулями до размера файла сертификата на карте
+func gostCert(t *testing.T, issuer *gostPrivateKey, subj *GostPublicKey, name string) []byte {
+	point := append(reverseOctets(fill32(subj.X)), reverseOctets(fill32(subj.Y))...)
+	pk, _ := asn1.Marshal(point)
+	keyAlg := gostTestOids[subj.Algorithm]
+	tbs := gostTestTBS{
+		Version: 2, Serial: big.NewInt(7),
+		SigAlg:  pkix.AlgorithmIdentifier{Algorithm: gostTestOids[issuer.pub.Algorithm].signature},
+		Issuer:  pkix.Name{CommonName: "issuer", Country: []string{"RU"}}.ToRDNSequence(),
+		Subject: pkix.Name{CommonName: name}.ToRDNSequence(),
+		PublicKey: gostPublicKeyInfo{
+			Algorithm: gostKeyAlgorithm{keyAlg.key,
+				gostKeyParameters{asn1.ObjectIdentifier{1, 2, 643, 2, 2, 35, 1}, keyAlg.digest}},
+			PublicKey: asn1.BitString{Bytes: pk, BitLength: len(pk) * 8},
+		},
+	}
+	tbs.Validity.A = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tbs.Validity.B = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	tbsDer, err := asn1.Marshal(tbs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s := issuer.sign(issuer.pub.digest(tbsDer))
+	sig := append(fill32(s), fill32(r)...)
+	der, err := asn1.Marshal(struct {
+		TBS asn1.RawValue
+		Alg pkix.AlgorithmIdentifier
+		Sig asn1.BitString
+	}{asn1.RawValue{FullBytes: tbsDer}, tbs.SigAlg, asn1.BitString{Bytes: sig, BitLength: 512}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(der, make([]byte, 1000-len(der))...)
+}
+
+// Функция формирует файл карты СКЗИ, подписанный ключом карты, с цепочкой сертификатов
+// и возвращает его вместе с корневым сертификатом. Все ключи - алгоритма alg. При tamper данные
+// секции 0506 изменяются после подписи. Секция 0507 не подписывается.
+func buildGostDDD(t *testing.T, alg string, tamper bool) ([]byte, []byte) {
+	root, ca, card := genGost(t, alg), genGost(t, alg), genGost(t, alg)
+	src := buildDriverDDD()
+	var out []byte
+	out = append(out, tlv([]byte{0xC2, 0x00, 0x00}, gostCert(t, ca, card.pub, "card"))...)
+	out = append(out, tlv([]byte{0xC2, 0x08, 0x00}, gostCert(t, root, ca.pub, "ca"))...)
+	for off := 0; off+5 <= len(src); {
+		tag := src[off : off+3]
+		l := int(binary.BigEndian.Uint16(src[off+3 : off+5]))
+		val := append([]byte{}, src[off+5:off+5+l]...)
+		off += 5 + l
+		if tag[2] != 0 {
+			continue
+		}
+		r, s := card.sign(card.pub.digest(val))
+		if tamper && tag[0] == 0x05 && tag[1] == 0x06 {
+			val[5] ^= 0x10
+		}
+		out = append(out, tlv(tag, val)...)
+		if tag[0] == 0x05 && !(tag[1] == 0x07) {
+			out = append(out, tlv([]byte{tag[0], tag[1], 0x81}, append(fill32(s), fill32(r)...))...)
+		}
+	}
+	rootCert := gostCert(t, root, root.pub, "root")
+	return out, rootCert
+}
+
+func TestVerifyGostSignatures(t *testing.T) {
+	for _, alg := range []string{oidGostR3410_2001, oidGostR3410_2012_256} {
+		f, rootCert := buildGostDDD(t, alg, false)
+		root, err := ParseGostRootCertificate(rootCert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := ParseBytesWithOptions(f, ParseOptions{GostRootKey: root})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sec(c.Report, "0504").Signature != SignatureValid || sec(c.Report, "C200").Signature != SignatureValid ||
+			sec(c.Report, "0507").Signature != SignatureMissing {
+			t.Errorf("%s: unexpected signatures: 0504 %+v, C200 %+v, 0507 %+v", alg,
+				sec(c.Report, "0504"), sec(c.Report, "C200"), sec(c.Report, "0507"))
+		}
+
+		f, rootCert = buildGostDDD(t, alg, true)
+		root, _ = ParseGostRootCertificate(rootCert)
+		c, _ = ParseBytesWithOptions(f, ParseOptions{GostRootKey: root})
+		if sec(c.Report, "0506").Signature != SignatureInvalid || sec(c.Report, "0505").Signature != SignatureValid {
+			t.Errorf("%s: tampered section: %+v", alg, sec(c.Report, "0506"))
+		}
+
+		// цепочка сертификатов не проверяется другим корневым ключом
+		f, _ = buildGostDDD(t, alg, false)
+		c, _ = ParseBytesWithOptions(f, ParseOptions{GostRootKey: root})
+		if sec(c.Report, "C208").Signature != SignatureInvalid || sec(c.Report, "0506").Signature != SignatureNotVerified {
+			t.Errorf("%s: wrong root: C208 %+v, 0506 %+v", alg, sec(c.Report, "C208"), sec(c.Report, "0506"))
+		}
+	}
+}
+
+func TestVerifyGostUnsupportedAlgorithm(t *testing.T) {
+	root, ca := genGost(t, oidGostR3410_2001), genGost(t, oidGostR3410_2001)
+	rootKey, err := ParseGostRootCertificate(gostCert(t, root, root.pub, "root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ключ карты ГОСТ Р 34.10-2001 с тестовой таблицей замен ГОСТ Р 34.11-94
+	card := *genGost(t, oidGostR3410_2001).pub
+	card.Algorithm = "2001-test-params"
+	tlvRecords := map[string][]byte{
+		"C208": gostCert(t, root, ca.pub, "ca"),
+		"C200": gostCert(t, ca, &card, "card"),
+		"0504": {1, 2, 3},
+	}
+	signatures := map[string][]byte{"050481": make([]byte, 64)}
+	report := newParseReport(cardSectionNames, tlvRecords)
+	verifyGostSignatures(tlvRecords, signatures, rootKey, &report)
+	if sec(report, "C208").Signature != SignatureValid || sec(report, "C200").Signature != SignatureUnsupported ||
+		sec(report, "0504").Signature != SignatureUnsupported {
+		t.Errorf("unexpected signatures: C200 %+v, 0504 %+v", sec(report, "C200"), sec(report, "0504"))
+	}
+
+	// ключ ГОСТ Р 34.10-2012 длиной 512 бит
+	tbs := &gostTBSCertificate{}
+	tbs.PublicKey.Algorithm.Algorithm = asn1.ObjectIdentifier{1, 2, 643, 7, 1, 1, 1, 2}
+	if _, err := tbs.publicKey(); !errors.Is(err, errGostUnsupported) {
+		t.Errorf("expected unsupported algorithm error, got %v", err)
+	}
+}
+
+// Контрольный пример ГОСТ Р 34.10-2012 (приложение А.1): ключ d, случайное число k и
+// хэш-код e на тестовой кривой дают подпись (r, s), которая проверяется ключом Q = dP
+func TestGostR3410Example(t *testing.T) {
+	c := &gostCurve{
+		P: gostHex("8000000000000000000000000000000000000000000000000000000000000431"),
+		A: big.NewInt(7),
+		B: gostHex("5FBFF498AA938CE739B8E022FBAFEF40563F6E6A3472FC2A514C0CE9DAE23B7E"),
+		Q: gostHex("8000000000000000000000000000000150FE8A1892976154C59CFC193ACCF5B3"),
+		X: big.NewInt(2),
+		Y: gostHex("08E2A8A0E65147D4BD6316030E16D19C85C97F0A9CA267122B96ABBCEA7E8FC8"),
+	}
+	d := gostHex("7A929ADE789BB9BE10ED359DD39A72C11B60961F49397EEE1D19CE9891EC3B28")
+	e := gostHex("2DFBC1B372D89A1188C09C52E0EEC61FCE52032AB1022E8E67ECE6672B043EE5")
+	k := gostHex("77105C9B20BCD3122823C8CF6FCC7B956DE33814E95B7FE64FED924594DCEAB3")
+	expectedR := gostHex("41AA28D2F1AB148280CD9ED56FEDA41974053554A42767B83AD043FD39DC0493")
+	expectedS := gostHex("01456C64BA4642A1653C235A98A60249BCD6D3F746B631DF928014F6C5BF9C40")
+
+	qx, qy := c.mul(c.X, c.Y, d)
+	if qx.Cmp(gostHex("7F2B49E270DB6D90D8595BEC458B50C58585BA1D4E9B788F6689DBD8E56FD80B")) != 0 ||
+		qy.Cmp(gostHex("26F1B489D6701DD185C8413A977B3CBBAF64D1C593D26627DFFB101A87FF77DA")) != 0 {
+		t.Fatalf("unexpected public key %X %X", qx, qy)
+	}
+	if !c.isOnCurve(qx, qy) {
+		t.Fatal("public key is not on curve")
+	}
+
+	// r = x(kP) mod q, s = (rd + ke) mod q
+	cx, _ := c.mul(c.X, c.Y, k)
+	r := new(big.Int).Mod(cx, c.Q)
+	s := new(big.Int).Mul(r, d)
+	s.Add(s, new(big.Int).Mul(k, e)).Mod(s, c.Q)
+	if r.Cmp(expectedR) != 0 || s.Cmp(expectedS) != 0 {
+		t.Fatalf("unexpected signature r %X s %X", r, s)
+	}
+
+	key := &GostPublicKey{X: qx, Y: qy, Algorithm: oidGostR3410_2012_256, curve: c}
+	// хэш-код передается в порядке байт функции хэширования (little-endian)
+	digest := reverseOctets(fill32(e))
+	if !key.verify(digest, expectedR, expectedS) {
+		t.Error("example signature is invalid")
+	}
+	if key.verify(digest, expectedS, expectedR) {
+		t.Error("signature with swapped r and s is valid")
+	}
+	digest[0] ^= 1
+	if key.verify(digest, expectedR, expectedS) {
+		t.Error("signature of another digest is valid")
+	}
+}
+
+// Подпись ГОСТ Р 34.10-2001 с хэш-функцией ГОСТ Р 34.11-94 (КриптоПро), сформированная
+// libgcrypt 1.10 на кривой КриптоПро A
+func TestGostR3410_2001Signature(t *testing.T) {
+	key := &GostPublicKey{
+		X:         gostHex("FD21C21AB0DC84C154F3D218E9040BEE64FFF48BDFF814B232295B09D0DF72E4"),
+		Y:         gostHex("5026DEC9AC4F07061A2A01D7A2307E0659239A82A95862DF86041D1458E45049"),
+		Algorithm: oidGostR3410_2001,
+		curve:     gostCurveCryptoProA,
+	}
+	if !key.curve.isOnCurve(key.X, key.Y) {
+		t.Fatal("public key is not on curve")
+	}
+	sign, _ := hex.DecodeString("D86F20A2A6FCFDD15B5A876845A8E8405E08FF606659BBD2677B486A511B5599" +
+		"532429783CDC77B0B80E3B87A7EB9EAAB76257BB74CC446A75F254B37C9CA7EB")
+	r, s, err := gostSignature(sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("GOST R 34.10-2001")
+	if !key.verify(key.digest(message), r, s) {
+		t.Error("signature is invalid")
+	}
+	if key.verify(streebog256(message), r, s) {
+		t.Error("signature is valid with GOST R 34.11-2012 digest")
+	}
+}
+
+func TestGostSignatureOrder(t *testing.T) {
+	key := genGost(t, oidGostR3410_2012_256)
+	digest := streebog256([]byte("EF_Driver_Activity_Data"))
+	r, s := key.sign(digest)
+
+	// s || r
+	gotR, gotS, err := gostSignature(append(fill32(s), fill32(r)...))
+	if err != nil || gotR.Cmp(r) != 0 || gotS.Cmp(s) != 0 || !key.pub.verify(digest, gotR, gotS) {
+		t.Errorf("signature s || r is not decoded: %v", err)
+	}
+	if _, _, err := gostSignature(fill32(s)); err == nil {
+		t.Error("short signature decoded")
+	}
+}
@@ -0,0 +1,205 @@
+package ddd
+
+import (
+	"sort"
+	"time"
+)
+
+// Вид специального условия
+type SpecificConditionKind string
+
+const (
+	ConditionOutOfScope         SpecificConditionKind = "out_of_scope"         // вне сферы действия регламента
+	ConditionFerryTrainCrossing SpecificConditionKind = "ferry_train_crossing" // переправа на пароме или поезде
+)
+
+// Максимальное время других видов деятельности, прерывающих отдых на пароме или поезде
+// (ст. 9(1) регламента (ЕС) 561/2006): не более двух раз, не более 1 часа в сумме
+const (
+	ferryMaxInterruptions     = 2
+	ferryMaxInterruptionTotal = 60
+)
+
+// Интервал специального условия, составленный из записей о начале и окончании. Если для начала
+// не найдено окончание (или наоборот), соответствующее время не заполняется и устанавливается
+// флаг MissingEnd (MissingBegin).
+type SpecificConditionInterval struct {
+	Kind         SpecificConditionKind `json:"kind"`
+	Begin        *time.Time            `json:"begin,omitempty"`
+	End          *time.Time            `json:"end,omitempty"`
+	MissingBegin bool                  `json:"missing_begin"`
+	MissingEnd   bool                  `json:"missing_end"`
+}
+
+type SpecificConditionIntervals []SpecificConditionInterval
+
+// Запись о специальном условии независимо от поколения карты
+type specificConditionEntry struct {
+	time      time.Time
+	condition int
+}
+
+// Метод составляет интервалы специальных условий из записей карты первого поколения. В первом
+// поколении переправа отмечается одной записью (код 3) без окончания, поэтому ее окончание
+// определяется по суточным записям о деятельности activity.
+func (r SpecificConditionRecords) Intervals(activity ActivityDailyRecords) SpecificConditionIntervals {
+	entries := []specificConditionEntry{}
+	for _, rec := range r {
+		entries = append(entries, specificConditionEntry{rec.EntryTime, int(rec.SpecificConditionTypeId)})
+	}
+	return pairSpecificConditions(entries, 1, buildActivityTimeline(activity))
+}
+
+// Метод составляет интервалы специальных условий из записей карты второго поколения
+func (r SpecificConditionRecordsG2) Intervals() SpecificConditionIntervals {
+	entries := []specificConditionEntry{}
+	for _, rec := range r {
+		entries = append(entries, specificConditionEntry{rec.EntryTime, int(rec.SpecificConditionTypeId)})
+	}
+	return pairSpecificConditions(entries, 2, nil)
+}
+
+// Функция упорядочивает записи по времени и объединяет начало каждого условия с ближайшим
+// следующим окончанием того же условия. Для первого поколения (generation 1) код 3 - это одна
+// переправа: она начинается в момент записи и заканчивается с окончанием первого отдыха
+// на шкале деятельности spans, завершившегося после записи, или со следующей записью
+// о специальном условии, если она раньше. Записи с неизвестным типом пропускаются.
+func pairSpecificConditions(entries []specificConditionEntry, generation int, spans []activitySpan) SpecificConditionIntervals {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time.Before(entries[j].time)
+	})
+
+	result := SpecificConditionIntervals{}
+	open := map[SpecificConditionKind]int{}
+	for i := range entries {
+		e := entries[i]
+		if generation == 1 && e.condition == ConditionFerryTrain {
+			crossing := SpecificConditionInterval{Kind: ConditionFerryTrainCrossing, Begin: &entries[i].time}
+			var next *time.Time
+			if i+1 < len(entries) {
+				next = &entries[i+1].time
+			}
+			crossing.End = ferryCrossingEnd(e.time, next, spans)
+			crossing.MissingEnd = crossing.End == nil
+			result = append(result, crossing)
+			continue
+		}
+
+		var kind SpecificConditionKind
+		switch {
+		case e.condition == ConditionOutOfScopeBegin || e.condition == ConditionOutOfScopeEnd:
+			kind = ConditionOutOfScope
+		case generation != 1 && (e.condition == ConditionFerryTrainBegin || e.condition == ConditionFerryTrainEnd):
+			kind = ConditionFerryTrainCrossing
+		default:
+			continue
+		}
+
+		idx, isOpen := open[kind]
+		if e.condition == ConditionOutOfScopeBegin || e.condition == ConditionFerryTrainBegin {
+			if isOpen {
+				result[idx].MissingEnd = true
+			}
+			open[kind] = len(result)
+			result = append(result, SpecificConditionInterval{Kind: kind, Begin: &entries[i].time})
+			continue
+		}
+
+		if isOpen {
+			result[idx].End = &entries[i].time
+			delete(open, kind)
+			continue
+		}
+		result = append(result, SpecificConditionInterval{Kind: kind, End: &entries[i].time, MissingBegin: true})
+	}
+	for _, idx := range open {
+		result[idx].MissingEnd = true
+	}
+
+	return result
+}
+
+// Функция возвращает окончание переправы первого поколения, начавшейся в момент begin: окончание
+// первого отдыха, завершившегося после begin, или время следующей записи next, если она раньше.
+// Если ни отдых, ни следующая запись не найдены, возвращается nil.
+func ferryCrossingEnd(begin time.Time, next *time.Time, spans []activitySpan) *time.Time {
+	for _, s := range spans {
+		if s.Kind != ActivityBreakRest || !s.End.After(begin) {
+			continue
+		}
+		if next != nil && next.Before(s.End) {
+			return next
+		}
+		end := s.End
+		return &end
+	}
+	return next
+}
+
+// Функция накладывает интервалы специальных условий на шкалу деятельности. Управление вне сферы
+// действия регламента считается работой. Деятельность во время переправы на пароме или поезде,
+// прервавшая отдых не более двух раз и не более чем на 1 час в сумме, считается отдыхом.
+// Интервалы без начала или окончания не учитываются.
+func applySpecificConditions(spans []activitySpan, conditions SpecificConditionIntervals) []activitySpan {
+	for _, c := range conditions {
+		if c.Begin == nil || c.End == nil || !c.End.After(*c.Begin) {
+			continue
+		}
+
+		switch c.Kind {
+		case ConditionOutOfScope:
+			spans = overlaySpans(spans, *c.Begin, *c.End, func(k ActivityKind) ActivityKind {
+				if k == ActivityDriving {
+					return ActivityWork
+				}
+				return k
+			})
+		case ConditionFerryTrainCrossing:
+			interruptions, total := 0, 0
+			for _, s := range spans {
+				if s.Kind != ActivityBreakRest && s.End.After(*c.Begin) && s.Start.Before(*c.End) {
+					interruptions++
+					total += clipSpan(s, *c.Begin, *c.End).minutes()
+				}
+			}
+			if interruptions > ferryMaxInterruptions || total > ferryMaxInterruptionTotal {
+				continue
+			}
+			spans = overlaySpans(spans, *c.Begin, *c.End, func(ActivityKind) ActivityKind {
+				return ActivityBreakRest
+			})
+		}
+	}
+
+	return mergeSpans(spans)
+}
+
+// Функция заменяет вид деятельности в интервале [begin, end) функцией convert,
+// периоды на границах интервала разделяются
+func overlaySpans(spans []activitySpan, begin time.Time, end time.Time, convert func(ActivityKind) ActivityKind) []activitySpan {
+	result := []activitySpan{}
+	for _, s := range spans {
+		if !s.End.After(begin) || !s.Start.Before(end) {
+			result = append(result, s)
+			continue
+		}
+		if s.Start.Before(begin) {
+			result = append(result, activitySpan{s.Start, begin, s.Kind})
+		}
+		inside := clipSpan(s, begin, end)
+		inside.Kind = convert(s.Kind)
+		result = append(result, inside)
+		if s.End.After(end) {
+			result = append(result, activitySpan{end, s.End, s.Kind})
+		}
+	}
+	return result
+}
+
+// Метод возвращает интервалы специальных условий, соответствующие суточным записям activityRecords
+func (c *Card) specificConditions() SpecificConditionIntervals {
+	if len(c.ActivityDailyRecords) == 0 && c.G2 != nil {
+		return c.G2.SpecificConditionIntervals
+	}
+	return c.SpecificConditionIntervals
+}
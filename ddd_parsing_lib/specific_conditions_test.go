@@ -0,0 +1,167 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+// Функция возвращает указатель на время
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// Функция сравнивает время интервала с ожидаемым, nil означает отсутствие времени
+func sameTime(got *time.Time, expected *time.Time) bool {
+	if got == nil || expected == nil {
+		return got == expected
+	}
+	return got.Equal(*expected)
+}
+
+func TestSpecificConditionIntervalsG2(t *testing.T) {
+	at := func(hour int) time.Time { return monday.Add(time.Duration(hour) * time.Hour) }
+	records := SpecificConditionRecordsG2{
+		{SpecificConditionTypeId: ConditionFerryTrainEnd, EntryTime: at(5)},
+		{SpecificConditionTypeId: ConditionOutOfScopeBegin, EntryTime: at(1)},
+		{SpecificConditionTypeId: ConditionFerryTrainBegin, EntryTime: at(2)},
+		{SpecificConditionTypeId: ConditionOutOfScopeEnd, EntryTime: at(10)},
+		{SpecificConditionTypeId: ConditionOutOfScopeEnd, EntryTime: at(11)},
+		{SpecificConditionTypeId: ConditionOutOfScopeBegin, EntryTime: at(12)},
+		{SpecificConditionTypeId: 0, EntryTime: at(13)},
+	}
+
+	expected := SpecificConditionIntervals{
+		{Kind: ConditionOutOfScope, Begin: timePtr(at(1)), End: timePtr(at(10))},
+		{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(2)), End: timePtr(at(5))},
+		{Kind: ConditionOutOfScope, End: timePtr(at(11)), MissingBegin: true},
+		{Kind: ConditionOutOfScope, Begin: timePtr(at(12)), MissingEnd: true},
+	}
+	checkConditionIntervals(t, "gen2", records.Intervals(), expected)
+}
+
+func TestSpecificConditionIntervalsGen1(t *testing.T) {
+	at := func(day int, hour int, minute int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	// управление 06:00-10:00, отдых 10:00-11:00, управление 11:00-20:00, отдых до 06:00,
+	// управление 06:00-08:00, отдых до конца суток
+	activity := ActivityDailyRecords{
+		dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 600}, [2]int{3, 660}, [2]int{0, 1200}),
+		dayRecord(monday.AddDate(0, 0, 1), [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 480}),
+	}
+
+	tests := []struct {
+		name     string
+		records  SpecificConditionRecords
+		expected SpecificConditionIntervals
+	}{
+		{
+			name:    "closed by the end of rest",
+			records: SpecificConditionRecords{{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: at(0, 19, 30)}},
+			expected: SpecificConditionIntervals{
+				{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(0, 19, 30)), End: timePtr(at(1, 6, 0))},
+			},
+		},
+		{
+			name:    "entry during rest",
+			records: SpecificConditionRecords{{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: at(0, 10, 15)}},
+			expected: SpecificConditionIntervals{
+				{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(0, 10, 15)), End: timePtr(at(0, 11, 0))},
+			},
+		},
+		{
+			name: "closed by the next entry",
+			records: SpecificConditionRecords{
+				{SpecificConditionTypeId: ConditionOutOfScopeBegin, EntryTime: at(0, 22, 0)},
+				{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: at(0, 19, 30)},
+			},
+			expected: SpecificConditionIntervals{
+				{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(0, 19, 30)), End: timePtr(at(0, 22, 0))},
+				{Kind: ConditionOutOfScope, Begin: timePtr(at(0, 22, 0)), MissingEnd: true},
+			},
+		},
+		{
+			name: "no end after the last activity",
+			records: SpecificConditionRecords{
+				{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: at(2, 1, 0)},
+				{SpecificConditionTypeId: ConditionFerryTrainEnd, EntryTime: at(2, 2, 0)},
+			},
+			expected: SpecificConditionIntervals{
+				{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(2, 1, 0)), End: timePtr(at(2, 2, 0))},
+			},
+		},
+		{
+			name:    "no rest and no next entry",
+			records: SpecificConditionRecords{{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: at(2, 1, 0)}},
+			expected: SpecificConditionIntervals{
+				{Kind: ConditionFerryTrainCrossing, Begin: timePtr(at(2, 1, 0)), MissingEnd: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		checkConditionIntervals(t, tt.name, tt.records.Intervals(activity), tt.expected)
+	}
+}
+
+// Функция сравнивает интервалы специальных условий с ожидаемыми
+func checkConditionIntervals(t *testing.T, name string, got SpecificConditionIntervals, expected SpecificConditionIntervals) {
+	t.Helper()
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %d intervals, got %+v", name, len(expected), got)
+		return
+	}
+	for i := range expected {
+		g, e := got[i], expected[i]
+		if g.Kind != e.Kind || !sameTime(g.Begin, e.Begin) || !sameTime(g.End, e.End) ||
+			g.MissingBegin != e.MissingBegin || g.MissingEnd != e.MissingEnd {
+			t.Errorf("%s: interval %d expected %v %v-%v missing %v/%v, got %v %v-%v missing %v/%v", name, i,
+				e.Kind, e.Begin, e.End, e.MissingBegin, e.MissingEnd, g.Kind, g.Begin, g.End, g.MissingBegin, g.MissingEnd)
+		}
+	}
+}
+
+func TestAnalyzeEU561FerryGen1(t *testing.T) {
+	// понедельник: управление 07:00-11:00 и 11:45-15:45, работа до 19:00, ожидание парома до 20:30,
+	// въезд на паром 20:30-21:00, отдых на пароме до 05:00; вторник: управление 05:00-09:00 и 09:45-13:00
+	records := ActivityDailyRecords{
+		dayRecord(monday, [2]int{0, 0}, [2]int{3, 420}, [2]int{0, 660}, [2]int{3, 705}, [2]int{2, 945},
+			[2]int{0, 1140}, [2]int{3, 1230}, [2]int{0, 1260}),
+		dayRecord(monday.AddDate(0, 0, 1), [2]int{0, 0}, [2]int{3, 300}, [2]int{0, 540}, [2]int{3, 585}, [2]int{0, 780}),
+		dayRecord(monday.AddDate(0, 0, 2), [2]int{0, 0}),
+	}
+	conditions := SpecificConditionRecords{
+		{SpecificConditionTypeId: ConditionFerryTrain, EntryTime: monday.Add(20*time.Hour + 30*time.Minute)},
+	}.Intervals(records)
+
+	checkInfringements(t, "without crossing", AnalyzeEU561(records),
+		map[string]int{"EU561_DAILY_REST": 1, "EU561_DAILY_DRIVING": 1},
+		map[string]expectedInfringement{"EU561_DAILY_REST": {480, SeverityMinor}})
+	checkInfringements(t, "gen1 crossing", AnalyzeEU561(records, conditions...), map[string]int{}, nil)
+}
+
+func TestAnalyzeOutOfScope(t *testing.T) {
+	// управление 06:00-10:00 и 10:45-15:45
+	records := ActivityDailyRecords{
+		dayRecord(monday, [2]int{0, 0}, [2]int{3, 360}, [2]int{0, 600}, [2]int{3, 645}, [2]int{0, 945}),
+		dayRecord(monday.AddDate(0, 0, 1), [2]int{0, 0}),
+	}
+	outOfScope := SpecificConditionRecordsG2{
+		{SpecificConditionTypeId: ConditionOutOfScopeBegin, EntryTime: monday.Add(10 * time.Hour)},
+		{SpecificConditionTypeId: ConditionOutOfScopeEnd, EntryTime: monday.Add(16 * time.Hour)},
+	}.Intervals()
+
+	checkInfringements(t, "in scope", AnalyzeRU424(records),
+		map[string]int{"RU424_CONTINUOUS_DRIVING": 1}, nil)
+
+	report, err := AnalyzeCompliance(records, RulesetRU424, outOfScope...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInfringements(t, "out of scope", report, map[string]int{}, nil)
+	if len(report.DutyPeriods) != 1 {
+		t.Fatalf("expected 1 duty period, got %+v", report.DutyPeriods)
+	}
+	if p := report.DutyPeriods[0]; p.Driving != 240 || p.Work != 300 {
+		t.Errorf("expected driving 240 and work 300, got %d and %d", p.Driving, p.Work)
+	}
+}
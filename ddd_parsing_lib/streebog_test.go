@@ -0,0 +1,30 @@
+package ddd
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Контрольные примеры ГОСТ Р 34.11-2012 (приложение А). В стандарте векторы записаны
+// от старшего байта к младшему, здесь - в порядке байт в памяти.
+func TestStreebog256(t *testing.T) {
+	m2, _ := hex.DecodeString("d1e520e2e5f2f0e82c20d1f2f0e8e1eee6e820e2edf3f6e82c20e2e5fef2fa20f120eceef0ff20f1f2f0" +
+		"e5ebe0ece820ede020f5f0e0e1f0fbff20efebfaeafb20c8e3eef0e5e2fb")
+	tests := []struct {
+		name    string
+		message []byte
+		digest  string
+	}{
+		{"M1", []byte("012345678901234567890123456789012345678901234567890123456789012"),
+			"9d151eefd8590b89daa6ba6cb74af9275dd051026bb149a452fd84e5e57b5500"},
+		{"M2", m2, "9dd2fe4e90409e5da87f53976d7405b0c0cac628fc669a741d50063c557e8f50"},
+		{"empty", []byte{}, "3f539a213e97c802cc229d474c6aa32a825a360b2a933a949fd925208d9ce1bb"},
+		{"fox", []byte("The quick brown fox jumps over the lazy dog"),
+			"3e7dea7f2384b6c5a3d0e24aaa29c05e89ddd762145030ec22c71a6db8b2c1f4"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(streebog256(tt.message)); got != tt.digest {
+			t.Errorf("%s: got %s, expected %s", tt.name, got, tt.digest)
+		}
+	}
+}
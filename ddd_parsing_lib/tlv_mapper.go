@@ -1,4 +1,4 @@
-package main
+package ddd
 
 import (
 	"bytes"
@@ -27,85 +27,88 @@ func loadFields(customStruct interface{}, tlvRecords map[string][]byte) error {
 	var err error
 
 	switch t := customStruct.(type) {
-	case *cardEventRecords:
+	case *CardEventGroups:
 		tag := "0502"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 24, 0)
-		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := cardEventRecord{}
-		for _, tlv := range tlvs {
-			if err := loadFields(&c, tlv); err != nil {
-				return err
+		blocks, readErr := readTypeBlocks(tag, tlvRecords[tag], 24, recordsPerType(tlvRecords, 3), len(gen1EventGroupTypes))
+		for i, block := range blocks {
+			records := CardEventRecords{}
+			for _, tlv := range recTblToTlvs(tag, block) {
+				c := CardEventRecord{}
+				if err := loadFields(&c, tlv); err != nil {
+					return err
+				}
+				if !EventRecordIsEmpty(&c) {
+					records = append(records, c)
+				}
 			}
 
-			if !EventRecordIsEmpty(&c) {
-				*t = append(*t, c)
+			firstTypeId := EventFaultType(0)
+			if len(records) > 0 {
+				firstTypeId = records[0].EventTypeId
 			}
-		}
-		return nil
-	case *сardVehicleRecords:
-		tag := "0505"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 31, 2)
-		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := сardVehicleRecord{}
-		for _, tlv := range tlvs {
-			if err := loadFields(&c, tlv); err != nil {
-				return err
-			}
-			if !VehicleRecordIsEmpty(&c) {
-				*t = append(*t, c)
+			groupType := detectEventFaultGroupType(firstTypeId, len(records) > 0, i, len(blocks), gen1EventGroupTypes)
+			if groupType < 0 {
+				continue
 			}
+			*t = append(*t, CardEventGroup{groupType, groupType.GroupLabel(LangEn), records})
 		}
-		return nil
-	case *activityDailyRecords:
+		return readErr
+	case *CardVehicleRecords:
+		return loadCyclicRecordList(t, "0505", tlvRecords, 31, 2, 2, func(rec interface{}) bool {
+			return VehicleRecordIsEmpty(rec.(*CardVehicleRecord))
+		})
+	case *ActivityDailyRecords:
 		tag := "0504"
-		recordsOfCircleFile := readActivityDailyRecs(tlvRecords[tag], 4)
+		recordsOfCircleFile, readErr := readActivityDailyRecs(tlvRecords[tag], 4)
 		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := activityDailyRecord{}
+		c := ActivityDailyRecord{}
 		for _, tlv := range tlvs {
 			if err := loadFields(&c, tlv); err != nil {
 				return err
 			}
 
 			if err := c.ParseChangeInfo(); err != nil {
-				return err
+				return &SectionError{tag, err}
 			}
 
 			*t = append(*t, c)
 		}
-		return nil
-	case *placeRecords:
-		tag := "0506"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 10, 1)
-		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := placeRecord{}
-		for _, tlv := range tlvs {
-			if err := loadFields(&c, tlv); err != nil {
-				return err
-			}
-			if !PlaceRecordIsEmpty(&c) {
-				*t = append(*t, c)
-			}
-		}
-		return nil
-	case *cardFaultRecords:
+		return readErr
+	case *PlaceRecords:
+		return loadCyclicRecordList(t, "0506", tlvRecords, 10, 1, 1, func(rec interface{}) bool {
+			return PlaceRecordIsEmpty(rec.(*PlaceRecord))
+		})
+	case *CardFaultGroups:
 		tag := "0503"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 24, 0)
-		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := cardFaultRecord{}
-		for _, tlv := range tlvs {
-			if err := loadFields(&c, tlv); err != nil {
-				return err
+		blocks, readErr := readTypeBlocks(tag, tlvRecords[tag], 24, recordsPerType(tlvRecords, 4), len(gen1FaultGroupTypes))
+		for i, block := range blocks {
+			records := CardFaultRecords{}
+			for _, tlv := range recTblToTlvs(tag, block) {
+				c := CardFaultRecord{}
+				if err := loadFields(&c, tlv); err != nil {
+					return err
+				}
+				if !FaultRecordIsEmpty(&c) {
+					records = append(records, c)
+				}
 			}
-			if !FaultRecordIsEmpty(&c) {
-				*t = append(*t, c)
+
+			firstTypeId := EventFaultType(0)
+			if len(records) > 0 {
+				firstTypeId = records[0].FaultTypeId
+			}
+			groupType := detectEventFaultGroupType(firstTypeId, len(records) > 0, i, len(blocks), gen1FaultGroupTypes)
+			if groupType < 0 {
+				continue
 			}
+			*t = append(*t, CardFaultGroup{groupType, groupType.GroupLabel(LangEn), records})
 		}
-		return nil
-	case *cardControlActivityDataRecords:
+		return readErr
+	case *CardControlActivityDataRecords:
 		tag := "0508"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 46, 0)
+		recordsOfCircleFile, readErr := readFileRecords(tag, tlvRecords[tag], 46, 0)
 		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := cardControlActivityDataRecord{}
+		c := CardControlActivityDataRecord{}
 		for _, tlv := range tlvs {
 			if err := loadFields(&c, tlv); err != nil {
 				return err
@@ -114,12 +117,12 @@ func loadFields(customStruct interface{}, tlvRecords map[string][]byte) error {
 				*t = append(*t, c)
 			}
 		}
-		return nil
-	case *specificConditionRecords:
+		return readErr
+	case *SpecificConditionRecords:
 		tag := "0522"
-		recordsOfCircleFile := readFileRecords(tlvRecords[tag], 5, 0)
+		recordsOfCircleFile, readErr := readFileRecords(tag, tlvRecords[tag], 5, 0)
 		tlvs := recTblToTlvs(tag, recordsOfCircleFile)
-		c := specificConditionRecord{}
+		c := SpecificConditionRecord{}
 		for _, tlv := range tlvs {
 			if err := loadFields(&c, tlv); err != nil {
 				return err
@@ -128,46 +131,143 @@ func loadFields(customStruct interface{}, tlvRecords map[string][]byte) error {
 				*t = append(*t, c)
 			}
 		}
-		return nil
-	case *card:
+		return readErr
+	case *VuCompanyLocksRecords:
+		return loadRecordList(t, "7601:locks", tlvRecords, 98)
+	case *VuControlActivityRecords:
+		return loadRecordList(t, "7601:controls", tlvRecords, 31)
+	case *VuCardIWRecords:
+		return loadRecordList(t, "7602:cards", tlvRecords, 129)
+	case *VuPlaceRecords:
+		return loadRecordList(t, "7602:places", tlvRecords, 28)
+	case *VuSpecificConditionRecords:
+		return loadRecordList(t, "7602:conditions", tlvRecords, 5)
+	case *VuFaultRecords:
+		return loadRecordList(t, "7603:faults", tlvRecords, 82)
+	case *VuEventRecords:
+		return loadRecordList(t, "7603:events", tlvRecords, 83)
+	case *VuOverSpeedingEventRecords:
+		return loadRecordList(t, "7603:overspeed_events", tlvRecords, 31)
+	case *VuTimeAdjustmentRecords:
+		return loadRecordList(t, "7603:time_adjustments", tlvRecords, 98)
+	case *VuDetailedSpeedBlocks:
+		return loadRecordList(t, "7604:speed_blocks", tlvRecords, 64)
+	case *VuCalibrationRecords:
+		return loadRecordList(t, "7605:calibrations", tlvRecords, 167)
+	case *CardVehicleRecordsG2:
+		return loadCyclicRecordList(t, "0505", tlvRecords, 48, 2, 2, func(rec interface{}) bool {
+			return VehicleRecordG2IsEmpty(rec.(*CardVehicleRecordG2))
+		})
+	case *PlaceRecordsG2:
+		return loadCyclicRecordList(t, "0506", tlvRecords, 21, 2, 2, func(rec interface{}) bool {
+			return PlaceRecordG2IsEmpty(rec.(*PlaceRecordG2))
+		})
+	case *SpecificConditionRecordsG2:
+		return loadCyclicRecordList(t, "0522", tlvRecords, 5, 2, 2, func(rec interface{}) bool {
+			return SpecificConditionG2IsEmpty(rec.(*SpecificConditionRecordG2))
+		})
+	case *CardVehicleUnitRecords:
+		return loadCyclicRecordList(t, "0523", tlvRecords, 10, 2, 2, func(rec interface{}) bool {
+			return VehicleUnitRecordIsEmpty(rec.(*CardVehicleUnitRecord))
+		})
+	case *GNSSAccumulatedDrivingRecords:
+		return loadCyclicRecordList(t, "0524", tlvRecords, 18, 2, 2, func(rec interface{}) bool {
+			return GNSSRecordIsEmpty(rec.(*GNSSAccumulatedDrivingRecord))
+		})
+	case *PlaceAuthStatusRecords:
+		return loadCyclicRecordList(t, "0526", tlvRecords, 5, 2, 2, func(rec interface{}) bool {
+			return AuthStatusRecordIsEmpty(rec.(*PlaceAuthStatusRecord))
+		})
+	case *GNSSPlaceAuthStatusRecords:
+		return loadCyclicRecordList(t, "0527", tlvRecords, 5, 2, 2, func(rec interface{}) bool {
+			return AuthStatusRecordIsEmpty((*PlaceAuthStatusRecord)(rec.(*GNSSPlaceAuthStatusRecord)))
+		})
+	case *CardBorderCrossingRecords:
+		return loadCyclicRecordList(t, "0528", tlvRecords, 17, 2, 2, func(rec interface{}) bool {
+			return BorderCrossingRecordIsEmpty(rec.(*CardBorderCrossingRecord))
+		})
+	case *CardLoadUnloadRecords:
+		return loadCyclicRecordList(t, "0529", tlvRecords, 20, 2, 2, func(rec interface{}) bool {
+			return LoadUnloadRecordIsEmpty(rec.(*CardLoadUnloadRecord))
+		})
+	case *CardLoadTypeEntryRecords:
+		return loadCyclicRecordList(t, "0530", tlvRecords, 5, 2, 2, func(rec interface{}) bool {
+			return LoadTypeEntryRecordIsEmpty(rec.(*CardLoadTypeEntryRecord))
+		})
+	case *WorkshopCalibrationRecords:
+		return loadCyclicRecordList(t, "050A", tlvRecords, 105, 3, 1, func(rec interface{}) bool {
+			return CalibrationRecordIsEmpty(rec.(*WorkshopCalibrationRecord))
+		})
+	case *ControlActivityRecords:
+		return loadCyclicRecordList(t, "050C", tlvRecords, 46, 2, 2, func(rec interface{}) bool {
+			return ControlActivityRecordIsEmpty(rec.(*ControlActivityRecord))
+		})
+	case *CompanyActivityRecords:
+		return loadCyclicRecordList(t, "050C", tlvRecords, 46, 2, 2, func(rec interface{}) bool {
+			return CompanyActivityRecordIsEmpty(rec.(*CompanyActivityRecord))
+		})
+	case *ControlCardHolder, *ControlActivityRecord, *CompanyCardHolder, *CompanyActivityRecord:
+		structValRef = reflect.ValueOf(t)
+		structType = structValRef.Elem().Type()
+	case *WorkshopCardHolder, *WorkshopCalibrationData, *WorkshopCalibrationRecord, *SensorInstallationData:
+		structValRef = reflect.ValueOf(t)
+		structType = structValRef.Elem().Type()
+	case *ApplicationIdentificationV2, *CardBorderCrossingRecord, *CardLoadUnloadRecord,
+		*CardLoadTypeEntryRecord:
+		structValRef = reflect.ValueOf(t)
+		structType = structValRef.Elem().Type()
+	case *CardG2, *CardInfoG2, *ApplicationIdentificationG2, *CardVehicleRecordG2, *PlaceRecordG2,
+		*SpecificConditionRecordG2, *CardVehicleUnitRecord, *GNSSAccumulatedDrivingRecord,
+		*PlaceAuthStatusRecord, *GNSSPlaceAuthStatusRecord:
+		structValRef = reflect.ValueOf(t)
+		structType = structValRef.Elem().Type()
+	case *VuOverview, *VuCompanyLocksRecord, *VuControlActivityRecord, *VuDailyActivity,
+		*VuCardIWRecord, *VuPlaceRecord, *VuSpecificConditionRecord, *VuEventsAndFaults,
+		*VuFaultRecord, *VuEventRecord, *VuOverSpeedingEventRecord, *VuTimeAdjustmentRecord,
+		*VuDetailedSpeedBlock, *VuTechnicalData, *VuCalibrationRecord:
+		structValRef = reflect.ValueOf(t)
+		structType = structValRef.Elem().Type()
+	case *Card:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *driver:
+	case *Driver:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *dlicense:
+	case *DLicense:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *sessionOpen:
+	case *SessionOpen:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *cardInfo:
+	case *CardInfo:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *cardEventRecord:
+	case *CardEventRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *сardVehicleRecord:
+	case *CardVehicleRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *activityDailyRecord:
+	case *ActivityDailyRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *placeRecord:
+	case *PlaceRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *cardFaultRecord:
+	case *CardFaultRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *cardControlActivityDataRecord:
+	case *CardControlActivityDataRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
-	case *specificConditionRecord:
+	case *SpecificConditionRecord:
 		structType = reflect.TypeOf(*t)
 		structValRef = reflect.ValueOf(t)
 	default:
 	}
 
+	// ошибка в одном поле не прерывает загрузку остальных полей структуры
+	var errs loadErrors
 	for i := 0; i < structType.NumField(); i++ {
 		current_field := structType.Field(i)
 
@@ -183,20 +283,70 @@ func loadFields(customStruct interface{}, tlvRecords map[string][]byte) error {
 				continue
 			}
 			log.Printf("Can't find section [name:%s]", tlv_config.Name)
-			return errors.New("Not valid input file")
+			errs = append(errs, &SectionError{tlv_config.Name, errors.New("Not valid input file")})
+			continue
 		}
 
-		hexVal := readBytes(tlvVal, tlv_config.ValueLen, tlv_config.Offset)
+		hexVal, err := readBytes(tlv_config.Name, tlvVal, tlv_config.ValueLen, tlv_config.Offset)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
 
 		field_val, err := decodeValue(tlv_config.OutputType, hexVal)
 		if err != nil {
-			return err
+			errs = append(errs, &SectionError{tlv_config.Name, err})
+			continue
 		}
-		structValRef.Elem().Field(i).Set(field_val)
+		// поля справочников имеют собственные типы на основе int
+		structValRef.Elem().Field(i).Set(field_val.Convert(current_field.Type))
+	}
+	fillIsoCodes(structValRef.Elem())
+	fillCodeNames(structValRef.Elem())
+
+	if len(errs) > 0 {
+		return errs
 	}
 	return err
 }
 
+// Функция загружает записи секции tag в список records (указатель на срез структур).
+// Используется для секций, записи которых идут подряд без пустых записей.
+func loadRecordList(records interface{}, tag string, tlvRecords map[string][]byte, recordLen int) error {
+	return loadCyclicRecordList(records, tag, tlvRecords, recordLen, 0, 0, nil)
+}
+
+// Функция загружает записи циклического файла tag в список records (указатель на срез структур).
+// offset - смещение первой записи от начала файла, pointerLen - длина указателя на самую новую
+// запись, который хранится непосредственно перед записями (0 - указателя нет). Если указатель есть,
+// записи упорядочиваются от самой старой к самой новой.
+// isEmpty - проверка на пустую запись, пустые записи в список не добавляются.
+func loadCyclicRecordList(records interface{}, tag string, tlvRecords map[string][]byte, recordLen int,
+	offset int, pointerLen int, isEmpty func(rec interface{}) bool) error {
+	recordsOfCircleFile, readErr := readFileRecords(tag, tlvRecords[tag], recordLen, offset)
+	if pointerLen > 0 && readErr == nil && len(recordsOfCircleFile) > 0 {
+		pointer, err := readBytes(tag, tlvRecords[tag], pointerLen, offset - pointerLen)
+		if err == nil {
+			newest, _ := bytesToInt(pointer)
+			recordsOfCircleFile, readErr = orderCyclicRecords(tag, recordsOfCircleFile, newest)
+		}
+	}
+	tlvs := recTblToTlvs(tag, recordsOfCircleFile)
+
+	list := reflect.ValueOf(records).Elem()
+	for _, tlv := range tlvs {
+		rec := reflect.New(list.Type().Elem())
+		if err := loadFields(rec.Interface(), tlv); err != nil {
+			return err
+		}
+		if isEmpty != nil && isEmpty(rec.Interface()) {
+			continue
+		}
+		list.Set(reflect.Append(list, rec.Elem()))
+	}
+	return readErr
+}
+
 // Функция для парсинга подсказок к полям, через которые будет осуществляться
 // маппинг из байтовых значений.
 // Формат подсказок следующий:
@@ -270,11 +420,11 @@ func decodeValue(field_type string, hexVal []byte) (reflect.Value, error) {
 		}
 		result = reflect.ValueOf(utf_string)
 	case "hexadecimal":
-		trimmingHex := bytes.Trim(hexVal, string(0x00))
+		trimmingHex := bytes.Trim(hexVal, "\x00")
 		trimming_str := fmt.Sprintf("%x", trimmingHex)
 		result = reflect.ValueOf(trimming_str)
 	case "daylicounter":
-		trimmingHex := bytes.TrimLeft(hexVal, string(0x00))
+		trimmingHex := bytes.TrimLeft(hexVal, "\x00")
 		trimming_str := fmt.Sprintf("%x", trimmingHex)
 		result = reflect.ValueOf(trimming_str)
 	case "activites":
@@ -299,6 +449,18 @@ func decodeValue(field_type string, hexVal []byte) (reflect.Value, error) {
 			}
 		}
 		result = reflect.ValueOf(cur_date)
+	case "intlist":
+		intList := make([]int, len(hexVal))
+		for i, b := range hexVal {
+			intList[i] = int(b)
+		}
+		result = reflect.ValueOf(intList)
+	case "coordinate":
+		coord, err := bytesToCoordinate(hexVal)
+		if err != nil {
+			return result, err
+		}
+		result = reflect.ValueOf(coord)
 	case "date":
 		cur_date, err := hexToDate(hexVal)
 		if err != nil {
@@ -0,0 +1,64 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+// Функция формирует файл мест на 4 записи, в котором записи хранятся в порядке
+// [r2, r3, r0, r1], то есть самая новая запись r3 имеет индекс 1
+func buildCyclicPlaces(newest byte) []byte {
+	data := make([]byte, 1+10*4)
+	data[0] = newest
+	for slot, n := range []int{2, 3, 0, 1} {
+		r := data[1+10*slot:]
+		copy(r, ts(day0.Add(time.Duration(n)*time.Hour)))
+		r[5] = byte(n + 1)
+	}
+	return data
+}
+
+func TestLoadCyclicPlaceRecords(t *testing.T) {
+	var places PlaceRecords
+	if err := loadFields(&places, map[string][]byte{"0506": buildCyclicPlaces(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(places) != 4 {
+		t.Fatalf("expected 4 places, got %d", len(places))
+	}
+	for i, p := range places {
+		if !p.EntryTime.Equal(day0.Add(time.Duration(i)*time.Hour)) || p.DailyWorkPeriodCountry != i+1 {
+			t.Errorf("place %d: unexpected record %v %d", i, p.EntryTime, p.DailyWorkPeriodCountry)
+		}
+	}
+
+	// указатель за пределами файла: записи в порядке хранения и ошибка
+	places = nil
+	err := loadFields(&places, map[string][]byte{"0506": buildCyclicPlaces(9)})
+	if err == nil || len(places) != 4 || places[0].DailyWorkPeriodCountry != 3 {
+		t.Fatalf("unexpected result for invalid pointer: %v, %+v", err, places)
+	}
+}
+
+func TestLoadCyclicVehicleRecords(t *testing.T) {
+	// 5 записей, самая новая - с индексом 1: в порядке хранения записи дней 3, 4, 0, 1, 2
+	data := buildVehicles(5)
+	stored := append([]byte{}, data[2:2+31*5]...)
+	for slot, n := range []int{3, 4, 0, 1, 2} {
+		copy(data[2+31*slot:], stored[31*n:31*(n+1)])
+	}
+	data[1] = 1
+
+	var vehicles CardVehicleRecords
+	if err := loadFields(&vehicles, map[string][]byte{"0505": data}); err != nil {
+		t.Fatal(err)
+	}
+	if len(vehicles) != 5 {
+		t.Fatalf("expected 5 vehicles, got %d", len(vehicles))
+	}
+	for i, v := range vehicles {
+		if !v.VehicleFirstUse.Equal(day0.AddDate(0, 0, i).Add(6 * time.Hour)) {
+			t.Errorf("vehicle %d: unexpected first use %v", i, v.VehicleFirstUse)
+		}
+	}
+}
@@ -1,11 +1,11 @@
-package main
+package ddd
 
 import (
 	"time"
 )
 
 // проверка на пустую запись об использовании ТС
-func VehicleRecordIsEmpty(vr *сardVehicleRecord) bool {
+func VehicleRecordIsEmpty(vr *CardVehicleRecord) bool {
 	var result bool
 
 	result = vr.VehicleFirstUse == time.Unix(0, 0).UTC()
@@ -17,7 +17,7 @@ func VehicleRecordIsEmpty(vr *сardVehicleRecord) bool {
 }
 
 // проверка на пустую запись о неисправности
-func FaultRecordIsEmpty(fault *cardFaultRecord) bool {
+func FaultRecordIsEmpty(fault *CardFaultRecord) bool {
 	var result bool
 
 	result = fault.FaultBeginTime == time.Unix(0, 0).UTC()
@@ -28,7 +28,7 @@ func FaultRecordIsEmpty(fault *cardFaultRecord) bool {
 }
 
 // проверка на пустую запись о событии
-func EventRecordIsEmpty(event *cardEventRecord) bool {
+func EventRecordIsEmpty(event *CardEventRecord) bool {
 	var result bool
 
 	result = event.EventBeginTime == time.Unix(0, 0).UTC()
@@ -39,7 +39,7 @@ func EventRecordIsEmpty(event *cardEventRecord) bool {
 }
 
 // проверка на пустую запись о месте
-func PlaceRecordIsEmpty(place *placeRecord) bool {
+func PlaceRecordIsEmpty(place *PlaceRecord) bool {
 	var result bool
 
 	result = place.EntryTime == time.Unix(0, 0).UTC()
@@ -52,7 +52,7 @@ func PlaceRecordIsEmpty(place *placeRecord) bool {
 }
 
 // проверка на пустую запись о специальных условиях
-func SpecificConditionIsEmpty(sc *specificConditionRecord) bool {
+func SpecificConditionIsEmpty(sc *SpecificConditionRecord) bool {
 	var result bool
 
 	result = sc.EntryTime == time.Unix(0, 0).UTC()
@@ -62,7 +62,7 @@ func SpecificConditionIsEmpty(sc *specificConditionRecord) bool {
 }
 
 // проверка на пустую запись о контроле
-func ControlActivityDataIsEmpty(cad *cardControlActivityDataRecord) bool {
+func ControlActivityDataIsEmpty(cad *CardControlActivityDataRecord) bool {
 	var result bool
 
 	result = cad.ControlTypeId == 0
@@ -74,4 +74,136 @@ func ControlActivityDataIsEmpty(cad *cardControlActivityDataRecord) bool {
 	result = result && cad.ControlDownloadPeriodEnd == time.Unix(0, 0).UTC()
 
 	return result
-}
\ No newline at end of file
+}
+// проверка на пустую запись об использовании ТС (второе поколение)
+func VehicleRecordG2IsEmpty(vr *CardVehicleRecordG2) bool {
+	var result bool
+
+	result = vr.VehicleFirstUse == time.Unix(0, 0).UTC()
+	result = result && vr.VehicleLastUse == time.Unix(0, 0).UTC()
+	result = result && vr.VehicleOdometerBegin == 0
+	result = result && vr.VehicleOdometerEnd == 0
+
+	return result
+}
+
+// проверка на пустую запись о месте (второе поколение)
+func PlaceRecordG2IsEmpty(place *PlaceRecordG2) bool {
+	var result bool
+
+	result = place.EntryTime == time.Unix(0, 0).UTC()
+	result = result && place.DailyWorkPeriodCountry == 0
+	result = result && place.DailyWorkPeriodRegion == 0
+	result = result && place.TypePeriodId == 0
+	result = result && place.VehicleOdometerValue == 0
+
+	return result
+}
+
+// проверка на пустую запись о специальных условиях (второе поколение)
+func SpecificConditionG2IsEmpty(sc *SpecificConditionRecordG2) bool {
+	var result bool
+
+	result = sc.EntryTime == time.Unix(0, 0).UTC()
+	result = result && sc.SpecificConditionTypeId == 0
+
+	return result
+}
+
+// проверка на пустую запись об использовании ВБУ
+func VehicleUnitRecordIsEmpty(vu *CardVehicleUnitRecord) bool {
+	var result bool
+
+	result = vu.TimeStamp == time.Unix(0, 0).UTC()
+	result = result && vu.ManufacturerCode == 0
+	result = result && vu.DeviceId == 0
+
+	return result
+}
+
+// проверка на пустую запись о месте накопленного времени управления
+func GNSSRecordIsEmpty(gnss *GNSSAccumulatedDrivingRecord) bool {
+	var result bool
+
+	result = gnss.TimeStamp == time.Unix(0, 0).UTC()
+	result = result && gnss.GnssTimeStamp == time.Unix(0, 0).UTC()
+	result = result && gnss.VehicleOdometerValue == 0
+
+	return result
+}
+
+// проверка на пустую запись о статусе аутентификации
+func AuthStatusRecordIsEmpty(as *PlaceAuthStatusRecord) bool {
+	var result bool
+
+	result = as.EntryTime == time.Unix(0, 0).UTC()
+	result = result && as.AuthenticationStatus == 0
+
+	return result
+}
+
+// проверка на пустую запись о пересечении границы
+func BorderCrossingRecordIsEmpty(bc *CardBorderCrossingRecord) bool {
+	var result bool
+
+	result = bc.GnssTimeStamp == time.Unix(0, 0).UTC()
+	result = result && bc.CountryLeft == 0
+	result = result && bc.CountryEntered == 0
+	result = result && bc.VehicleOdometerValue == 0
+
+	return result
+}
+
+// проверка на пустую запись о погрузке/разгрузке
+func LoadUnloadRecordIsEmpty(lu *CardLoadUnloadRecord) bool {
+	var result bool
+
+	result = lu.TimeStamp == time.Unix(0, 0).UTC()
+	result = result && lu.OperationType == 0
+	result = result && lu.VehicleOdometerValue == 0
+
+	return result
+}
+
+// проверка на пустую запись о типе груза
+func LoadTypeEntryRecordIsEmpty(lt *CardLoadTypeEntryRecord) bool {
+	var result bool
+
+	result = lt.TimeStamp == time.Unix(0, 0).UTC()
+	result = result && lt.LoadTypeEntered == 0
+
+	return result
+}
+
+// проверка на пустую запись о калибровке
+func CalibrationRecordIsEmpty(cr *WorkshopCalibrationRecord) bool {
+	var result bool
+
+	result = cr.CalibrationPurpose == 0
+	result = result && cr.VehicleIdentificationNumber == ""
+	result = result && cr.NewTimeValue == time.Unix(0, 0).UTC()
+
+	return result
+}
+
+// проверка на пустую запись о контроле
+func ControlActivityRecordIsEmpty(cr *ControlActivityRecord) bool {
+	var result bool
+
+	result = cr.ControlTime == time.Unix(0, 0).UTC()
+	result = result && cr.ControlType == 0
+	result = result && cr.ControlledCardNumber == ""
+
+	return result
+}
+
+// проверка на пустую запись о действии с картой предприятия
+func CompanyActivityRecordIsEmpty(cr *CompanyActivityRecord) bool {
+	var result bool
+
+	result = cr.CompanyActivityTime == time.Unix(0, 0).UTC()
+	result = result && cr.CompanyActivityType == 0
+	result = result && cr.VehicleRegistrationNumber == ""
+
+	return result
+}
@@ -0,0 +1,156 @@
+package ddd
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Сводка использования транспортного средства по записям карты. UsageTime - суммарная
+// продолжительность использования в минутах, Distance - пробег по показаниям одометра
+// завершенных сеансов с учетом перехода одометра через ноль.
+// Для сверки с суточными записями о деятельности: Days - количество суток (UTC), в которые
+// использовалось ТС, ActivityDistance - сумма пройденного за сутки расстояния (ActivityDayDistance)
+// за сутки, в которые использовалось только это ТС, SharedDays - количество суток, в которые
+// использовались и другие ТС (их расстояние в ActivityDistance не входит).
+type VehicleUsage struct {
+	VehicleRegistrationNation    int         `json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `json:"vehicle_registration_number"`
+	VehicleIdentificationNumber  string      `json:"vehicle_identification_number,omitempty"`
+	Sessions                     int         `json:"sessions"`
+	FirstUse                     time.Time   `json:"first_use"`
+	LastUse                      time.Time   `json:"last_use"`
+	UsageTime                    int         `json:"usage_time"`
+	Distance                     int         `json:"distance"`
+	Days                         int         `json:"days"`
+	ActivityDistance             int         `json:"activity_distance"`
+	SharedDays                   int         `json:"shared_days"`
+}
+
+type VehicleUsages []VehicleUsage
+
+// ТС определяется страной и номером регистрации
+type vehicleKey struct {
+	nation int
+	number string
+}
+
+// Запись об использовании ТС независимо от поколения карты
+type vehicleSession struct {
+	nation        int
+	number        string
+	vin           string
+	firstUse      time.Time
+	lastUse       time.Time
+	odometerBegin int
+	odometerEnd   int
+}
+
+// Метод преобразует записи карты в сеансы использования ТС
+func (r CardVehicleRecords) sessions() []vehicleSession {
+	sessions := []vehicleSession{}
+	for _, v := range r {
+		sessions = append(sessions, vehicleSession{v.VehicleRegistrationNation, v.VehicleRegistrationNumber, "",
+			v.VehicleFirstUse, v.VehicleLastUse, v.VehicleOdometerBegin, v.VehicleOdometerEnd})
+	}
+	return sessions
+}
+
+// Метод преобразует записи карты второго поколения в сеансы использования ТС
+func (r CardVehicleRecordsG2) sessions() []vehicleSession {
+	sessions := []vehicleSession{}
+	for _, v := range r {
+		sessions = append(sessions, vehicleSession{v.VehicleRegistrationNation, v.VehicleRegistrationNumber,
+			v.VehicleIdentificationNumber, v.VehicleFirstUse, v.VehicleLastUse, v.VehicleOdometerBegin, v.VehicleOdometerEnd})
+	}
+	return sessions
+}
+
+// Метод формирует сводку использования ТС по записям карты и суточным записям о деятельности days
+func (r CardVehicleRecords) Usage(days ActivityDailyRecords) VehicleUsages {
+	return vehicleUsage(r.sessions(), days)
+}
+
+// Метод формирует сводку использования ТС по записям карты второго поколения
+func (r CardVehicleRecordsG2) Usage(days ActivityDailyRecords) VehicleUsages {
+	return vehicleUsage(r.sessions(), days)
+}
+
+// Функция группирует записи об использовании по стране и номеру регистрации ТС.
+// Сводки упорядочиваются по времени первого использования.
+func vehicleUsage(sessions []vehicleSession, days ActivityDailyRecords) VehicleUsages {
+	usage := map[vehicleKey]*VehicleUsage{}
+	vehicleDays := map[vehicleKey]map[time.Time]bool{}
+	dayVehicles := map[time.Time]int{}
+
+	for _, s := range sessions {
+		key := vehicleKey{s.nation, s.number}
+		u, ok := usage[key]
+		if !ok {
+			u = &VehicleUsage{
+				VehicleRegistrationNation: s.nation,
+				VehicleRegistrationNumber: s.number,
+				FirstUse:                  s.firstUse,
+				LastUse:                   s.lastUse,
+			}
+			usage[key] = u
+			vehicleDays[key] = map[time.Time]bool{}
+		}
+
+		u.Sessions++
+		if s.vin != "" {
+			u.VehicleIdentificationNumber = s.vin
+		}
+		if s.firstUse.Before(u.FirstUse) {
+			u.FirstUse = s.firstUse
+		}
+		lastUse := s.lastUse
+		if lastUse.Before(s.firstUse) {
+			// сеанс не завершен или время окончания не записано
+			lastUse = s.firstUse
+		}
+		if lastUse.After(u.LastUse) {
+			u.LastUse = lastUse
+		}
+		u.UsageTime += int(lastUse.Sub(s.firstUse) / time.Minute)
+		if s.completed() {
+			distance, _ := odometerDistance(s.odometerBegin, s.odometerEnd)
+			u.Distance += distance
+		}
+
+		for day := dayStart(s.firstUse); !day.After(lastUse); day = day.AddDate(0, 0, 1) {
+			if !vehicleDays[key][day] {
+				vehicleDays[key][day] = true
+				dayVehicles[day]++
+			}
+		}
+	}
+
+	dayDistance := map[time.Time]int{}
+	for _, d := range days {
+		dayDistance[dayStart(d.ActivityRecordDate)] += d.ActivityDayDistance
+	}
+
+	result := VehicleUsages{}
+	for key, u := range usage {
+		for day := range vehicleDays[key] {
+			u.Days++
+			if dayVehicles[day] > 1 {
+				u.SharedDays++
+				continue
+			}
+			u.ActivityDistance += dayDistance[day]
+		}
+		fillIsoCodes(reflect.ValueOf(u).Elem())
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FirstUse.Equal(result[j].FirstUse) {
+			return result[i].FirstUse.Before(result[j].FirstUse)
+		}
+		return result[i].VehicleRegistrationNumber < result[j].VehicleRegistrationNumber
+	})
+
+	return result
+}
@@ -0,0 +1,91 @@
+package ddd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVehicleUsage(t *testing.T) {
+	records := CardVehicleRecords{
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 100,
+			VehicleOdometerEnd: 300, VehicleFirstUse: monday.Add(6 * time.Hour), VehicleLastUse: monday.Add(10 * time.Hour)},
+		{VehicleRegistrationNation: 0x11, VehicleRegistrationNumber: "XY9", VehicleOdometerBegin: 500,
+			VehicleOdometerEnd: 550, VehicleFirstUse: monday.Add(12 * time.Hour), VehicleLastUse: monday.Add(13 * time.Hour)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 300,
+			VehicleOdometerEnd: 700, VehicleFirstUse: monday.Add(30 * time.Hour), VehicleLastUse: monday.Add(50 * time.Hour)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 700,
+			VehicleFirstUse: monday.Add(80 * time.Hour)},
+	}
+	days := ActivityDailyRecords{
+		{ActivityRecordDate: monday, ActivityDayDistance: 250},
+		{ActivityRecordDate: monday.AddDate(0, 0, 1), ActivityDayDistance: 150},
+		{ActivityRecordDate: monday.AddDate(0, 0, 2), ActivityDayDistance: 250},
+		{ActivityRecordDate: monday.AddDate(0, 0, 3), ActivityDayDistance: 0},
+	}
+
+	usage := records.Usage(days)
+	if len(usage) != 2 {
+		t.Fatalf("expected 2 vehicles, got %+v", usage)
+	}
+
+	// незавершенный сеанс учитывается в количестве и времени последнего использования,
+	// в понедельник использовались оба ТС
+	ab := usage[0]
+	if ab.VehicleRegistrationNumber != "AB123" || ab.Sessions != 3 || ab.Distance != 600 || ab.UsageTime != 240+1200 {
+		t.Errorf("unexpected AB123 usage %+v", ab)
+	}
+	if !ab.FirstUse.Equal(monday.Add(6*time.Hour)) || !ab.LastUse.Equal(monday.Add(80*time.Hour)) {
+		t.Errorf("unexpected AB123 use period %v - %v", ab.FirstUse, ab.LastUse)
+	}
+	if ab.Days != 4 || ab.SharedDays != 1 || ab.ActivityDistance != 400 {
+		t.Errorf("expected 4 days, 1 shared, distance 400, got %d, %d, %d", ab.Days, ab.SharedDays, ab.ActivityDistance)
+	}
+	if ab.VehicleRegistrationNationIso == nil || ab.VehicleRegistrationNationIso.Alpha2 != "DE" {
+		t.Errorf("unexpected nation %+v", ab.VehicleRegistrationNationIso)
+	}
+
+	xy := usage[1]
+	if xy.VehicleRegistrationNumber != "XY9" || xy.Distance != 50 || xy.Days != 1 || xy.SharedDays != 1 || xy.ActivityDistance != 0 {
+		t.Errorf("unexpected XY9 usage %+v", xy)
+	}
+}
+
+func TestCardVehicleUsage(t *testing.T) {
+	card, err := ParseBytes(buildDriverDDD())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(card.VehicleUsage) == 0 {
+		t.Fatalf("expected vehicle usage for %+v", card.CardVehicleRecords)
+	}
+
+	sessions := 0
+	for _, u := range card.VehicleUsage {
+		sessions += u.Sessions
+	}
+	if sessions != len(card.CardVehicleRecords) {
+		t.Errorf("expected %d sessions, got %d", len(card.CardVehicleRecords), sessions)
+	}
+}
+
+func TestVehicleUsageOdometerRollover(t *testing.T) {
+	records := CardVehicleRecords{
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 999800,
+			VehicleOdometerEnd: 150, VehicleFirstUse: monday.Add(6 * time.Hour), VehicleLastUse: monday.Add(12 * time.Hour)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 150,
+			VehicleOdometerEnd: 400, VehicleFirstUse: monday.Add(30 * time.Hour), VehicleLastUse: monday.Add(34 * time.Hour)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 400,
+			VehicleOdometerEnd: 100, VehicleFirstUse: monday.Add(54 * time.Hour), VehicleLastUse: monday.Add(58 * time.Hour)},
+		{VehicleRegistrationNation: 0x0D, VehicleRegistrationNumber: "AB123", VehicleOdometerBegin: 999900,
+			VehicleFirstUse: monday.Add(78 * time.Hour)},
+	}
+
+	usage := records.Usage(ActivityDailyRecords{})
+	if len(usage) != 1 || usage[0].Sessions != 4 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	// 350 км через ноль и 250 км; уменьшение показаний и незавершенный сеанс не учитываются
+	if usage[0].Distance != 600 {
+		t.Errorf("expected distance 600, got %d", usage[0].Distance)
+	}
+}
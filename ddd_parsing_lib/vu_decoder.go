@@ -0,0 +1,88 @@
+package ddd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Описание части блока данных ВБУ (TREP). Части фиксированной длины имеют counterLen = 0,
+// для списков записей counterLen - размер счетчика записей перед ними.
+type vuBlockPart struct {
+	name       string
+	counterLen int
+	recordLen  int
+}
+
+// Структура блоков данных ВБУ первого поколения по приложению 1B (дополнение 7)
+var vuBlockParts = map[byte][]vuBlockPart{
+	// обзор
+	0x01: {{"", 0, 491}, {"locks", 1, 98}, {"controls", 1, 31}, {"signature", 0, 128}},
+	// деятельность за день
+	0x02: {{"", 0, 7}, {"cards", 2, 129}, {"activities", 2, 2}, {"places", 1, 28},
+		{"conditions", 2, 5}, {"signature", 0, 128}},
+	// события и неисправности
+	0x03: {{"faults", 1, 82}, {"events", 1, 83}, {"overspeed_control", 0, 9},
+		{"overspeed_events", 1, 31}, {"time_adjustments", 1, 98}, {"signature", 0, 128}},
+	// подробные данные о скорости
+	0x04: {{"speed_blocks", 2, 64}, {"signature", 0, 128}},
+	// технические данные
+	0x05: {{"", 0, 136}, {"calibrations", 1, 167}, {"signature", 0, 128}},
+}
+
+// Функция разбивает выгрузку ВБУ на блоки данных (TREP). Каждый блок представлен словарем
+// вида {Тэг: Значение}, где основная часть блока хранится под тэгом "76XX", а списки записей
+// под тэгами "76XX:<часть>", например "7601:locks".
+// При ошибке возвращаются блоки, прочитанные до нее, последний блок может быть неполным.
+func extractVuBlocks(ddd []byte) ([]map[string][]byte, error) {
+	var result []map[string][]byte
+
+	offset := 0
+	trepCountBytes := 2
+
+	for offset < len(ddd) {
+		trep, err := readBytes("", ddd, trepCountBytes, offset)
+		if err != nil {
+			return result, err
+		}
+		tag := fmt.Sprintf("%X", trep)
+
+		parts, ok := vuBlockParts[trep[1]]
+		if trep[0] != 0x76 || !ok {
+			return result, &SectionError{tag, errors.New("Unknown TREP")}
+		}
+		offset = offset + trepCountBytes
+
+		block := map[string][]byte{tag: []byte{}}
+		result = append(result, block)
+
+		for _, p := range parts {
+			partTag := tag
+			if p.name != "" {
+				partTag = tag + ":" + p.name
+			}
+
+			count := 1
+			if p.counterLen > 0 {
+				counter, err := readBytes(partTag, ddd, p.counterLen, offset)
+				if err != nil {
+					return result, err
+				}
+				if count, err = bytesToInt(counter); err != nil {
+					return result, &SectionError{partTag, err}
+				}
+				offset = offset + p.counterLen
+			}
+
+			val, err := readBytes(partTag, ddd, count*p.recordLen, offset)
+			if err != nil {
+				// сохраняем доступную часть, чтобы разобрать из нее то, что возможно
+				block[partTag] = ddd[offset:]
+				return result, err
+			}
+			block[partTag] = val
+			offset = offset + len(val)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,355 @@
+package ddd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+)
+
+type VuCompanyLocksRecord struct {
+	LockInTime                    time.Time `tlv:"7601:locks 4 0 date" json:"lock_in_time"`
+	LockOutTime                   time.Time `tlv:"7601:locks 4 4 date" json:"lock_out_time"`
+	CompanyName                   string    `tlv:"7601:locks 36 8 string" json:"company_name"`
+	CompanyAddress                string    `tlv:"7601:locks 36 44 string" json:"company_address"`
+	CompanyCardTypeId             int       `tlv:"7601:locks 1 80 int" json:"company_card_type_id"`
+	CompanyCardIssuingMemberState int       `tlv:"7601:locks 1 81 int" json:"company_card_issuing_member_state"`
+	CompanyCardNumber             string    `tlv:"7601:locks 16 82 string" json:"company_card_number"`
+}
+
+type VuCompanyLocksRecords []VuCompanyLocksRecord
+
+type VuControlActivityRecord struct {
+	ControlTypeId                 ControlType `tlv:"7601:controls 1 0 int" json:"control_type_id"`
+	ControlTypeName               string      `code:"ControlTypeId" json:"control_type_name"`
+	ControlTime                   time.Time   `tlv:"7601:controls 4 1 date" json:"control_time"`
+	ControlCardTypeId             int         `tlv:"7601:controls 1 5 int" json:"control_card_type_id"`
+	ControlCardIssuingMemberState int         `tlv:"7601:controls 1 6 int" json:"control_card_issuing_member_state"`
+	ControlCardNumber             string      `tlv:"7601:controls 16 7 string" json:"control_card_number"`
+	DownloadPeriodBeginTime       time.Time   `tlv:"7601:controls 4 23 date" json:"download_period_begin_time"`
+	DownloadPeriodEndTime         time.Time   `tlv:"7601:controls 4 27 date" json:"download_period_end_time"`
+}
+
+type VuControlActivityRecords []VuControlActivityRecord
+
+type VuOverview struct {
+	MemberStateCertificate       string                   `tlv:"7601 194 0 hexadecimal" json:"member_state_certificate"`
+	VuCertificate                string                   `tlv:"7601 194 194 hexadecimal" json:"vu_certificate"`
+	VehicleIdentificationNumber  string                   `tlv:"7601 17 388 string" json:"vehicle_identification_number"`
+	VehicleRegistrationNation    int                      `tlv:"7601 1 405 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo              `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string                   `tlv:"7601 14 406 string" json:"vehicle_registration_number"`
+	CurrentDateTime              time.Time                `tlv:"7601 4 420 date" json:"current_date_time"`
+	MinDownloadableTime          time.Time                `tlv:"7601 4 424 date" json:"min_downloadable_time"`
+	MaxDownloadableTime          time.Time                `tlv:"7601 4 428 date" json:"max_downloadable_time"`
+	CardSlotsStatus              int                      `tlv:"7601 1 432 int" json:"card_slots_status"`
+	DownloadingTime              time.Time                `tlv:"7601 4 433 date" json:"downloading_time"`
+	DownloadCardTypeId           int                      `tlv:"7601 1 437 int" json:"download_card_type_id"`
+	DownloadCardIssuingState     int                      `tlv:"7601 1 438 int" json:"download_card_issuing_member_state"`
+	DownloadCardNumber           string                   `tlv:"7601 16 439 string" json:"download_card_number"`
+	CompanyOrWorkshopName        string                   `tlv:"7601 36 455 string" json:"company_or_workshop_name"`
+	CompanyLocks                 VuCompanyLocksRecords    `json:"company_locks"`
+	ControlActivities            VuControlActivityRecords `json:"control_activities"`
+}
+
+type VuCardIWRecord struct {
+	HolderSurname                     string      `tlv:"7602:cards 36 0 string" json:"holder_surname"`
+	HolderFirstNames                  string      `tlv:"7602:cards 36 36 string" json:"holder_first_names"`
+	CardTypeId                        int         `tlv:"7602:cards 1 72 int" json:"card_type_id"`
+	CardIssuingMemberState            int         `tlv:"7602:cards 1 73 int" json:"card_issuing_member_state"`
+	CardIssuingMemberStateIso         *NationInfo `iso:"CardIssuingMemberState" json:"card_issuing_member_state_iso,omitempty"`
+	CardNumber                        string      `tlv:"7602:cards 16 74 string" json:"card_number"`
+	CardExpiryDate                    time.Time   `tlv:"7602:cards 4 90 date" json:"card_expiry_date"`
+	CardInsertionTime                 time.Time   `tlv:"7602:cards 4 94 date" json:"card_insertion_time"`
+	VehicleOdometerValueAtInsertion   int         `tlv:"7602:cards 3 98 int" json:"vehicle_odometer_value_at_insertion"`
+	CardSlotNumber                    int         `tlv:"7602:cards 1 101 int" json:"card_slot_number"`
+	CardWithdrawalTime                time.Time   `tlv:"7602:cards 4 102 date" json:"card_withdrawal_time"`
+	VehicleOdometerValueAtWithdrawal  int         `tlv:"7602:cards 3 106 int" json:"vehicle_odometer_value_at_withdrawal"`
+	PreviousVehicleRegistrationNation int         `tlv:"7602:cards 1 109 int" json:"previous_vehicle_registration_nation"`
+	PreviousVehicleRegistrationNumber string      `tlv:"7602:cards 14 110 string" json:"previous_vehicle_registration_number"`
+	PreviousCardWithdrawalTime        time.Time   `tlv:"7602:cards 4 124 date" json:"previous_card_withdrawal_time"`
+	ManualInputFlag                   int         `tlv:"7602:cards 1 128 int" json:"manual_input_flag"`
+}
+
+type VuCardIWRecords []VuCardIWRecord
+
+type VuPlaceRecord struct {
+	CardTypeId                int                      `tlv:"7602:places 1 0 int" json:"card_type_id"`
+	CardIssuingMemberState    int                      `tlv:"7602:places 1 1 int" json:"card_issuing_member_state"`
+	CardIssuingMemberStateIso *NationInfo              `iso:"CardIssuingMemberState" json:"card_issuing_member_state_iso,omitempty"`
+	CardNumber                string                   `tlv:"7602:places 16 2 string" json:"card_number"`
+	EntryTime                 time.Time                `tlv:"7602:places 4 18 date" json:"entry_time"`
+	TypePeriodId              EntryTypeDailyWorkPeriod `tlv:"7602:places 1 22 int" json:"type_period_id"`
+	TypePeriodName            string                   `code:"TypePeriodId" json:"type_period_name"`
+	DailyWorkPeriodCountry    int                      `tlv:"7602:places 1 23 int" json:"daily_work_period_country"`
+	DailyWorkPeriodCountryIso *NationInfo              `iso:"DailyWorkPeriodCountry" json:"daily_work_period_country_iso,omitempty"`
+	DailyWorkPeriodRegion     int                      `tlv:"7602:places 1 24 int" json:"daily_work_period_region"`
+	DailyWorkPeriodRegionIso  *RegionInfo              `iso:"DailyWorkPeriodRegion DailyWorkPeriodCountry" json:"daily_work_period_region_iso,omitempty"`
+	VehicleOdometerValue      int                      `tlv:"7602:places 3 25 int" json:"vehicle_odometer_value"`
+}
+
+type VuPlaceRecords []VuPlaceRecord
+
+type VuSpecificConditionRecord struct {
+	EntryTime                 time.Time             `tlv:"7602:conditions 4 0 date" json:"entry_time"`
+	SpecificConditionTypeId   SpecificConditionType `tlv:"7602:conditions 1 4 int" json:"specific_condition_type_id"`
+	SpecificConditionTypeName string                `code:"SpecificConditionTypeId" json:"specific_condition_type_name"`
+}
+
+type VuSpecificConditionRecords []VuSpecificConditionRecord
+
+type VuDailyActivity struct {
+	TimeReal                 time.Time                  `tlv:"7602 4 0 date" json:"time_real"`
+	OdometerValueMidnight    int                        `tlv:"7602 3 4 int" json:"odometer_value_midnight"`
+	CardIWRecords            VuCardIWRecords            `json:"card_iw_records"`
+	ActivitiesS              string                     `tlv:"7602:activities -1 0 activites" json:"activities_s"`
+	ActivityChangeInfos      []ActivityChangeInfo       `json:"activity_change_infos"`
+	PlaceRecords             VuPlaceRecords             `json:"place_records"`
+	SpecificConditionRecords VuSpecificConditionRecords `json:"specific_condition_records"`
+}
+
+func (da *VuDailyActivity) ParseChangeInfo() error {
+	acis, err := parseChangeInfos(da.TimeReal, da.ActivitiesS)
+	da.ActivityChangeInfos = acis
+	if err != nil {
+		log.Println("Can't upload change info for vu daily activity")
+	}
+
+	return err
+}
+
+type VuDailyActivities []VuDailyActivity
+
+type VuFaultRecord struct {
+	FaultTypeId                 EventFaultType `tlv:"7603:faults 1 0 int" json:"fault_type_id"`
+	FaultTypeName               string         `code:"FaultTypeId" json:"fault_type_name"`
+	FaultRecordPurpose          int            `tlv:"7603:faults 1 1 int" json:"fault_record_purpose"`
+	FaultBeginTime              time.Time      `tlv:"7603:faults 4 2 date" json:"fault_begin_time"`
+	FaultEndTime                time.Time      `tlv:"7603:faults 4 6 date" json:"fault_end_time"`
+	CardNumberDriverSlotBegin   string         `tlv:"7603:faults 16 12 string" json:"card_number_driver_slot_begin"`
+	CardNumberCodriverSlotBegin string         `tlv:"7603:faults 16 30 string" json:"card_number_codriver_slot_begin"`
+	CardNumberDriverSlotEnd     string         `tlv:"7603:faults 16 48 string" json:"card_number_driver_slot_end"`
+	CardNumberCodriverSlotEnd   string         `tlv:"7603:faults 16 66 string" json:"card_number_codriver_slot_end"`
+}
+
+type VuFaultRecords []VuFaultRecord
+
+type VuEventRecord struct {
+	EventTypeId                 EventFaultType `tlv:"7603:events 1 0 int" json:"event_type_id"`
+	EventTypeName               string         `code:"EventTypeId" json:"event_type_name"`
+	EventRecordPurpose          int            `tlv:"7603:events 1 1 int" json:"event_record_purpose"`
+	EventBeginTime              time.Time      `tlv:"7603:events 4 2 date" json:"event_begin_time"`
+	EventEndTime                time.Time      `tlv:"7603:events 4 6 date" json:"event_end_time"`
+	CardNumberDriverSlotBegin   string         `tlv:"7603:events 16 12 string" json:"card_number_driver_slot_begin"`
+	CardNumberCodriverSlotBegin string         `tlv:"7603:events 16 30 string" json:"card_number_codriver_slot_begin"`
+	CardNumberDriverSlotEnd     string         `tlv:"7603:events 16 48 string" json:"card_number_driver_slot_end"`
+	CardNumberCodriverSlotEnd   string         `tlv:"7603:events 16 66 string" json:"card_number_codriver_slot_end"`
+	SimilarEventsNumber         int            `tlv:"7603:events 1 82 int" json:"similar_events_number"`
+}
+
+type VuEventRecords []VuEventRecord
+
+type VuOverSpeedingEventRecord struct {
+	EventTypeId               EventFaultType `tlv:"7603:overspeed_events 1 0 int" json:"event_type_id"`
+	EventTypeName             string         `code:"EventTypeId" json:"event_type_name"`
+	EventRecordPurpose        int            `tlv:"7603:overspeed_events 1 1 int" json:"event_record_purpose"`
+	EventBeginTime            time.Time      `tlv:"7603:overspeed_events 4 2 date" json:"event_begin_time"`
+	EventEndTime              time.Time      `tlv:"7603:overspeed_events 4 6 date" json:"event_end_time"`
+	MaxSpeedValue             int            `tlv:"7603:overspeed_events 1 10 int" json:"max_speed_value"`
+	AverageSpeedValue         int            `tlv:"7603:overspeed_events 1 11 int" json:"average_speed_value"`
+	CardNumberDriverSlotBegin string         `tlv:"7603:overspeed_events 16 14 string" json:"card_number_driver_slot_begin"`
+	SimilarEventsNumber       int            `tlv:"7603:overspeed_events 1 30 int" json:"similar_events_number"`
+}
+
+type VuOverSpeedingEventRecords []VuOverSpeedingEventRecord
+
+type VuTimeAdjustmentRecord struct {
+	OldTimeValue       time.Time `tlv:"7603:time_adjustments 4 0 date" json:"old_time_value"`
+	NewTimeValue       time.Time `tlv:"7603:time_adjustments 4 4 date" json:"new_time_value"`
+	WorkshopName       string    `tlv:"7603:time_adjustments 36 8 string" json:"workshop_name"`
+	WorkshopAddress    string    `tlv:"7603:time_adjustments 36 44 string" json:"workshop_address"`
+	WorkshopCardNumber string    `tlv:"7603:time_adjustments 16 82 string" json:"workshop_card_number"`
+}
+
+type VuTimeAdjustmentRecords []VuTimeAdjustmentRecord
+
+type VuEventsAndFaults struct {
+	Faults                            VuFaultRecords             `json:"faults"`
+	Events                            VuEventRecords             `json:"events"`
+	LastOverspeedControlTime          time.Time                  `tlv:"7603:overspeed_control 4 0 date" json:"last_overspeed_control_time"`
+	FirstOverspeedSince               time.Time                  `tlv:"7603:overspeed_control 4 4 date" json:"first_overspeed_since"`
+	NumberOfOverspeedSinceLastControl int                        `tlv:"7603:overspeed_control 1 8 int" json:"number_of_overspeed_since_last_control"`
+	OverSpeedingEvents                VuOverSpeedingEventRecords `json:"over_speeding_events"`
+	TimeAdjustments                   VuTimeAdjustmentRecords    `json:"time_adjustments"`
+}
+
+type VuDetailedSpeedBlock struct {
+	SpeedBlockBeginDate time.Time `tlv:"7604:speed_blocks 4 0 date" json:"speed_block_begin_date"`
+	SpeedsPerSecond     []int     `tlv:"7604:speed_blocks 60 4 intlist" json:"speeds_per_second"`
+}
+
+type VuDetailedSpeedBlocks []VuDetailedSpeedBlock
+
+type VuCalibrationRecord struct {
+	CalibrationPurpose           int         `tlv:"7605:calibrations 1 0 int" json:"calibration_purpose"`
+	WorkshopName                 string      `tlv:"7605:calibrations 36 1 string" json:"workshop_name"`
+	WorkshopAddress              string      `tlv:"7605:calibrations 36 37 string" json:"workshop_address"`
+	WorkshopCardNumber           string      `tlv:"7605:calibrations 16 75 string" json:"workshop_card_number"`
+	WorkshopCardExpiryDate       time.Time   `tlv:"7605:calibrations 4 91 date" json:"workshop_card_expiry_date"`
+	VehicleIdentificationNumber  string      `tlv:"7605:calibrations 17 95 string" json:"vehicle_identification_number"`
+	VehicleRegistrationNation    int         `tlv:"7605:calibrations 1 112 int" json:"vehicle_registration_nation"`
+	VehicleRegistrationNationIso *NationInfo `iso:"VehicleRegistrationNation" json:"vehicle_registration_nation_iso,omitempty"`
+	VehicleRegistrationNumber    string      `tlv:"7605:calibrations 14 113 string" json:"vehicle_registration_number"`
+	WVehicleCharacteristicConst  int         `tlv:"7605:calibrations 2 127 int" json:"w_vehicle_characteristic_constant"`
+	KConstantOfRecordingEquip    int         `tlv:"7605:calibrations 2 129 int" json:"k_constant_of_recording_equipment"`
+	LTyreCircumference           int         `tlv:"7605:calibrations 2 131 int" json:"l_tyre_circumference"`
+	TyreSize                     string      `tlv:"7605:calibrations 15 133 string" json:"tyre_size"`
+	AuthorisedSpeed              int         `tlv:"7605:calibrations 1 148 int" json:"authorised_speed"`
+	OldOdometerValue             int         `tlv:"7605:calibrations 3 149 int" json:"old_odometer_value"`
+	NewOdometerValue             int         `tlv:"7605:calibrations 3 152 int" json:"new_odometer_value"`
+	OldTimeValue                 time.Time   `tlv:"7605:calibrations 4 155 date" json:"old_time_value"`
+	NewTimeValue                 time.Time   `tlv:"7605:calibrations 4 159 date" json:"new_time_value"`
+	NextCalibrationDate          time.Time   `tlv:"7605:calibrations 4 163 date" json:"next_calibration_date"`
+}
+
+type VuCalibrationRecords []VuCalibrationRecord
+
+type VuTechnicalData struct {
+	VuManufacturerName     string               `tlv:"7605 36 0 string" json:"vu_manufacturer_name"`
+	VuManufacturerAddress  string               `tlv:"7605 36 36 string" json:"vu_manufacturer_address"`
+	VuPartNumber           string               `tlv:"7605 16 72 string" json:"vu_part_number"`
+	VuSerialNumber         string               `tlv:"7605 8 88 hexadecimal" json:"vu_serial_number"`
+	VuSoftwareVersion      string               `tlv:"7605 4 96 string" json:"vu_software_version"`
+	VuSoftInstallationDate time.Time            `tlv:"7605 4 100 date" json:"vu_soft_installation_date"`
+	VuManufacturingDate    time.Time            `tlv:"7605 4 104 date" json:"vu_manufacturing_date"`
+	VuApprovalNumber       string               `tlv:"7605 8 108 string" json:"vu_approval_number"`
+	SensorSerialNumber     string               `tlv:"7605 8 116 hexadecimal" json:"sensor_serial_number"`
+	SensorApprovalNumber   string               `tlv:"7605 8 124 string" json:"sensor_approval_number"`
+	SensorPairingDateFirst time.Time            `tlv:"7605 4 132 date" json:"sensor_pairing_date_first"`
+	CalibrationRecords     VuCalibrationRecords `json:"calibration_records"`
+}
+
+// Названия блоков данных ВБУ (TREP) по приложению 1B
+var vuSectionNames = map[string]string{
+	"7601": "VU_Overview",
+	"7602": "VU_Activities",
+	"7603": "VU_Events_And_Faults",
+	"7604": "VU_Detailed_Speed",
+	"7605": "VU_Technical_Data",
+}
+
+// Данные, выгруженные с бортового устройства (ВБУ) первого поколения
+type Vu struct {
+	Overview        VuOverview
+	Activities      VuDailyActivities
+	EventsAndFaults VuEventsAndFaults
+	DetailedSpeed   VuDetailedSpeedBlocks
+	TechnicalData   VuTechnicalData
+	Report          ParseReport
+}
+
+// Функция разбирает выгрузку ВБУ, прочитанную из r.
+func ParseVu(r io.Reader) (*Vu, error) {
+	ddd, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Can't read ddd file: %v", err)
+	}
+
+	return ParseVuBytes(ddd)
+}
+
+// Функция разбирает содержимое выгрузки ВБУ.
+// При ошибке разбора возвращаются данные, которые удалось загрузить.
+func ParseVuBytes(ddd []byte) (*Vu, error) {
+	v := &Vu{}
+	err := v.ParseFromDDD(ddd)
+
+	return v, err
+}
+
+// Метод заполняет данные ВБУ из ddd файла.
+// Ошибка в одном блоке не прерывает разбор остальных, результат обработки
+// каждого блока записывается в отчет Report. Возвращается первая возникшая ошибка.
+func (v *Vu) ParseFromDDD(ddd []byte) error {
+	blocks, err := extractVuBlocks(ddd)
+
+	found := map[string][]byte{}
+	for _, b := range blocks {
+		for tag, val := range b {
+			if !strings.Contains(tag, ":") {
+				found[tag] = val
+			}
+		}
+	}
+	v.Report = newParseReport(vuSectionNames, found)
+
+	if err != nil && len(blocks) == 0 {
+		return fmt.Errorf("Parse block error: %v", err)
+	}
+
+	var firstErr error
+	if err != nil {
+		// файл обрезан, последний блок загружен не полностью
+		v.Report.setStatus(errorTag(err), SectionPartial, err.Error())
+		firstErr = fmt.Errorf("Parse block error: %v", err)
+	}
+
+	check := func(tag string, target interface{}, err error, errMsg string) {
+		v.Report.addLoadResult([]string{tag}, target, err)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %v", errMsg, err)
+		}
+	}
+	load := func(tag string, target interface{}, block map[string][]byte, errMsg string) {
+		check(tag, target, loadFields(target, block), errMsg)
+	}
+
+	for _, b := range blocks {
+		if _, ok := b["7601"]; ok {
+			load("7601", &v.Overview, b, "Overview load error")
+			load("7601", &v.Overview.CompanyLocks, b, "Company locks load error")
+			load("7601", &v.Overview.ControlActivities, b, "Control activity load error")
+		}
+
+		if _, ok := b["7602"]; ok {
+			day := VuDailyActivity{}
+			load("7602", &day, b, "Daily activity load error")
+			if err := day.ParseChangeInfo(); err != nil {
+				check("7602", &day, &SectionError{"7602:activities", err}, "Daily activity load error")
+			}
+			load("7602", &day.CardIWRecords, b, "Card insertion record load error")
+			load("7602", &day.PlaceRecords, b, "Place record load error")
+			load("7602", &day.SpecificConditionRecords, b, "Specific condition record load error")
+			v.Activities = append(v.Activities, day)
+		}
+
+		if _, ok := b["7603"]; ok {
+			load("7603", &v.EventsAndFaults, b, "Overspeed control load error")
+			load("7603", &v.EventsAndFaults.Faults, b, "Fault record load error")
+			load("7603", &v.EventsAndFaults.Events, b, "Event record load error")
+			load("7603", &v.EventsAndFaults.OverSpeedingEvents, b, "Overspeeding event load error")
+			load("7603", &v.EventsAndFaults.TimeAdjustments, b, "Time adjustment load error")
+		}
+
+		if _, ok := b["7604"]; ok {
+			load("7604", &v.DetailedSpeed, b, "Detailed speed load error")
+		}
+
+		if _, ok := b["7605"]; ok {
+			load("7605", &v.TechnicalData, b, "Technical data load error")
+			load("7605", &v.TechnicalData.CalibrationRecords, b, "Calibration record load error")
+		}
+	}
+
+	return firstErr
+}
+
+// метод для экспорта данных ВБУ
+func (v *Vu) ExportToJson() (string, error) {
+	vu_json, err := json.Marshal(v)
+
+	return string(vu_json), err
+}
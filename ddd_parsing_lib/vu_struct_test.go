@@ -0,0 +1,135 @@
+package ddd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Функция формирует выгрузку ВБУ первого поколения: обзор с одной блокировкой компании,
+// двое суток деятельности, события и неисправности, детальная скорость и одна калибровка.
+// Подписи блоков заполнены нулями.
+func buildVu() []byte {
+	var out []byte
+	ov := make([]byte, 491)
+	copy(ov[388:], strField("WDB1234567890ABCD", 17))
+	ov[405] = 0x11
+	copy(ov[406:], append([]byte{0x01}, strField("AB123", 13)...))
+	copy(ov[420:], ts(day0))
+	out = append(out, 0x76, 0x01)
+	out = append(out, ov...)
+	out = append(out, 1)
+	lock := make([]byte, 98)
+	copy(lock, ts(day0))
+	copy(lock[8:], append([]byte{0x01}, strField("ACME", 35)...))
+	out = append(out, lock...)
+	out = append(out, 0)
+	out = append(out, make([]byte, 128)...)
+	for d := 0; d < 2; d++ {
+		out = append(out, 0x76, 0x02)
+		out = append(out, ts(day0.AddDate(0, 0, d))...)
+		out = append(out, 0, 0x10, 0)
+		out = append(out, 0, 1)
+		iw := make([]byte, 129)
+		copy(iw, append([]byte{0x01}, strField("DOE", 35)...))
+		copy(iw[94:], ts(day0.AddDate(0, 0, d).Add(6*time.Hour)))
+		out = append(out, iw...)
+		out = append(out, 0, 3)
+		out = append(out, aci(0, 0, 1, 0, 0)...)
+		out = append(out, aci(0, 0, 1, 3, 60)...)
+		out = append(out, aci(0, 0, 1, 0, 600)...)
+		out = append(out, 0)
+		out = append(out, 0, 0)
+		out = append(out, make([]byte, 128)...)
+	}
+	out = append(out, 0x76, 0x03, 0, 1)
+	out = append(out, make([]byte, 83)...)
+	out = append(out, make([]byte, 9)...)
+	out = append(out, 0, 0)
+	out = append(out, make([]byte, 128)...)
+	out = append(out, 0x76, 0x04, 0, 1)
+	sb := append(ts(day0), make([]byte, 60)...)
+	sb[10] = 80
+	out = append(out, sb...)
+	out = append(out, make([]byte, 128)...)
+	out = append(out, 0x76, 0x05)
+	out = append(out, make([]byte, 136)...)
+	out = append(out, 1)
+	cal := make([]byte, 167)
+	cal[0] = 3
+	copy(cal[95:], strField("WDB1234567890ABCD", 17))
+	cal[127], cal[128] = 0x1F, 0x40
+	out = append(out, cal...)
+	out = append(out, make([]byte, 128)...)
+	return out
+}
+
+func TestParseVuBytes(t *testing.T) {
+	v, err := ParseVuBytes(buildVu())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Report.IsComplete() {
+		t.Fatalf("incomplete report: %+v", v.Report)
+	}
+
+	o := v.Overview
+	if o.VehicleIdentificationNumber != "WDB1234567890ABCD" || o.VehicleRegistrationNumber != "AB123" ||
+		!o.CurrentDateTime.Equal(day0) || o.VehicleRegistrationNationIso == nil {
+		t.Errorf("unexpected overview: %+v", o)
+	}
+	if len(o.CompanyLocks) != 1 || o.CompanyLocks[0].CompanyName != "ACME" || len(o.ControlActivities) != 0 {
+		t.Errorf("unexpected locks %+v, controls %+v", o.CompanyLocks, o.ControlActivities)
+	}
+
+	if len(v.Activities) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(v.Activities))
+	}
+	for d, day := range v.Activities {
+		if !day.TimeReal.Equal(day0.AddDate(0, 0, d)) || day.OdometerValueMidnight != 0x1000 {
+			t.Errorf("day %d: unexpected header %v %d", d, day.TimeReal, day.OdometerValueMidnight)
+		}
+		if len(day.CardIWRecords) != 1 || day.CardIWRecords[0].HolderSurname != "DOE" {
+			t.Errorf("day %d: unexpected card records %+v", d, day.CardIWRecords)
+		}
+		if len(day.ActivityChangeInfos) != 3 {
+			t.Errorf("day %d: expected 3 activity changes, got %d", d, len(day.ActivityChangeInfos))
+		}
+	}
+
+	if len(v.DetailedSpeed) != 1 || v.DetailedSpeed[0].SpeedsPerSecond[6] != 80 {
+		t.Errorf("unexpected detailed speed %+v", v.DetailedSpeed)
+	}
+	cal := v.TechnicalData.CalibrationRecords
+	if len(cal) != 1 || cal[0].CalibrationPurpose != 3 || cal[0].VehicleIdentificationNumber != "WDB1234567890ABCD" ||
+		cal[0].WVehicleCharacteristicConst != 8000 {
+		t.Errorf("unexpected calibrations %+v", cal)
+	}
+}
+
+func TestParseVuBytesTruncated(t *testing.T) {
+	ddd := buildVu()
+	for n := 1; n < len(ddd); n++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic on file truncated to %d bytes: %v", n, r)
+				}
+			}()
+			ParseVuBytes(ddd[:n])
+		}()
+	}
+
+	// второй блок суточной деятельности обрезан: первые сутки сохраняются
+	v, err := ParseVuBytes(ddd[:800])
+	if err == nil || len(v.Activities) != 1 {
+		t.Fatalf("expected error and 1 day, got %v and %d days", err, len(v.Activities))
+	}
+	if v.Report.IsComplete() {
+		t.Error("report of a truncated file is complete")
+	}
+	var decodeErr *DecodeError
+	if _, err := extractVuBlocks(ddd[:800]); !errors.As(err, &decodeErr) {
+		t.Errorf("expected DecodeError, got %v", err)
+	}
+}
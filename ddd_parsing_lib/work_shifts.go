@@ -0,0 +1,129 @@
+package ddd
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Ежедневный период работы (смена), составленный из записей о местах начала и окончания.
+// Если для начала не найдено окончание (или наоборот), соответствующие поля не заполняются
+// и устанавливается флаг MissingEnd (MissingBegin). Смена без окончания может быть текущей.
+type WorkShift struct {
+	BeginTime       *time.Time                `json:"begin_time,omitempty"`
+	BeginType       *EntryTypeDailyWorkPeriod `json:"begin_type,omitempty"`
+	BeginTypeName   string                    `code:"BeginType" json:"begin_type_name,omitempty"`
+	BeginCountry    int                       `json:"begin_country"`
+	BeginCountryIso *NationInfo               `iso:"BeginCountry" json:"begin_country_iso,omitempty"`
+	BeginRegion     int                       `json:"begin_region"`
+	BeginRegionIso  *RegionInfo               `iso:"BeginRegion BeginCountry" json:"begin_region_iso,omitempty"`
+	BeginOdometer   int                       `json:"begin_odometer"`
+	EndTime         *time.Time                `json:"end_time,omitempty"`
+	EndType         *EntryTypeDailyWorkPeriod `json:"end_type,omitempty"`
+	EndTypeName     string                    `code:"EndType" json:"end_type_name,omitempty"`
+	EndCountry      int                       `json:"end_country"`
+	EndCountryIso   *NationInfo               `iso:"EndCountry" json:"end_country_iso,omitempty"`
+	EndRegion       int                       `json:"end_region"`
+	EndRegionIso    *RegionInfo               `iso:"EndRegion EndCountry" json:"end_region_iso,omitempty"`
+	EndOdometer     int                       `json:"end_odometer"`
+	Distance        int                       `json:"distance"`
+	MissingBegin    bool                      `json:"missing_begin"`
+	MissingEnd      bool                      `json:"missing_end"`
+}
+
+type WorkShifts []WorkShift
+
+// Показания одометра ТС (OdometerShort) шестизначные: после 999 999 км отсчет начинается с нуля.
+// Уменьшение показаний считается переходом через ноль, только если пробег с учетом перехода
+// не превышает odometerMaxRolloverDistance.
+const (
+	odometerRollover            = 1000000
+	odometerMaxRolloverDistance = 50000
+)
+
+// Функция возвращает пробег между показаниями одометра begin и end с учетом перехода через ноль.
+// Если показания уменьшились и переход через ноль невозможен, возвращается false.
+func odometerDistance(begin int, end int) (int, bool) {
+	if end >= begin {
+		return end - begin, true
+	}
+	distance := end + odometerRollover - begin
+	if begin >= odometerRollover || distance > odometerMaxRolloverDistance {
+		return 0, false
+	}
+	return distance, true
+}
+
+// Метод составляет смены из записей о местах начала и окончания ежедневного периода работы.
+// Записи упорядочиваются по времени ввода, каждое начало объединяется с ближайшим следующим
+// окончанием. Пробег считается, только если известны показания одометра в начале и в конце,
+// с учетом перехода одометра через ноль.
+func (p PlaceRecords) WorkShifts() WorkShifts {
+	sorted := make(PlaceRecords, len(p))
+	copy(sorted, p)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EntryTime.Before(sorted[j].EntryTime)
+	})
+
+	result := WorkShifts{}
+	var open *WorkShift
+	for i := range sorted {
+		rec := sorted[i]
+		if rec.TypePeriodId.IsBegin() {
+			if open != nil {
+				open.MissingEnd = true
+				result = append(result, *open)
+			}
+			open = &WorkShift{
+				BeginTime:     &rec.EntryTime,
+				BeginType:     &rec.TypePeriodId,
+				BeginCountry:  rec.DailyWorkPeriodCountry,
+				BeginRegion:   rec.DailyWorkPeriodRegion,
+				BeginOdometer: rec.VehicleOdometerValue,
+			}
+			continue
+		}
+
+		shift := WorkShift{MissingBegin: true}
+		if open != nil {
+			shift = *open
+			open = nil
+		}
+		shift.EndTime = &rec.EntryTime
+		shift.EndType = &rec.TypePeriodId
+		shift.EndCountry = rec.DailyWorkPeriodCountry
+		shift.EndRegion = rec.DailyWorkPeriodRegion
+		shift.EndOdometer = rec.VehicleOdometerValue
+		if !shift.MissingBegin {
+			shift.Distance, _ = odometerDistance(shift.BeginOdometer, shift.EndOdometer)
+		}
+		result = append(result, shift)
+	}
+	if open != nil {
+		open.MissingEnd = true
+		result = append(result, *open)
+	}
+
+	for i := range result {
+		fillIsoCodes(reflect.ValueOf(&result[i]).Elem())
+		fillCodeNames(reflect.ValueOf(&result[i]).Elem())
+	}
+
+	return result
+}
+
+// Метод составляет смены из записей о местах карты второго поколения
+func (p PlaceRecordsG2) WorkShifts() WorkShifts {
+	places := PlaceRecords{}
+	for _, r := range p {
+		places = append(places, PlaceRecord{
+			EntryTime:              r.EntryTime,
+			TypePeriodId:           r.TypePeriodId,
+			DailyWorkPeriodCountry: r.DailyWorkPeriodCountry,
+			DailyWorkPeriodRegion:  r.DailyWorkPeriodRegion,
+			VehicleOdometerValue:   r.VehicleOdometerValue,
+		})
+	}
+
+	return places.WorkShifts()
+}
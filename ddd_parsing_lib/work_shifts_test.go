@@ -0,0 +1,86 @@
+package ddd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaceRecordsWorkShifts(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	// записи не упорядочены, у первой смены нет начала, у двух последних - окончания
+	places := PlaceRecords{
+		{EntryTime: t0.Add(10 * time.Hour), TypePeriodId: EntryEndRelatedToCard, DailyWorkPeriodCountry: 0x11, VehicleOdometerValue: 1500},
+		{EntryTime: t0, TypePeriodId: EntryBeginRelatedToCard, DailyWorkPeriodCountry: 0x0F, DailyWorkPeriodRegion: 0x0E, VehicleOdometerValue: 1000},
+		{EntryTime: t0.Add(-30 * time.Hour), TypePeriodId: EntryEndManual, DailyWorkPeriodCountry: 0x0D},
+		{EntryTime: t0.Add(24 * time.Hour), TypePeriodId: EntryBeginAssumedByVu, DailyWorkPeriodCountry: 0x11, VehicleOdometerValue: 1500},
+		{EntryTime: t0.Add(48 * time.Hour), TypePeriodId: EntryBeginManual, DailyWorkPeriodCountry: 0x11, VehicleOdometerValue: 1900},
+	}
+	s := places.WorkShifts()
+	if len(s) != 4 {
+		t.Fatalf("expected 4 shifts, got %+v", s)
+	}
+	if !s[0].MissingBegin || s[0].BeginTime != nil || s[0].EndCountryIso == nil || s[0].EndCountryIso.Alpha2 != "DE" {
+		t.Errorf("unexpected shift without begin %+v", s[0])
+	}
+	if s[1].MissingBegin || s[1].MissingEnd || s[1].Distance != 500 || s[1].BeginRegionIso == nil || s[1].BeginRegionIso.Code != "ES-MD" || !s[1].EndTime.Equal(t0.Add(10*time.Hour)) {
+		t.Errorf("unexpected complete shift %+v", s[1])
+	}
+	if !s[2].MissingEnd || *s[2].BeginType != EntryBeginAssumedByVu || !s[3].MissingEnd || s[3].EndTime != nil {
+		t.Errorf("unexpected shifts without end %+v", s[2:])
+	}
+	js, _ := json.Marshal(s[2])
+	if strings.Contains(string(js), "end_time") {
+		t.Errorf("unexpected end time in %s", js)
+	}
+
+	c, _ := ParseBytes(buildDriverDDD())
+	if len(c.WorkShifts) == 0 {
+		t.Errorf("work shifts are not built from places %+v", c.PlaceRecords)
+	}
+}
+
+func TestOdometerDistance(t *testing.T) {
+	tests := []struct {
+		begin    int
+		end      int
+		expected int
+		ok       bool
+	}{
+		{begin: 1000, end: 1500, expected: 500, ok: true},
+		{begin: 1000, end: 1000, expected: 0, ok: true},
+		{begin: 999800, end: 150, expected: 350, ok: true},
+		{begin: 999999, end: 0, expected: 1, ok: true},
+		{begin: 500000, end: 400000},
+		{begin: 1000, end: 900},
+		{begin: 1200000, end: 150},
+	}
+	for _, tt := range tests {
+		distance, ok := odometerDistance(tt.begin, tt.end)
+		if distance != tt.expected || ok != tt.ok {
+			t.Errorf("%d -> %d: expected %d %v, got %d %v", tt.begin, tt.end, tt.expected, tt.ok, distance, ok)
+		}
+	}
+}
+
+func TestWorkShiftsOdometerRollover(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	places := PlaceRecords{
+		{EntryTime: begin, TypePeriodId: EntryBeginRelatedToCard, VehicleOdometerValue: 999700},
+		{EntryTime: begin.Add(10 * time.Hour), TypePeriodId: EntryEndRelatedToCard, VehicleOdometerValue: 300},
+		{EntryTime: begin.Add(24 * time.Hour), TypePeriodId: EntryBeginRelatedToCard, VehicleOdometerValue: 5000},
+		{EntryTime: begin.Add(34 * time.Hour), TypePeriodId: EntryEndRelatedToCard, VehicleOdometerValue: 4000},
+	}
+
+	shifts := places.WorkShifts()
+	if len(shifts) != 2 {
+		t.Fatalf("expected 2 shifts, got %+v", shifts)
+	}
+	if shifts[0].Distance != 600 {
+		t.Errorf("expected distance 600 across rollover, got %d", shifts[0].Distance)
+	}
+	if shifts[1].Distance != 0 {
+		t.Errorf("expected no distance for decreased odometer, got %d", shifts[1].Distance)
+	}
+}
@@ -0,0 +1,295 @@
+package ddd
+
+import (
+	"time"
+)
+
+// Нормы директивы 2002/15/EC в минутах
+const (
+	wtdAverageWeekly      = 48 * 60 // ст. 4(a): среднее рабочее время за неделю
+	wtdMaxWeekly          = 60 * 60 // ст. 4(a): рабочее время за одну неделю
+	wtdNightDaily         = 10 * 60 // ст. 7: рабочее время за 24 часа при работе в ночное время
+	wtdDailyRest          = 9 * 60  // отдых, завершающий период работы
+	wtdReferenceWeeks     = 17      // расчетный период по умолчанию (около четырех месяцев)
+	wtdDefaultNightLength = 4 * time.Hour
+)
+
+// Настройки расчета рабочего времени
+type WorkingTimeOptions struct {
+	// Длительность расчетного периода в календарных неделях. По умолчанию 17 недель (4 месяца).
+	ReferenceWeeks int
+	// Начало и окончание ночного времени, отсчитываемые от полуночи в часовом поясе Location. Если
+	// окончание меньше начала, ночное время переходит через полночь, если равно - ночное время
+	// не учитывается. По умолчанию с 00:00 до 04:00.
+	NightStart time.Duration
+	NightEnd   time.Duration
+	// Часовой пояс, в котором задано ночное время (национальное законодательство определяет его
+	// по местному времени). По умолчанию UTC.
+	Location *time.Location
+}
+
+// Функция возвращает настройки, в которых незаданные значения заменены значениями по умолчанию
+func (o WorkingTimeOptions) withDefaults() WorkingTimeOptions {
+	if o.ReferenceWeeks <= 0 {
+		o.ReferenceWeeks = wtdReferenceWeeks
+	}
+	if o.NightStart == 0 && o.NightEnd == 0 {
+		o.NightEnd = wtdDefaultNightLength
+	}
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	return o
+}
+
+// Рабочее время за календарную неделю или месяц. Рабочим временем считаются управление и работа,
+// время готовности не учитывается (ст. 3(a) директивы 2002/15/EC). Продолжительности в минутах.
+type WorkingTimeTotal struct {
+	Begin       time.Time `json:"begin"`
+	Driving     int       `json:"driving"`
+	Work        int       `json:"work"`
+	WorkingTime int       `json:"working_time"`
+	NightWork   int       `json:"night_work"`
+	NightShifts int       `json:"night_shifts"`
+}
+
+// Среднее рабочее время за неделю в расчетном периоде
+type ReferencePeriod struct {
+	Begin         time.Time `json:"begin"`
+	End           time.Time `json:"end"`
+	Weeks         int       `json:"weeks"`
+	WorkingTime   int       `json:"working_time"`
+	AverageWeekly int       `json:"average_weekly"`
+}
+
+// Отчет о рабочем времени водителя
+type WorkingTimeReport struct {
+	Regulation       string             `json:"regulation"`
+	ReferenceWeeks   int                `json:"reference_weeks"`
+	Weeks            []WorkingTimeTotal `json:"weeks"`
+	Months           []WorkingTimeTotal `json:"months"`
+	ReferencePeriods []ReferencePeriod  `json:"reference_periods"`
+	Infringements    []Infringement     `json:"infringements"`
+}
+
+// Функция рассчитывает рабочее время по директиве 2002/15/EC: рабочее время по календарным
+// неделям и месяцам, среднее рабочее время за неделю в расчетных периодах (не более 48 ч),
+// рабочее время за неделю (не более 60 ч) и рабочее время за 24 часа при работе в ночное время
+// (не более 10 ч). Расчетные периоды отсчитываются от недели первой записи, для последнего
+// неполного периода среднее считается по прошедшим неделям. Перерывы (ст. 5) не проверяются.
+func AnalyzeWorkingTime(records ActivityDailyRecords, opts WorkingTimeOptions) WorkingTimeReport {
+	opts = opts.withDefaults()
+	report := WorkingTimeReport{
+		Regulation:       "Directive 2002/15/EC",
+		ReferenceWeeks:   opts.ReferenceWeeks,
+		Weeks:            []WorkingTimeTotal{},
+		Months:           []WorkingTimeTotal{},
+		ReferencePeriods: []ReferencePeriod{},
+		Infringements:    []Infringement{},
+	}
+
+	spans := buildActivityTimeline(records)
+	if len(spans) == 0 {
+		return report
+	}
+
+	nightShifts := checkWTDNightWork(spans, opts, &report)
+
+	first := spans[0].Start
+	last := spans[len(spans)-1].End
+	for week := weekStart(first); week.Before(last); week = week.AddDate(0, 0, 7) {
+		total := workingTimeTotal(spans, week, week.AddDate(0, 0, 7), nightShifts, opts)
+		report.Weeks = append(report.Weeks, total)
+		if total.WorkingTime > wtdMaxWeekly {
+			report.Infringements = append(report.Infringements, Infringement{
+				Rule:        "WTD_WEEKLY_WORKING_TIME",
+				Description: "Weekly working time exceeds 60 hours (Art. 4(a))",
+				Severity:    excessSeverity(total.WorkingTime, wtdMaxWeekly),
+				Begin:       week,
+				End:         week.AddDate(0, 0, 7),
+				Limit:       wtdMaxWeekly,
+				Actual:      total.WorkingTime,
+			})
+		}
+	}
+
+	for month := monthStart(first); month.Before(last); month = month.AddDate(0, 1, 0) {
+		report.Months = append(report.Months, workingTimeTotal(spans, month, month.AddDate(0, 1, 0), nightShifts, opts))
+	}
+
+	for i := 0; i < len(report.Weeks); i += opts.ReferenceWeeks {
+		end := i + opts.ReferenceWeeks
+		if end > len(report.Weeks) {
+			end = len(report.Weeks)
+		}
+		period := ReferencePeriod{
+			Begin: report.Weeks[i].Begin,
+			End:   report.Weeks[end-1].Begin.AddDate(0, 0, 7),
+			Weeks: end - i,
+		}
+		for _, w := range report.Weeks[i:end] {
+			period.WorkingTime += w.WorkingTime
+		}
+		period.AverageWeekly = period.WorkingTime / period.Weeks
+		report.ReferencePeriods = append(report.ReferencePeriods, period)
+
+		if period.AverageWeekly > wtdAverageWeekly {
+			report.Infringements = append(report.Infringements, Infringement{
+				Rule:        "WTD_AVERAGE_WEEKLY_WORKING_TIME",
+				Description: "Average weekly working time over the reference period exceeds 48 hours (Art. 4(a))",
+				Severity:    excessSeverity(period.AverageWeekly, wtdAverageWeekly),
+				Begin:       period.Begin,
+				End:         period.End,
+				Limit:       wtdAverageWeekly,
+				Actual:      period.AverageWeekly,
+			})
+		}
+	}
+
+	sortInfringements(report.Infringements)
+
+	return report
+}
+
+// Метод рассчитывает рабочее время водителя по данным карты
+func (c *Card) AnalyzeWorkingTime(opts WorkingTimeOptions) WorkingTimeReport {
+	return AnalyzeWorkingTime(c.activityRecords(), opts)
+}
+
+// Функция разбивает деятельность на периоды работы, разделенные отдыхом не менее 9 ч, и проверяет,
+// что в периодах с работой в ночное время рабочее время за 24 часа от начала периода не превышает
+// 10 ч (ст. 7). Возвращается начало каждого периода с работой в ночное время.
+func checkWTDNightWork(spans []activitySpan, opts WorkingTimeOptions, report *WorkingTimeReport) []time.Time {
+	nightShifts := []time.Time{}
+
+	checkPeriod := func(begin time.Time, end time.Time) {
+		night := 0
+		for _, s := range spans {
+			if (s.Kind == ActivityDriving || s.Kind == ActivityWork) && s.End.After(begin) && s.Start.Before(end) {
+				night += nightMinutes(clipSpan(s, begin, end), opts)
+			}
+		}
+		if night == 0 {
+			return
+		}
+		nightShifts = append(nightShifts, begin)
+
+		dayEnd := begin.Add(24 * time.Hour)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		working := sumWorkingTimeWTD(spans, begin, dayEnd)
+		if working > wtdNightDaily {
+			report.Infringements = append(report.Infringements, Infringement{
+				Rule:        "WTD_NIGHT_WORK",
+				Description: "Working time exceeds 10 hours in a 24-hour period with night work (Art. 7)",
+				Severity:    excessSeverity(working, wtdNightDaily),
+				Begin:       begin,
+				End:         dayEnd,
+				Limit:       wtdNightDaily,
+				Actual:      working,
+			})
+		}
+	}
+
+	periodBegin := spans[0].Start
+	if spans[0].Kind == ActivityBreakRest {
+		periodBegin = spans[0].End
+	}
+	for _, s := range spans {
+		if s.Kind != ActivityBreakRest || s.minutes() < wtdDailyRest || !s.Start.After(periodBegin) {
+			continue
+		}
+		checkPeriod(periodBegin, s.Start)
+		periodBegin = s.End
+	}
+	if end := spans[len(spans)-1].End; end.After(periodBegin) {
+		checkPeriod(periodBegin, end)
+	}
+
+	return nightShifts
+}
+
+// Функция подсчитывает рабочее время по директиве 2002/15/EC (управление и работа) в интервале [begin, end)
+func sumWorkingTimeWTD(spans []activitySpan, begin time.Time, end time.Time) int {
+	return sumActivity(spans, ActivityDriving, begin, end) + sumActivity(spans, ActivityWork, begin, end)
+}
+
+// Функция рассчитывает рабочее время в интервале [begin, end). Периоды работы с работой
+// в ночное время относятся к интервалу, в котором они начались.
+func workingTimeTotal(spans []activitySpan, begin time.Time, end time.Time, nightShifts []time.Time,
+	opts WorkingTimeOptions) WorkingTimeTotal {
+	total := WorkingTimeTotal{
+		Begin:   begin,
+		Driving: sumActivity(spans, ActivityDriving, begin, end),
+		Work:    sumActivity(spans, ActivityWork, begin, end),
+	}
+	total.WorkingTime = total.Driving + total.Work
+
+	for _, s := range spans {
+		if (s.Kind == ActivityDriving || s.Kind == ActivityWork) && s.End.After(begin) && s.Start.Before(end) {
+			total.NightWork += nightMinutes(clipSpan(s, begin, end), opts)
+		}
+	}
+	for _, t := range nightShifts {
+		if !t.Before(begin) && t.Before(end) {
+			total.NightShifts++
+		}
+	}
+
+	return total
+}
+
+// Функция обрезает период s по интервалу [begin, end)
+func clipSpan(s activitySpan, begin time.Time, end time.Time) activitySpan {
+	if s.Start.Before(begin) {
+		s.Start = begin
+	}
+	if s.End.After(end) {
+		s.End = end
+	}
+	return s
+}
+
+// Функция подсчитывает, сколько минут периода s приходится на ночное время. Ночное время
+// отсчитывается от полуночи по местному времени opts.Location (UTC, если пояс не задан).
+// Если начало и окончание ночного времени совпадают, ночного времени нет.
+func nightMinutes(s activitySpan, opts WorkingTimeOptions) int {
+	nightLength := opts.NightEnd - opts.NightStart
+	if nightLength == 0 {
+		return 0
+	}
+	if nightLength < 0 {
+		nightLength += 24 * time.Hour
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var result time.Duration
+	start := s.Start.In(loc)
+	// ночное время, начавшееся накануне, может продолжаться в день начала периода
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, -1)
+	for ; day.Before(s.End); day = day.AddDate(0, 0, 1) {
+		nightBegin := day.Add(opts.NightStart)
+		nightEnd := nightBegin.Add(nightLength)
+		from, to := s.Start, s.End
+		if from.Before(nightBegin) {
+			from = nightBegin
+		}
+		if to.After(nightEnd) {
+			to = nightEnd
+		}
+		if to.After(from) {
+			result += to.Sub(from)
+		}
+	}
+	return int(result / time.Minute)
+}
+
+// Функция возвращает начало календарного месяца (1 число 00:00 UTC), в который входит t
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
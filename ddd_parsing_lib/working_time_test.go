@@ -0,0 +1,136 @@
+package ddd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeWorkingTime(t *testing.T) {
+	// 10 ч управления и работы и 1 ч готовности пять дней в неделю
+	records := workDays(monday, 35, 5, [2]int{0, 0}, [2]int{3, 360}, [2]int{2, 600}, [2]int{1, 960}, [2]int{0, 1020})
+
+	r := AnalyzeWorkingTime(records, WorkingTimeOptions{ReferenceWeeks: 2})
+	if len(r.Weeks) != 5 || r.Weeks[0].WorkingTime != 3000 || r.Weeks[0].Driving != 1200 || r.Weeks[0].NightWork != 0 {
+		t.Errorf("unexpected weeks %+v", r.Weeks)
+	}
+	if len(r.ReferencePeriods) != 3 || r.ReferencePeriods[0].AverageWeekly != 3000 || r.ReferencePeriods[2].Weeks != 1 {
+		t.Errorf("unexpected reference periods %+v", r.ReferencePeriods)
+	}
+	if len(r.Months) != 2 || r.Months[0].WorkingTime != 23*600 ||
+		!r.Months[1].Begin.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected months %+v", r.Months)
+	}
+	checkInfringements(t, "50 hours a week", ComplianceReport{Infringements: r.Infringements},
+		map[string]int{"WTD_AVERAGE_WEEKLY_WORKING_TIME": 3},
+		map[string]expectedInfringement{"WTD_AVERAGE_WEEKLY_WORKING_TIME": {3000, SeverityMinor}})
+
+	r = AnalyzeWorkingTime(records, WorkingTimeOptions{})
+	if r.ReferenceWeeks != 17 || len(r.ReferencePeriods) != 1 {
+		t.Errorf("unexpected default reference periods %+v", r.ReferencePeriods)
+	}
+}
+
+func TestAnalyzeWorkingTimeNight(t *testing.T) {
+	// управление с 22:00 до 05:00 и работа до 11:00
+	records := ActivityDailyRecords{
+		dayRecord(monday, [2]int{0, 0}, [2]int{3, 1320}),
+		dayRecord(monday.AddDate(0, 0, 1), [2]int{3, 0}, [2]int{2, 300}, [2]int{0, 660}),
+	}
+
+	tests := []struct {
+		name       string
+		opts       WorkingTimeOptions
+		nightWork  int
+		infringing bool
+	}{
+		{name: "default window", opts: WorkingTimeOptions{}, nightWork: 240, infringing: true},
+		{name: "22:00-05:00", opts: WorkingTimeOptions{NightStart: 22 * time.Hour, NightEnd: 5 * time.Hour},
+			nightWork: 420, infringing: true},
+		{name: "daytime window", opts: WorkingTimeOptions{NightStart: 12 * time.Hour, NightEnd: 16 * time.Hour}},
+		// совпадающие начало и окончание означают, что ночного времени нет
+		{name: "empty window", opts: WorkingTimeOptions{NightStart: 5 * time.Hour, NightEnd: 5 * time.Hour}},
+	}
+	for _, tt := range tests {
+		r := AnalyzeWorkingTime(records, tt.opts)
+		if r.Weeks[0].NightWork != tt.nightWork {
+			t.Errorf("%s: expected night work %d, got %d", tt.name, tt.nightWork, r.Weeks[0].NightWork)
+		}
+		counts := map[string]int{}
+		shifts := 0
+		if tt.infringing {
+			counts["WTD_NIGHT_WORK"] = 1
+			shifts = 1
+		}
+		if r.Weeks[0].NightShifts != shifts {
+			t.Errorf("%s: expected %d night shifts, got %d", tt.name, shifts, r.Weeks[0].NightShifts)
+		}
+		checkInfringements(t, tt.name, ComplianceReport{Infringements: r.Infringements}, counts,
+			map[string]expectedInfringement{"WTD_NIGHT_WORK": {780, SeverityVerySerious}})
+	}
+}
+
+func TestNightMinutes(t *testing.T) {
+	night := WorkingTimeOptions{NightStart: 22 * time.Hour, NightEnd: 5 * time.Hour}
+	msk := night
+	msk.Location = time.FixedZone("MSK", 3*60*60)
+	at := func(day int, hour int, minute int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name     string
+		span     activitySpan
+		opts     WorkingTimeOptions
+		expected int
+	}{
+		{name: "evening", span: activitySpan{Start: at(0, 20, 0), End: at(0, 23, 0)}, opts: night, expected: 60},
+		{name: "whole night", span: activitySpan{Start: at(0, 21, 0), End: at(1, 7, 0)}, opts: night, expected: 420},
+		{name: "early morning", span: activitySpan{Start: at(1, 3, 0), End: at(1, 6, 0)}, opts: night, expected: 120},
+		{name: "daytime", span: activitySpan{Start: at(1, 5, 0), End: at(1, 22, 0)}, opts: night},
+		{name: "two days", span: activitySpan{Start: at(0, 0, 0), End: at(2, 0, 0)}, opts: night, expected: 840},
+		{name: "local time", span: activitySpan{Start: at(0, 18, 30), End: at(0, 23, 0)}, opts: msk, expected: 240},
+		{name: "local morning", span: activitySpan{Start: at(1, 1, 0), End: at(1, 3, 0)}, opts: msk, expected: 60},
+		{name: "empty window", span: activitySpan{Start: at(0, 0, 0), End: at(2, 0, 0)},
+			opts: WorkingTimeOptions{NightStart: 3 * time.Hour, NightEnd: 3 * time.Hour}},
+	}
+	for _, tt := range tests {
+		if got := nightMinutes(tt.span, tt.opts.withDefaults()); got != tt.expected {
+			t.Errorf("%s: expected %d night minutes, got %d", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestNightShifts(t *testing.T) {
+	at := func(day int, hour int, minute int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	spans := []activitySpan{
+		{Start: at(0, 0, 0), End: at(0, 6, 0), Kind: ActivityBreakRest},
+		{Start: at(0, 6, 0), End: at(0, 14, 0), Kind: ActivityDriving},
+		{Start: at(0, 14, 0), End: at(1, 20, 0), Kind: ActivityBreakRest},
+		{Start: at(1, 20, 0), End: at(2, 7, 0), Kind: ActivityDriving},
+		{Start: at(2, 7, 0), End: at(2, 16, 0), Kind: ActivityBreakRest},
+		{Start: at(2, 16, 0), End: at(2, 22, 30), Kind: ActivityWork},
+		{Start: at(2, 22, 30), End: at(3, 8, 0), Kind: ActivityBreakRest},
+	}
+
+	tests := []struct {
+		name   string
+		opts   WorkingTimeOptions
+		shifts []time.Time
+	}{
+		{name: "default window", opts: WorkingTimeOptions{}, shifts: []time.Time{at(1, 20, 0)}},
+		{name: "22:00-05:00", opts: WorkingTimeOptions{NightStart: 22 * time.Hour, NightEnd: 5 * time.Hour},
+			shifts: []time.Time{at(1, 20, 0), at(2, 16, 0)}},
+	}
+	for _, tt := range tests {
+		report := WorkingTimeReport{}
+		shifts := checkWTDNightWork(spans, tt.opts.withDefaults(), &report)
+		if !reflect.DeepEqual(shifts, tt.shifts) {
+			t.Errorf("%s: expected night shifts %v, got %v", tt.name, tt.shifts, shifts)
+		}
+		checkInfringements(t, tt.name, ComplianceReport{Infringements: report.Infringements},
+			map[string]int{"WTD_NIGHT_WORK": 1}, map[string]expectedInfringement{"WTD_NIGHT_WORK": {660, SeveritySerious}})
+	}
+}
@@ -0,0 +1,3 @@
+module tcreader
+
+go 1.20